@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"tachigoma/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+var maxComplexity int
+
+var checkCmd = &cobra.Command{
+	Use:   "check [path]",
+	Short: "Report Go functions whose cyclomatic complexity is too high.",
+	Long: `Computes the cyclomatic complexity of every function in a Go file or package directory.
+Exits with status 1 if any function exceeds --max-complexity, so it can be used in CI.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		results, err := tools.AnalyzeComplexity(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		var failing []tools.FunctionComplexity
+		for _, r := range results {
+			if r.Complexity > maxComplexity {
+				failing = append(failing, r)
+			}
+		}
+
+		if len(failing) == 0 {
+			fmt.Printf("All %d functions are within the complexity limit of %d.\n", len(results), maxComplexity)
+			return
+		}
+
+		fmt.Println(tools.FormatComplexity(failing, maxComplexity))
+		fmt.Fprintf(os.Stderr, "%d function(s) exceed the complexity limit of %d.\n", len(failing), maxComplexity)
+		os.Exit(1)
+	},
+}
+
+func init() {
+	checkCmd.Flags().IntVar(&maxComplexity, "max-complexity", tools.DefaultComplexityThreshold, "Fail if any function's cyclomatic complexity is above this value.")
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"tachigoma/internal/llm"
+	"tachigoma/internal/tools"
+
+	"github.com/spf13/cobra"
+)
+
+// configTemplate documents every configuration key. Settings that are commented out
+// show their default value, or an example if they have none.
+var configTemplate = template.Must(template.New("config").Parse(`# .tachigoma.yaml
+# Tachigoma reads this file from the current directory or your home directory.
+
+# OpenAI-compatible API endpoint, key and model
+api_url: "{{.api_url}}"
+api_key: "sk-xxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"
+model: "{{.model}}"
+
+# Named profiles, selected with --profile <name>. Each one overrides
+# api_url, api_key and model; keys it leaves out fall back to the values above.
+# profiles:
+#   openai:
+#     api_url: "https://api.openai.com/v1"
+#     api_key: "sk-xxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"
+#     model: "gpt-4o-mini"
+#   ollama:
+#     api_url: "http://localhost:11434/v1"
+#     api_key: "ollama"
+#     model: "qwen2.5-coder"
+
+# max_response_tokens: {{.max_response_tokens}} # 0 means no limit. Also accepted as max_tokens
+# temperature: 0.7 # 0.0 to 2.0; the server default is used if unset
+# seed: 42 # Deterministic sampling, if the API supports it
+# stop_sequences: # The model stops generating before any of these; none are sent if unset
+#   - "</answer>"
+#   - "###"
+# max_tool_iterations: {{.max_tool_iterations}}
+# context_limit: {{.context_limit}} # Context window in tokens (about 4 characters each): warn at 90%, summarize older messages beyond it; 0 disables
+# allowed_base_path: "." # File tools may only access paths inside this directory
+# tool_plugin_dir: "~/.tachigoma/plugins" # Go plugins (*.so) providing extra tools, see examples/plugin
+# tools_enabled: {{.tools_enabled}} # false disables tools like --no-tools
+# http_timeout_seconds: {{.http_timeout_seconds}}
+# shell_command_timeout: {{.shell_command_timeout}}
+# shell_denylist: # Commands run_shell_command refuses; replaces the built-in list of destructive commands
+#   - "git push --force"
+#   - "re:\\bsudo\\b" # Patterns starting with re: are regular expressions
+# append_requires_confirmation: {{.append_requires_confirmation}} # Ask before append_file runs, like write_file
+# audit_log: "~/.tachigoma/audit.log" # Append every tool execution as a JSON line (secrets redacted)
+# parallel_tools: {{.parallel_tools}} # Run several tool calls of one response at once if none needs confirmation
+# tool_cache: {{.tool_cache}} # Reuse the results of identical read-only tool calls
+# tool_cache_ttl_seconds: {{.tool_cache_ttl_seconds}}
+# proxy_url: "http://proxy.example.com:8080" # Proxy for API requests; HTTP_PROXY and HTTPS_PROXY are used if unset
+# request_timeout_seconds: {{.request_timeout_seconds}}
+# max_retries: {{.max_retries}}
+# retry_backoff_seconds: {{.retry_backoff_seconds}}
+# theme: "{{.theme}}" # auto, dark, light, dracula, tokyo-night or none (raw text); changes apply while the TUI runs
+# model_pricing: # USD per 1K tokens for the cost shown in the TUI; overrides ~/.tachigoma/pricing.yaml and the built-in prices
+#   gpt-4:
+#     input: 0.03
+#     output: 0.06
+# cost_warning_threshold: {{printf "%.2f" .cost_warning_threshold}} # The session cost turns red above this many USD
+# demo_mode_delay_ms: {{.demo_mode_delay_ms}} # Delay between words with --demo-mode
+# system_prompt: "You are a concise assistant for Go projects."
+`))
+
+var (
+	configGlobal bool
+	configStdout bool
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the configuration file.",
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a starter configuration file.",
+	Long: `Writes a .tachigoma.yaml documenting every setting, with its default value, to the current
+directory, or to your home directory with --global. If the file already exists, the changes are
+shown and you are asked before it is overwritten. Use --stdout to print the file instead.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		content, err := renderConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if configStdout {
+			fmt.Print(content)
+			return
+		}
+
+		path := ".tachigoma.yaml"
+		if configGlobal {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding home directory: %v\n", err)
+				os.Exit(1)
+			}
+			path = filepath.Join(home, ".tachigoma.yaml")
+		}
+
+		if existing, err := os.ReadFile(path); err == nil {
+			diff := tools.UnifiedDiff(path, path, string(existing), content)
+			if diff == "" {
+				fmt.Printf("%s is already up to date.\n", path)
+				return
+			}
+			fmt.Print(diff)
+			if !confirm(fmt.Sprintf("Overwrite %s?", path)) {
+				fmt.Println("Aborted.")
+				return
+			}
+		} else if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Error reading '%s': %v\n", path, err)
+			os.Exit(1)
+		}
+
+		// The file holds the API key, so only the owner may read it
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s. Set api_key and model to get started.\n", path)
+	},
+}
+
+// renderConfig returns the starter config file filled in with the default settings.
+func renderConfig() (string, error) {
+	data := map[string]any{"max_tool_iterations": llm.DefaultMaxToolIterations}
+	for key, value := range configDefaults {
+		data[key] = value
+	}
+
+	var b bytes.Buffer
+	if err := configTemplate.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("error rendering config file: %w", err)
+	}
+	return b.String(), nil
+}
+
+// stdinReader is shared by every prompt, so answers piped in together are not lost to buffering.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// confirm asks a yes/no question on stdin. The question goes to stderr to keep stdout
+// clean for output. Anything but "y" or "yes" means no.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := stdinReader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func init() {
+	configInitCmd.Flags().BoolVar(&configGlobal, "global", false, "Write ~/.tachigoma.yaml instead of ./.tachigoma.yaml.")
+	configInitCmd.Flags().BoolVar(&configStdout, "stdout", false, "Print the file instead of writing it.")
+	configCmd.AddCommand(configInitCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tachigoma/internal/session"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergeSessionA string
+	mergeSessionB string
+	mergeOutput   string
+	mergeStrategy string
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "Merge two session files into a single conversation.",
+	Long: `Combines two saved sessions into one. Session A's system message is kept and B's is dropped.
+With --strategy interleave (the default) turns are ordered by timestamp; with append, all of A comes before all of B.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		a, err := session.Load(mergeSessionA)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		b, err := session.Load(mergeSessionB)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		merged, err := session.Merge(a, b, mergeStrategy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		merged.ID = strings.TrimSuffix(filepath.Base(mergeOutput), ".json")
+
+		if err := merged.SaveTo(mergeOutput); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Merged %d + %d messages into %s (%d messages).\n", len(a.Messages), len(b.Messages), mergeOutput, len(merged.Messages))
+	},
+}
+
+func init() {
+	mergeCmd.Flags().StringVar(&mergeSessionA, "session-a", "", "The first session file. Its system message is kept.")
+	mergeCmd.Flags().StringVar(&mergeSessionB, "session-b", "", "The second session file.")
+	mergeCmd.Flags().StringVar(&mergeOutput, "output", "", "Where to write the merged session.")
+	mergeCmd.Flags().StringVar(&mergeStrategy, "strategy", session.StrategyInterleave, "How to combine the sessions: interleave (by timestamp) or append (A then B).")
+	mergeCmd.MarkFlagRequired("session-a")
+	mergeCmd.MarkFlagRequired("session-b")
+	mergeCmd.MarkFlagRequired("output")
+	rootCmd.AddCommand(mergeCmd)
+}
@@ -1,20 +1,47 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"tachigoma/internal/audit"
 	"tachigoma/internal/llm"
+	"tachigoma/internal/session"
 	"tachigoma/internal/tui"
 
 	"github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 var (
-	prompt string
+	prompt     string
+	quiet      bool
+	jsonOutput bool
+	assumeYes  bool
+	autoEval   bool
+	demoMode   bool
+	planTools  bool
+	sessionID  string
+	promptFile string
+	profile    string
+	readOnly   bool
+	noTools    bool
+	jsonMode   bool
+	outputFmt  string
+	debug      bool
 )
 
 var rootCmd = &cobra.Command{
@@ -33,7 +60,22 @@ var rootCmd = &cobra.Command{
 			currentPrompt = strings.Join(args, " ")
 		}
 
-		if promptProvided {
+		if jsonOutput {
+			if cmd.Flags().Changed("output") && outputFmt != "json" {
+				fmt.Fprintf(os.Stderr, "Error: --json conflicts with --output %s\n", outputFmt)
+				os.Exit(1)
+			}
+			outputFmt = "json"
+		}
+		if outputFmt != "text" && outputFmt != "json" {
+			fmt.Fprintf(os.Stderr, "Error: invalid --output '%s': use text or json\n", outputFmt)
+			os.Exit(1)
+		}
+
+		if promptProvided && quiet {
+			// Scripting mode: print only the final response.
+			quietAPICall(currentPrompt)
+		} else if promptProvided {
 			// If a prompt is given, perform a direct API call and exit.
 			directAPICall(currentPrompt)
 		} else {
@@ -43,27 +85,36 @@ var rootCmd = &cobra.Command{
 	},
 }
 
-// directAPICall handles the one-off command mode.
+// errAPIKeyNotSet is reported when a prompt is given but no API key is configured.
+var errAPIKeyNotSet = errors.New("API key is not set. Please configure it in .tachigoma.yaml or environment variables; run `tachigoma config init` to create a config file.")
+
+// directAPICall handles the one-off command mode. Tool calls are executed as in the TUI,
+// asking on the terminal before running tools that require confirmation.
 func directAPICall(p string) {
 	apiKey := viper.GetString("api_key")
 	apiURL := viper.GetString("api_url")
 	model := viper.GetString("model")
 
 	if apiKey == "" {
-		fmt.Println("API key is not set. Please configure it in .tachigoma.yaml or environment variables.")
-		os.Exit(1)
+		exitWithError(errAPIKeyNotSet)
 	}
 
-	client := llm.NewClient(apiURL, apiKey)
+	client := llm.NewClient(apiURL, apiKey, clientOptions()...)
+	agent := llm.NewAgent(client, model, append(agentOptions(), llm.WithConfirmFunc(confirmToolCall))...)
 
-	fmt.Println("You:", p)
-	fmt.Print("Tachigoma: ...")
-
-	messages := []llm.Message{
-		{Role: "user", Content: p},
+	if outputFmt == "text" {
+		fmt.Println("You:", p)
+		fmt.Print("Tachigoma: ...")
 	}
 
-	response, err := client.Completion(messages, model)
+	response, err := agent.Run(p)
+	if err == nil {
+		saveSession(agent)
+	}
+	if outputFmt == "json" {
+		printJSONResult(p, response, model, agent.GetViewState().Usage, err)
+		return
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "\nError calling LLM API: %v\n", err)
 		os.Exit(1)
@@ -72,6 +123,100 @@ func directAPICall(p string) {
 	fmt.Printf("\rTachigoma: %s  \n", response)
 }
 
+// confirmToolCall asks on the terminal whether a tool call may run.
+func confirmToolCall(toolCall llm.ToolCall) bool {
+	fmt.Fprintf(os.Stderr, "\nTachigoma wants to run the tool: %s\nArguments: %s\n", toolCall.Function.Name, toolCall.Function.Arguments)
+	return confirm("Do you want to allow this?")
+}
+
+// refuseToolCall denies a tool call in scripting mode, where nobody can be asked, and notes it on stderr.
+func refuseToolCall(toolCall llm.ToolCall) bool {
+	fmt.Fprintf(os.Stderr, "Refused tool %s: it requires confirmation; pass --yes to allow it.\n", toolCall.Function.Name)
+	return false
+}
+
+// saveSession saves the agent's session, if it has one, and reports where on stderr.
+func saveSession(agent *llm.Agent) {
+	if err := agent.SaveSession(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving session: %v\n", err)
+	} else if id := agent.SessionID(); id != "" {
+		fmt.Fprintf(os.Stderr, "Session saved: %s\n", id)
+	}
+}
+
+// printJSONResult writes the result of a one-off prompt as a JSON object for scripts.
+// If err is not nil it is reported by exitWithError instead.
+func printJSONResult(p, response, model string, usage llm.Usage, err error) {
+	if err != nil {
+		exitWithError(err)
+	}
+
+	out, err := json.Marshal(map[string]any{
+		"prompt":      p,
+		"response":    response,
+		"model":       model,
+		"tokens_used": usage.TotalTokens,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding response: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
+
+// exitWithError reports an error that ends a one-off prompt and exits with status 1. With
+// --output json it goes to stderr as {"error": "...", "status": N}, with status 0 if no HTTP
+// response was received.
+func exitWithError(err error) {
+	if outputFmt != "json" {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	status := 0
+	var apiErr *llm.APIError
+	if errors.As(err, &apiErr) {
+		status = apiErr.StatusCode
+	}
+	out, _ := json.Marshal(map[string]any{"error": err.Error(), "status": status})
+	fmt.Fprintln(os.Stderr, string(out))
+	os.Exit(1)
+}
+
+// quietAPICall handles the scripting mode. Tools that require confirmation are refused unless
+// --yes is given, and only the final assistant text (or the --output json object) is written to stdout.
+func quietAPICall(p string) {
+	apiKey := viper.GetString("api_key")
+	apiURL := viper.GetString("api_url")
+	model := viper.GetString("model")
+
+	if apiKey == "" {
+		exitWithError(errAPIKeyNotSet)
+	}
+
+	client := llm.NewClient(apiURL, apiKey, clientOptions()...)
+	opts := agentOptions()
+	if !assumeYes {
+		opts = append(opts, llm.WithConfirmFunc(refuseToolCall))
+	}
+	agent := llm.NewAgent(client, model, opts...)
+
+	response, err := agent.Run(p)
+	if err == nil {
+		saveSession(agent)
+	}
+	if outputFmt == "json" {
+		printJSONResult(p, response, model, agent.GetViewState().Usage, err)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error calling LLM API: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(response)
+}
+
 // callTUI handles the interactive session mode.
 func callTUI() {
 	// We need to create the client and pass it to the TUI
@@ -80,21 +225,194 @@ func callTUI() {
 	model := viper.GetString("model")
 
 	if apiKey == "" {
-		fmt.Println("API key is not set. Please configure it in .tachigoma.yaml or environment variables.")
+		fmt.Println(errAPIKeyNotSet)
 		os.Exit(1)
 	}
 
-	client := llm.NewClient(apiURL, apiKey)
+	client := llm.NewClient(apiURL, apiKey, clientOptions()...)
+
+	tuiOptions := []tui.Option{tui.WithTheme(viper.GetString("theme"))}
+	if demoMode {
+		tuiOptions = append(tuiOptions, tui.WithDemoMode(time.Duration(viper.GetInt("demo_mode_delay_ms"))*time.Millisecond))
+	}
+	pricing, err := loadPricing()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if price, ok := pricing.Lookup(model); ok {
+		tuiOptions = append(tuiOptions, tui.WithCostEstimate(price, viper.GetFloat64("cost_warning_threshold")))
+	}
 
-	initialModel := tui.NewModel(client, model) // Pass client and model to TUI
+	initialModel := tui.NewModel(client, model, agentOptions(), tuiOptions...) // Pass client and model to TUI
 	program := tea.NewProgram(initialModel)
 
+	// Apply theme changes in the config file without a restart
+	if viper.ConfigFileUsed() != "" {
+		viper.OnConfigChange(func(fsnotify.Event) {
+			program.Send(tui.ThemeMsg{Theme: viper.GetString("theme")})
+		})
+		viper.WatchConfig()
+	}
+
 	if _, err := program.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// clientOptions builds the LLM client options from the loaded configuration.
+func clientOptions() []llm.ClientOption {
+	opts := []llm.ClientOption{
+		llm.WithTimeout(time.Duration(viper.GetInt("request_timeout_seconds")) * time.Second),
+		llm.WithRetry(viper.GetInt("max_retries"), time.Duration(viper.GetInt("retry_backoff_seconds"))*time.Second),
+	}
+	if debug {
+		opts = append(opts, llm.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	}
+	if proxy := viper.GetString("proxy_url"); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			exitWithError(fmt.Errorf("invalid proxy_url '%s': expected a URL like http://proxy.example.com:8080", proxy))
+		}
+		opts = append(opts, llm.WithProxy(proxyURL))
+	}
+	return opts
+}
+
+// agentOptions builds the agent options from the loaded configuration.
+func agentOptions() []llm.AgentOption {
+	opts := []llm.AgentOption{
+		llm.WithMaxResponseTokens(viper.GetInt("max_response_tokens")),
+		llm.WithAutoEval(autoEval),
+		llm.WithRequiresPlan(planTools),
+		llm.WithReadOnly(readOnly),
+		llm.WithTools(viper.GetBool("tools_enabled") && !noTools),
+		llm.WithHTTPTimeout(time.Duration(viper.GetInt("http_timeout_seconds")) * time.Second),
+		llm.WithContextLimit(viper.GetInt("context_limit")),
+		llm.WithShellTimeout(time.Duration(viper.GetInt("shell_command_timeout")) * time.Second),
+		llm.WithAppendConfirmation(viper.GetBool("append_requires_confirmation")),
+		llm.WithParallelTools(viper.GetBool("parallel_tools")),
+	}
+	if jsonMode {
+		opts = append(opts, llm.WithJSONMode())
+	}
+	if viper.IsSet("seed") {
+		opts = append(opts, llm.WithSeed(viper.GetInt("seed")))
+	}
+	if seqs := viper.GetStringSlice("stop_sequences"); len(seqs) > 0 {
+		opts = append(opts, llm.WithStopSequences(seqs...))
+	}
+	if viper.IsSet("temperature") {
+		opts = append(opts, llm.WithTemperature(viper.GetFloat64("temperature")))
+	}
+	if viper.IsSet("shell_denylist") {
+		opts = append(opts, llm.WithShellDenylist(viper.GetStringSlice("shell_denylist")))
+	}
+	if viper.GetBool("tool_cache") {
+		opts = append(opts, llm.WithToolCache(time.Duration(viper.GetInt("tool_cache_ttl_seconds"))*time.Second))
+	}
+	if dir := viper.GetString("allowed_base_path"); dir != "" {
+		opts = append(opts, llm.WithAllowedBasePath(expandHome(dir)))
+	}
+	if path := viper.GetString("audit_log"); path != "" {
+		logger, err := audit.NewFileAuditLogger(expandHome(path))
+		if err != nil {
+			exitWithError(err)
+		}
+		opts = append(opts, llm.WithAuditLogger(logger))
+	}
+	if dir := viper.GetString("tool_plugin_dir"); dir != "" {
+		opts = append(opts, llm.WithPluginDir(expandHome(dir)))
+	}
+	if viper.IsSet("max_tool_iterations") {
+		opts = append(opts, llm.WithMaxToolIterations(viper.GetInt("max_tool_iterations")))
+	}
+	systemPrompt, err := loadSystemPrompt()
+	if err != nil {
+		exitWithError(err)
+	}
+	if systemPrompt != "" {
+		opts = append(opts, llm.WithSystemPrompt(systemPrompt))
+	}
+	if sessionID != "" {
+		s, err := session.Open(sessionID)
+		if err != nil {
+			exitWithError(fmt.Errorf("opening session: %w", err))
+		}
+		opts = append(opts, llm.WithSession(s))
+	}
+	return opts
+}
+
+// expandHome replaces a leading ~ in path with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// pricingFile holds model prices that replace or extend llm.DefaultPricing.
+const pricingFile = "~/.tachigoma/pricing.yaml"
+
+// loadPricing returns the model prices used for cost estimates: the built-in defaults,
+// overridden by pricingFile if it exists and then by the model_pricing config key.
+func loadPricing() (llm.Pricing, error) {
+	pricing := llm.Pricing{}
+	for name, price := range llm.DefaultPricing {
+		pricing[name] = price
+	}
+
+	// Model names like gpt-4.1 contain dots, so they must not be split into nested keys
+	v := viper.NewWithOptions(viper.KeyDelimiter("::"))
+	v.SetConfigFile(expandHome(pricingFile))
+	v.SetConfigType("yaml")
+	if err := v.ReadInConfig(); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("error reading %s: %w", pricingFile, err)
+		}
+	} else {
+		var filePricing llm.Pricing
+		if err := v.Unmarshal(&filePricing); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", pricingFile, err)
+		}
+		maps.Copy(pricing, filePricing)
+	}
+
+	var configPricing llm.Pricing
+	if err := viper.UnmarshalKey("model_pricing", &configPricing); err != nil {
+		return nil, fmt.Errorf("invalid model_pricing: %w", err)
+	}
+	maps.Copy(pricing, configPricing)
+	return pricing, nil
+}
+
+// loadSystemPrompt returns the system prompt from the --system-prompt file or the system_prompt
+// config key, in that order. An empty result means the embedded default is used.
+func loadSystemPrompt() (string, error) {
+	if promptFile != "" {
+		data, err := os.ReadFile(promptFile)
+		if err != nil {
+			return "", fmt.Errorf("error reading system prompt file '%s': %w", promptFile, err)
+		}
+		if strings.TrimSpace(string(data)) == "" {
+			return "", fmt.Errorf("system prompt file '%s' is empty", promptFile)
+		}
+		return string(data), nil
+	}
+
+	// An empty system_prompt keeps the default
+	if systemPrompt := viper.GetString("system_prompt"); strings.TrimSpace(systemPrompt) != "" {
+		return systemPrompt, nil
+	}
+	return "", nil
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -105,6 +423,57 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.PersistentFlags().StringVarP(&prompt, "prompt", "p", "", "Prompt for a one-off question. If empty, starts interactive TUI mode.")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Print only the final response text. Tools that require confirmation are refused unless --yes is given.")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Alias of --output json.")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "With --quiet, run tools that require confirmation, such as shell commands and file writes, without asking.")
+	rootCmd.PersistentFlags().StringVar(&outputFmt, "output", "text", "Output format of a one-off prompt: text, or json for a {\"prompt\", \"response\", \"model\", \"tokens_used\"} object.")
+	rootCmd.PersistentFlags().BoolVar(&jsonMode, "json-mode", false, "Ask the model to reply with a JSON object (response_format json_object).")
+	rootCmd.PersistentFlags().BoolVar(&autoEval, "auto-eval", false, "Automatically score every assistant response and show the result in the help bar.")
+	rootCmd.PersistentFlags().BoolVar(&demoMode, "demo-mode", false, "Reveal responses word by word for presentations. The delay is set by demo_mode_delay_ms.")
+	rootCmd.PersistentFlags().BoolVar(&planTools, "plan", false, "Ask the model for an overview of its planned tool calls before the first one runs.")
+	rootCmd.PersistentFlags().BoolVar(&noTools, "no-tools", false, "Chat without tools. Tool definitions are not sent to the model.")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "Disable every tool that requires confirmation or modifies files, such as writing files or running commands.")
+	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Log API requests, stream events and tool executions to stderr. In the TUI, redirect stderr to a file, e.g. 2>debug.log.")
+	rootCmd.PersistentFlags().StringVar(&promptFile, "system-prompt", "", "Read the system prompt from this file instead of using the built-in one.")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Use the API URL, key and model of this profile from the config file.")
+	rootCmd.PersistentFlags().StringVar(&sessionID, "session", "", "Resume the session with this ID (or session file path) and save it on exit. Use \"new\" to start a new session.")
+	rootCmd.PersistentFlags().Int("max-response-tokens", 0, "Maximum number of tokens per response. 0 means no limit. Also accepted as --max-tokens.")
+	viper.BindPFlag("max_response_tokens", rootCmd.PersistentFlags().Lookup("max-response-tokens"))
+	// --max-tokens is accepted as the name used by the API.
+	rootCmd.SetGlobalNormalizationFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "max-tokens" {
+			name = "max-response-tokens"
+		}
+		return pflag.NormalizedName(name)
+	})
+	rootCmd.PersistentFlags().Int("seed", 0, "Seed for deterministic sampling, if the API supports it.")
+	viper.BindPFlag("seed", rootCmd.PersistentFlags().Lookup("seed"))
+	rootCmd.PersistentFlags().Float64("temperature", 0, "Sampling temperature between 0.0 and 2.0. If unset, the server default is used.")
+	viper.BindPFlag("temperature", rootCmd.PersistentFlags().Lookup("temperature"))
+	rootCmd.PersistentFlags().String("theme", "auto", "Color scheme of the TUI: "+strings.Join(tui.Themes, ", ")+".")
+	viper.BindPFlag("theme", rootCmd.PersistentFlags().Lookup("theme"))
+}
+
+// configDefaults holds the default value of every setting that has one.
+// `tachigoma config init` documents them in the file it generates.
+var configDefaults = map[string]any{
+	"api_url":                      "http://localhost:3000/v1",
+	"model":                        "gpt-3.5-turbo",
+	"max_response_tokens":          0,
+	"demo_mode_delay_ms":           30,
+	"http_timeout_seconds":         30,
+	"shell_command_timeout":        30,
+	"append_requires_confirmation": false,
+	"parallel_tools":               false,
+	"tool_cache":                   false,
+	"tool_cache_ttl_seconds":       60,
+	"request_timeout_seconds":      300,
+	"max_retries":                  2,
+	"retry_backoff_seconds":        8,
+	"tools_enabled":                true,
+	"context_limit":                llm.DefaultContextLimit,
+	"theme":                        "auto",
+	"cost_warning_threshold":       1.0,
 }
 
 func initConfig() {
@@ -113,8 +482,9 @@ func initConfig() {
 	viper.AddConfigPath(".")
 	viper.AddConfigPath("$HOME")
 
-	viper.SetDefault("api_url", "http://localhost:3000/v1")
-	viper.SetDefault("model", "gpt-3.5-turbo")
+	for key, value := range configDefaults {
+		viper.SetDefault(key, value)
+	}
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
@@ -124,4 +494,53 @@ func initConfig() {
 			os.Exit(1)
 		}
 	}
+
+	// max_tokens, the name used by the API, is accepted in place of max_response_tokens.
+	if viper.InConfig("max_tokens") && !viper.InConfig("max_response_tokens") && !rootCmd.PersistentFlags().Changed("max-response-tokens") {
+		viper.Set("max_response_tokens", viper.GetInt("max_tokens"))
+	}
+
+	if profile != "" {
+		applyProfile(profile)
+	}
+
+	if viper.IsSet("temperature") {
+		if t := viper.GetFloat64("temperature"); t < 0 || t > 2 {
+			fmt.Fprintf(os.Stderr, "Invalid temperature %g: it must be between 0.0 and 2.0.\n", t)
+			os.Exit(1)
+		}
+	}
+
+	if theme := viper.GetString("theme"); !tui.ValidTheme(theme) {
+		fmt.Fprintf(os.Stderr, "Invalid theme '%s': use %s.\n", theme, strings.Join(tui.Themes, ", "))
+		os.Exit(1)
+	}
+}
+
+// profileKeys are the settings a profile can override.
+var profileKeys = []string{"api_url", "api_key", "model"}
+
+// applyProfile merges the settings of the named profile over the top-level ones.
+// It exits listing the available profiles if there is no profile with that name.
+func applyProfile(name string) {
+	profiles := viper.GetStringMap("profiles")
+	if _, ok := profiles[strings.ToLower(name)]; !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			fmt.Fprintf(os.Stderr, "Unknown profile '%s': no profiles are defined in the config file.\n", name)
+		} else {
+			fmt.Fprintf(os.Stderr, "Unknown profile '%s'. Available profiles: %s\n", name, strings.Join(names, ", "))
+		}
+		os.Exit(1)
+	}
+
+	for _, key := range profileKeys {
+		if value := viper.GetString("profiles." + name + "." + key); value != "" {
+			viper.Set(key, value)
+		}
+	}
 }
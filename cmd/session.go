@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"tachigoma/internal/session"
+
+	"github.com/charmbracelet/glamour"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportOutput        string
+	exportIncludeSystem bool
+	deleteAll           bool
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Manage saved conversations.",
+}
+
+var sessionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved sessions.",
+	Run: func(cmd *cobra.Command, args []string) {
+		summaries, err := session.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(summaries) == 0 {
+			fmt.Println("No saved sessions.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCREATED\tMESSAGES\tSUMMARY")
+		for _, s := range summaries {
+			created := "-"
+			if !s.CreatedAt.IsZero() {
+				created = s.CreatedAt.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", s.ID, created, s.MessageCount, truncate(s.FirstPrompt, 60))
+		}
+		w.Flush()
+	},
+}
+
+var sessionDeleteCmd = &cobra.Command{
+	Use:   "delete <id>",
+	Short: "Delete a saved session.",
+	Long:  `Deletes a saved session after asking for confirmation. With --all, every saved session is deleted.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if deleteAll {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if deleteAll {
+			summaries, err := session.List()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if len(summaries) == 0 {
+				fmt.Println("No saved sessions.")
+				return
+			}
+			if !confirm(fmt.Sprintf("Delete all %d saved sessions?", len(summaries))) {
+				fmt.Println("Aborted.")
+				return
+			}
+			n, err := session.DeleteAll()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Deleted %d sessions.\n", n)
+			return
+		}
+
+		id := args[0]
+		if strings.HasSuffix(id, ".json") {
+			fmt.Fprintf(os.Stderr, "Error: expected a session ID, not a file name: %s\n", id)
+			os.Exit(1)
+		}
+		if _, err := loadSession(id); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !confirm(fmt.Sprintf("Delete session %s?", id)) {
+			fmt.Println("Aborted.")
+			return
+		}
+		if err := session.Delete(id); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted session %s.\n", id)
+	},
+}
+
+var sessionExportCmd = &cobra.Command{
+	Use:   "export <id>",
+	Short: "Export a saved session as Markdown.",
+	Long: `Writes a saved conversation as a Markdown document, to stdout or to --output.
+When printing to a terminal the document is rendered for display.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := loadSession(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		doc := s.Markdown(exportIncludeSystem)
+
+		if exportOutput != "" {
+			if err := os.WriteFile(exportOutput, []byte(doc), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing '%s': %v\n", exportOutput, err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Session exported to %s\n", exportOutput)
+			return
+		}
+
+		if isTerminal(os.Stdout) {
+			if renderer, err := glamour.NewTermRenderer(glamour.WithAutoStyle()); err == nil {
+				if rendered, err := renderer.Render(doc); err == nil {
+					doc = rendered
+				}
+			}
+		}
+		fmt.Print(doc)
+	},
+}
+
+var sessionForkCmd = &cobra.Command{
+	Use:   "fork <session-id> <message-index>",
+	Short: "Start a new session from the first messages of a saved one.",
+	Long: `Copies the messages before <message-index> of a saved session into a new session and prints its ID.
+Indices start at 0 and count every stored message, including the system prompt, so forking
+at the index of a user message lets you ask that question again differently:
+
+  tachigoma session fork 1b9d6bcd-... 5
+  tachigoma --session <new-id>`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := loadSession(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		index, err := strconv.Atoi(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid message index '%s'\n", args[1])
+			os.Exit(1)
+		}
+
+		fork, err := s.Fork(index)
+		if err == nil {
+			err = fork.Save(fork.History())
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(fork.ID)
+	},
+}
+
+// loadSession reads a saved session by ID, or by path if the argument ends in .json.
+func loadSession(id string) (*session.Session, error) {
+	if strings.HasSuffix(id, ".json") {
+		return session.Load(id)
+	}
+	if err := session.CheckID(id); err != nil {
+		return nil, err
+	}
+	dir, err := session.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return session.Load(filepath.Join(dir, id+".json"))
+}
+
+// isTerminal reports whether f is attached to a terminal rather than a pipe or file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// truncate shortens s to at most n runes on a single line.
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func init() {
+	sessionExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the Markdown to this file instead of stdout.")
+	sessionExportCmd.Flags().BoolVar(&exportIncludeSystem, "include-system", false, "Include system messages in the export.")
+
+	sessionDeleteCmd.Flags().BoolVar(&deleteAll, "all", false, "Delete every saved session.")
+
+	sessionCmd.AddCommand(sessionListCmd)
+	sessionCmd.AddCommand(sessionDeleteCmd)
+	sessionCmd.AddCommand(sessionExportCmd)
+	sessionCmd.AddCommand(sessionForkCmd)
+	rootCmd.AddCommand(sessionCmd)
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"tachigoma/internal/llm"
+	"tachigoma/internal/tools"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Show the tools the model can use.",
+}
+
+var toolsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available tools.",
+	Long: `Lists every registered tool, including plugins from tool_plugin_dir, with whether it asks for
+confirmation before running. With --no-tools or tools_enabled: false no tools are listed.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		registered := registeredTools()
+		if len(registered) == 0 {
+			fmt.Println("Tools are disabled.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tCONFIRM\tDESCRIPTION")
+		for _, tool := range registered {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", tool.Name(), confirmationMode(tool), truncate(firstSentence(tool.Description()), 80))
+		}
+		w.Flush()
+	},
+}
+
+var toolsDescribeCmd = &cobra.Command{
+	Use:   "describe <name>",
+	Short: "Show the description and parameters of a tool.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var tool tools.Tool
+		for _, registered := range registeredTools() {
+			if registered.Name() == args[0] {
+				tool = registered
+				break
+			}
+		}
+		if tool == nil {
+			fmt.Fprintf(os.Stderr, "Error: unknown tool '%s'. Run `tachigoma tools list` to see the available tools.\n", args[0])
+			os.Exit(1)
+		}
+
+		schema, err := json.MarshalIndent(tool.Parameters(), "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding parameters: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Name: %s\nConfirmation: %s\n\n%s\n\nParameters:\n%s\n", tool.Name(), confirmationMode(tool), tool.Description(), schema)
+	},
+}
+
+// registeredTools returns the tools an agent built from the current configuration would offer.
+// Only the options that change the tool set are applied, so no session or audit log is opened.
+func registeredTools() []tools.Tool {
+	opts := []llm.AgentOption{
+		llm.WithTools(viper.GetBool("tools_enabled") && !noTools),
+		llm.WithReadOnly(readOnly),
+		llm.WithAppendConfirmation(viper.GetBool("append_requires_confirmation")),
+	}
+	if dir := viper.GetString("tool_plugin_dir"); dir != "" {
+		opts = append(opts, llm.WithPluginDir(expandHome(dir)))
+	}
+	return llm.NewAgent(nil, viper.GetString("model"), opts...).Tools()
+}
+
+// confirmationMode describes when a tool asks for confirmation, or that --read-only disables it.
+func confirmationMode(tool tools.Tool) string {
+	if readOnly && llm.DisabledInReadOnly(tool) {
+		return "disabled (read-only)"
+	}
+	if !tool.RequiresConfirmation() {
+		return "no"
+	}
+	if _, ok := tool.(tools.ArgsConfirmer); ok {
+		return "depends on arguments"
+	}
+	return "yes"
+}
+
+// firstSentence returns the first sentence of a tool description. Abbreviations like
+// "e.g." do not end a sentence.
+func firstSentence(description string) string {
+	for i := 0; ; {
+		end := strings.Index(description[i:], ". ")
+		if end < 0 {
+			return strings.TrimSuffix(description, ".")
+		}
+		end += i
+		if !strings.HasSuffix(description[:end], "e.g") && !strings.HasSuffix(description[:end], "i.e") {
+			return description[:end]
+		}
+		i = end + 2
+	}
+}
+
+func init() {
+	toolsCmd.AddCommand(toolsListCmd)
+	toolsCmd.AddCommand(toolsDescribeCmd)
+	rootCmd.AddCommand(toolsCmd)
+}
@@ -0,0 +1,67 @@
+// Command plugin is a minimal tool plugin. It adds a "reverse_text" tool that reverses a string.
+//
+// Build it with the same Go version and module version as tachigoma, then point
+// tool_plugin_dir at the directory containing the .so file:
+//
+//	go build -buildmode=plugin -o ~/.tachigoma/plugins/reverse.so ./examples/plugin
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"tachigoma/internal/tools"
+)
+
+// ToolAPIVersion tells tachigoma which version of the tool contract this plugin implements.
+var ToolAPIVersion = tools.ToolAPIVersion
+
+// ToolFactory is looked up by tachigoma and called once at startup.
+func ToolFactory() tools.Tool {
+	return &reverseTextTool{}
+}
+
+type reverseTextTool struct{}
+
+func (t *reverseTextTool) Name() string {
+	return "reverse_text"
+}
+
+func (t *reverseTextTool) Description() string {
+	return "Reverses a string. Usage: {\"text\": \"<text>\"}"
+}
+
+func (t *reverseTextTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"text": map[string]any{
+				"type":        "string",
+				"description": "The text to reverse.",
+			},
+		},
+		"required": []string{"text"},
+	}
+}
+
+func (t *reverseTextTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *reverseTextTool) Execute(args string) (string, error) {
+	var toolArgs struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for reverse_text: %w", err)
+	}
+
+	runes := []rune(toolArgs.Text)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes), nil
+}
+
+// main is never run; a plugin needs a main package, and this lets `go build ./...` build it too.
+func main() {}
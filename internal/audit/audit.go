@@ -0,0 +1,204 @@
+// Package audit records the tools the agent runs, so operators can review what it did.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxResultSummary is the number of characters of a tool result kept in an entry.
+const maxResultSummary = 200
+
+// redacted replaces secret values in logged arguments and results.
+const redacted = "[REDACTED]"
+
+// AuditEntry describes one tool execution.
+type AuditEntry struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Tool          string    `json:"tool"`
+	Arguments     string    `json:"arguments"` // Secrets are redacted
+	Result        string    `json:"result"`    // The start of the result, or the error; secrets are redacted
+	Error         bool      `json:"error,omitempty"`
+	UserConfirmed bool      `json:"user_confirmed"`
+}
+
+// AuditLogger records tool executions.
+type AuditLogger interface {
+	Log(entry AuditEntry)
+}
+
+// NewEntry builds the entry for a tool call that ran now, or was refused, redacting secrets
+// in args and result and shortening the result.
+func NewEntry(toolName, args, result string, err error, confirmed bool) AuditEntry {
+	entry := AuditEntry{
+		Timestamp:     time.Now(),
+		Tool:          toolName,
+		Arguments:     RedactArgs(args),
+		Result:        summarize(RedactResult(args, result)),
+		UserConfirmed: confirmed,
+	}
+	if err != nil {
+		// Errors describe the call rather than the secret, so only secrets inside them are redacted
+		entry.Result = summarize(redactOutput(err.Error()))
+		entry.Error = true
+	}
+	return entry
+}
+
+// NopLogger discards every entry. It is the default when no audit log is configured.
+type NopLogger struct{}
+
+func (NopLogger) Log(AuditEntry) {}
+
+// FileAuditLogger appends entries to a file as newline-delimited JSON.
+type FileAuditLogger struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditLogger opens path for appending, creating it if needed. Only the owner
+// may read the file.
+func NewFileAuditLogger(path string) (*FileAuditLogger, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error opening audit log '%s': %w", path, err)
+	}
+	return &FileAuditLogger{file: file}, nil
+}
+
+// Log writes entry as one line. Write errors are reported on stderr, since a failing
+// audit log must not stop the agent.
+func (l *FileAuditLogger) Log(entry AuditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding audit entry: %v\n", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing audit log: %v\n", err)
+	}
+}
+
+// Close closes the log file.
+func (l *FileAuditLogger) Close() error {
+	return l.file.Close()
+}
+
+// secretKey matches argument names whose values are secrets.
+var secretKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[_-]?key|authorization|credential|private[_-]?key)`)
+
+// secretFile matches paths of files that hold secrets, such as .env files and private keys.
+var secretFile = regexp.MustCompile(`(?i)(^|[/\\])(\.env(\.[^/\\]*)?|\.netrc|\.pgpass|credentials(\.json)?|id_(rsa|dsa|ecdsa|ed25519)|[^/\\]*\.(pem|key|p12|pfx))$`)
+
+// secretAssignment matches lines like KEY=value or "key": value whose key looks secret.
+var secretAssignment = regexp.MustCompile(`(?im)^(\s*(?:export\s+)?["']?[\w.-]*` + secretKey.String() + `[\w.-]*["']?\s*[:=]\s*).+$`)
+
+// secretValue matches secrets that can appear inside any string, such as headers or commands.
+var secretValue = regexp.MustCompile(`(?i)(bearer\s+)[^\s"']+|\bsk-[A-Za-z0-9_-]{8,}`)
+
+// RedactArgs replaces the values of secret-looking keys in a JSON object, and bearer
+// tokens or API keys anywhere in it. Arguments that are not JSON are redacted as text.
+func RedactArgs(args string) string {
+	var value any
+	if err := json.Unmarshal([]byte(args), &value); err != nil {
+		return redactText(args)
+	}
+	data, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return redactText(args)
+	}
+	return string(data)
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if secretKey.MatchString(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	case string:
+		return redactText(v)
+	default:
+		return v
+	}
+}
+
+func redactText(text string) string {
+	return secretValue.ReplaceAllStringFunc(text, func(match string) string {
+		if prefix := secretValue.FindStringSubmatch(match)[1]; prefix != "" {
+			return prefix + redacted
+		}
+		return redacted
+	})
+}
+
+// RedactResult redacts secrets in the result of a tool call made with args. The whole result
+// is redacted if args name a secret, e.g. env_get of an API key or read_file of a .env file;
+// otherwise secret-looking assignments, bearer tokens and API keys in it are.
+func RedactResult(args, result string) string {
+	if namesSecret(args) {
+		return redacted
+	}
+	return redactOutput(result)
+}
+
+// redactOutput redacts secret-looking assignments, bearer tokens and API keys in text.
+func redactOutput(text string) string {
+	return redactText(secretAssignment.ReplaceAllString(text, "${1}"+redacted))
+}
+
+// namesSecret reports whether any string in the JSON arguments is a secret-looking name or path.
+func namesSecret(args string) bool {
+	var value any
+	if err := json.Unmarshal([]byte(args), &value); err != nil {
+		return false
+	}
+	var walk func(value any) bool
+	walk = func(value any) bool {
+		switch v := value.(type) {
+		case map[string]any:
+			for _, item := range v {
+				if walk(item) {
+					return true
+				}
+			}
+		case []any:
+			for _, item := range v {
+				if walk(item) {
+					return true
+				}
+			}
+		case string:
+			return secretFile.MatchString(v) || (!strings.ContainsAny(v, " \n") && secretKey.MatchString(v))
+		}
+		return false
+	}
+	return walk(value)
+}
+
+// summarize collapses the whitespace in result and shortens it to maxResultSummary characters.
+func summarize(result string) string {
+	result = strings.Join(strings.Fields(result), " ")
+	if runes := []rune(result); len(runes) > maxResultSummary {
+		return string(runes[:maxResultSummary]) + "..."
+	}
+	return result
+}
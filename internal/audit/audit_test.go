@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRedactResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   string
+		result string
+		want   string
+	}{
+		{name: "secret env var", args: `{"name": "OPENAI_API_KEY"}`, result: "sk-abc", want: redacted},
+		{name: "env file", args: `{"path": "config/.env"}`, result: "DB_HOST=localhost", want: redacted},
+		{name: "private key", args: `{"path": "/home/u/.ssh/id_ed25519"}`, result: "-----BEGIN", want: redacted},
+		{name: "plain env var", args: `{"name": "HOME"}`, result: "/home/u", want: "/home/u"},
+		{
+			name:   "assignments in a file",
+			args:   `{"path": "settings.txt"}`,
+			result: "host=db\nDB_PASSWORD=hunter2\nexport GITHUB_TOKEN=ghp_x\n\"api_key\": \"abc\"",
+			want:   "host=db\nDB_PASSWORD=" + redacted + "\nexport GITHUB_TOKEN=" + redacted + "\n\"api_key\": " + redacted,
+		},
+		{name: "bearer token", args: `{"url": "https://example.com"}`, result: "Authorization: Bearer abc.def", want: "Authorization: " + redacted},
+		{name: "inline key", args: `{"command": "cat notes"}`, result: "key is sk-0123456789abcdef", want: "key is " + redacted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactResult(tt.args, tt.result); got != tt.want {
+				t.Errorf("RedactResult() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEntryErrors(t *testing.T) {
+	entry := NewEntry("read_file", `{"path": ".env"}`, "", errors.New("open .env: permission denied"), false)
+	if !entry.Error || entry.Result != "open .env: permission denied" {
+		t.Errorf("NewEntry() = %+v, want the error kept", entry)
+	}
+
+	entry = NewEntry("http_request", `{"url": "https://example.com"}`, "", errors.New("status 401 for Bearer abc.def"), true)
+	if want := "status 401 for Bearer " + redacted; entry.Result != want {
+		t.Errorf("NewEntry() result = %q, want %q", entry.Result, want)
+	}
+
+	entry = NewEntry("env_get", `{"name": "PATH"}`, strings.Repeat("a", maxResultSummary+10), nil, true)
+	if !entry.UserConfirmed || len(entry.Result) != maxResultSummary+len("...") {
+		t.Errorf("NewEntry() = %+v, want a confirmed entry with a shortened result", entry)
+	}
+}
@@ -1,9 +1,19 @@
 package llm
 
 import (
+	"context"
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"tachigoma/internal/audit"
 	"tachigoma/internal/tools"
+	"time"
 
 	"github.com/charmbracelet/bubbletea"
 )
@@ -17,27 +27,352 @@ type Agent struct {
 	modelName    string
 	toolRegistry map[string]tools.Tool
 
+	// Options
+	maxResponseTokens int
+	seed              *int
+	stopSequences     []string
+	temperature       *float64
+	jsonMode          bool
+	autoEval          bool
+	requiresPlan      bool
+	maxToolIterations int
+	session           SessionStore
+	httpTimeout       time.Duration
+	shellTimeout      time.Duration
+	shellDenylist     []string // Nil keeps tools.DefaultShellDenylist
+	appendConfirm     bool
+	parallelTools     bool
+	pluginDir         string
+	contextLimit      int
+	systemPrompt      string
+	readOnly          bool
+	toolsDisabled     bool
+	preToolHook       PreToolHook
+	postToolHooks     []PostToolHook
+	logger            *slog.Logger // The client's logger
+	confirmFunc       ConfirmFunc  // Nil runs every tool in Run without asking
+	toolCache         *ToolCache   // Nil when caching is disabled
+	allowedBasePath   string       // File tools may only access paths under this directory; empty allows all
+
 	// State
-	messages           []Message
-	pendingToolCalls   []ToolCall
-	confirmingToolCall ToolCall
-	isConfirming       bool
+	ctx                 context.Context // Cancels the streams of the current turn
+	messages            []Message
+	pendingToolCalls    []ToolCall
+	confirmingToolCall  ToolCall
+	confirmationSummary string
+	isConfirming        bool
+	inputToolCall       ToolCall
+	isAwaitingInput     bool
+	planRequested       bool
+	plan                []PlanStep
+	summarizing         bool  // A summary of the older messages has been requested
+	toolIterations      int   // Tools run since the last user input
+	usage               Usage // Tokens used by the conversation's completions so far
 
 	// Live state for streaming
 	lastStreamedContent string
 }
 
+// AgentOption configures optional Agent behaviour.
+type AgentOption func(*Agent)
+
+// WithMaxResponseTokens limits every response to n tokens. Zero means no limit.
+func WithMaxResponseTokens(n int) AgentOption {
+	return func(a *Agent) {
+		a.maxResponseTokens = n
+	}
+}
+
+// WithSeed asks the server for deterministic sampling with the given seed.
+func WithSeed(seed int) AgentOption {
+	return func(a *Agent) {
+		a.seed = &seed
+	}
+}
+
+// WithStopSequences makes the model stop generating before any of seqs. Without
+// stop sequences none are sent, leaving the model's behaviour unchanged.
+func WithStopSequences(seqs ...string) AgentOption {
+	return func(a *Agent) {
+		a.stopSequences = seqs
+	}
+}
+
+// WithTemperature sets the sampling temperature sent with every request.
+func WithTemperature(t float64) AgentOption {
+	return func(a *Agent) {
+		a.temperature = &t
+	}
+}
+
+// WithJSONMode asks the model to reply with a single JSON object. The system prompt is
+// extended to say so, as the API requires JSON to be mentioned in the messages.
+func WithJSONMode() AgentOption {
+	return func(a *Agent) {
+		a.jsonMode = true
+	}
+}
+
+// WithAutoEval grades every final assistant response with the evaluate_response tool.
+func WithAutoEval(enabled bool) AgentOption {
+	return func(a *Agent) {
+		a.autoEval = enabled
+	}
+}
+
+// WithRequiresPlan asks the model for an advisory plan of all its tool calls
+// before the first tool of a turn runs.
+func WithRequiresPlan(enabled bool) AgentOption {
+	return func(a *Agent) {
+		a.requiresPlan = enabled
+	}
+}
+
+// JSONModeInstruction is added to the system prompt in JSON mode.
+const JSONModeInstruction = "Respond with a single valid JSON object and nothing else."
+
+// DefaultMaxToolIterations is the number of tools an agent runs per user input before it stops calling tools.
+const DefaultMaxToolIterations = 20
+
+// maxToolIterationsResult is the tool result given for calls made after the limit is reached.
+const maxToolIterationsResult = "Maximum tool call limit reached"
+
+// WithMaxToolIterations limits how many tools may run in response to a single user input.
+// When the limit is reached the model is asked for a final answer without tools.
+func WithMaxToolIterations(n int) AgentOption {
+	return func(a *Agent) {
+		a.maxToolIterations = n
+	}
+}
+
+// WithHTTPTimeout sets the timeout of requests made by the http_request tool.
+func WithHTTPTimeout(d time.Duration) AgentOption {
+	return func(a *Agent) {
+		a.httpTimeout = d
+	}
+}
+
+// WithSystemPrompt replaces the embedded default system prompt.
+// Resumed sessions keep their own prompt unless it is the default one.
+func WithSystemPrompt(prompt string) AgentOption {
+	return func(a *Agent) {
+		a.systemPrompt = prompt
+	}
+}
+
+// readOnlyResult is the tool result given for calls that read-only mode refuses.
+const readOnlyResult = "Tool disabled in read-only mode"
+
+// DisabledInReadOnly reports whether read-only mode refuses calls to tool: it modifies files
+// (see tools.FileModifier) or requires confirmation.
+func DisabledInReadOnly(tool tools.Tool) bool {
+	return tools.ModifiesFiles(tool) || tool.RequiresConfirmation()
+}
+
+// WithReadOnly refuses every tool that requires confirmation or otherwise modifies files,
+// so the agent cannot modify anything.
+func WithReadOnly(enabled bool) AgentOption {
+	return func(a *Agent) {
+		a.readOnly = enabled
+	}
+}
+
+// WithTools enables or disables tool use. When disabled no tools are registered
+// or sent to the API, which keeps the prompt small for plain chat.
+func WithTools(enabled bool) AgentOption {
+	return func(a *Agent) {
+		a.toolsDisabled = !enabled
+	}
+}
+
+// WithToolCache reuses the results of read-only tool calls repeated within ttl, instead of
+// running the tool again. Zero uses DefaultToolCacheTTL.
+func WithToolCache(ttl time.Duration) AgentOption {
+	return func(a *Agent) {
+		a.toolCache = NewToolCache(ttl)
+	}
+}
+
+// WithAllowedBasePath keeps the file-system tools from accessing paths outside of dir.
+func WithAllowedBasePath(dir string) AgentOption {
+	return func(a *Agent) {
+		a.allowedBasePath = dir
+	}
+}
+
+// PreToolHook is called before a tool runs. Returning an error prevents the call,
+// and the error is given to the model as the tool result.
+type PreToolHook func(toolName string, args string) error
+
+// PostToolHook is called after every tool that ran, with the tool's own result and error, and
+// for every call the user refused, with ErrToolDenied. confirmed tells whether the user approved
+// the call.
+type PostToolHook func(toolName string, args string, result string, err error, confirmed bool)
+
+// ErrToolDenied is given to post-tool hooks for calls the user refused.
+var ErrToolDenied = errors.New("user denied execution")
+
+// WithPreToolHook installs a hook that runs before every tool call, e.g. for access control.
+func WithPreToolHook(hook PreToolHook) AgentOption {
+	return func(a *Agent) {
+		a.preToolHook = hook
+	}
+}
+
+// WithPostToolHook installs a hook that runs after every tool call, e.g. for logging or metrics.
+// Hooks run in the order they were installed.
+func WithPostToolHook(hook PostToolHook) AgentOption {
+	return func(a *Agent) {
+		a.postToolHooks = append(a.postToolHooks, hook)
+	}
+}
+
+// ConfirmFunc asks the user whether a tool call that requires confirmation may run.
+type ConfirmFunc func(toolCall ToolCall) bool
+
+// WithConfirmFunc makes Run ask confirm before running a tool that requires confirmation,
+// instead of running it unasked. Calls that are refused get the same result as in the TUI.
+func WithConfirmFunc(confirm ConfirmFunc) AgentOption {
+	return func(a *Agent) {
+		a.confirmFunc = confirm
+	}
+}
+
+// WithShellTimeout sets how long run_shell_command lets a command run when the call does not say.
+func WithShellTimeout(d time.Duration) AgentOption {
+	return func(a *Agent) {
+		a.shellTimeout = d
+	}
+}
+
+// WithShellDenylist replaces the patterns of commands run_shell_command refuses to run.
+// See tools.RunShellCommandTool for the pattern syntax. An empty list allows every command.
+func WithShellDenylist(patterns []string) AgentOption {
+	return func(a *Agent) {
+		a.shellDenylist = append([]string{}, patterns...)
+	}
+}
+
+// WithAuditLogger records every tool execution, and every call the user refused, with logger.
+func WithAuditLogger(logger audit.AuditLogger) AgentOption {
+	return WithPostToolHook(func(toolName, args, result string, err error, confirmed bool) {
+		logger.Log(audit.NewEntry(toolName, args, result, err, confirmed))
+	})
+}
+
+// WithAppendConfirmation makes append_file ask for confirmation like the other tools that modify files.
+func WithAppendConfirmation(enabled bool) AgentOption {
+	return func(a *Agent) {
+		a.appendConfirm = enabled
+	}
+}
+
+// WithParallelTools runs consecutive tool calls of one response concurrently when none of
+// them needs confirmation or user input, e.g. when the model reads several files at once.
+// Tool hooks may then be called from several goroutines at the same time.
+func WithParallelTools(enabled bool) AgentOption {
+	return func(a *Agent) {
+		a.parallelTools = enabled
+	}
+}
+
+// WithPluginDir registers the tools of every plugin in dir. See tools.LoadPlugins for the plugin contract.
+func WithPluginDir(dir string) AgentOption {
+	return func(a *Agent) {
+		a.pluginDir = dir
+	}
+}
+
+// WithContextLimit sets the model's context window in tokens, as estimated by EstimateTokens.
+// The agent warns before a request that fills more than 90% of it, and summarizes older
+// messages once the conversation exceeds it. Zero disables both.
+func WithContextLimit(limit int) AgentOption {
+	return func(a *Agent) {
+		a.contextLimit = limit
+	}
+}
+
+// SessionStore persists the conversation so that it can be resumed later.
+// It is implemented by session.Session.
+type SessionStore interface {
+	GetID() string
+	History() []Message
+	Save(messages []Message) error
+}
+
+// WithSession resumes the conversation stored in s, if any, and lets SaveSession write it back.
+func WithSession(s SessionStore) AgentOption {
+	return func(a *Agent) {
+		a.session = s
+		if history := s.History(); len(history) > 0 {
+			if history[0].Role != "system" {
+				history = append([]Message{{Role: "system", Content: systemPromptContent}}, history...)
+			}
+			a.messages = history
+		}
+	}
+}
+
 // NewAgent creates a new agent.
-func NewAgent(client *Client, modelName string) *Agent {
+func NewAgent(client *Client, modelName string, opts ...AgentOption) *Agent {
 	// Initialize and register all available tools.
 	availableTools := []tools.Tool{
 		&tools.ListDirectoryTool{},
+		&tools.TreeTool{},
 		&tools.ReadFileTool{},
 		&tools.WriteFileTool{},
+		&tools.AppendFileTool{},
 		&tools.SearchFileContentTool{},
 		&tools.GlobTool{},
 		&tools.ReplaceTool{},
+		&tools.InsertLinesTool{},
+		&tools.DeleteLinesTool{},
+		&tools.DeleteFileTool{},
+		&tools.MoveFileTool{},
+		&tools.CopyFileTool{},
+		&tools.CreateDirectoryTool{},
+		&tools.FileStatTool{},
+		&tools.SymlinkTool{},
+		&tools.ReadlinkTool{},
+		&tools.WatchFileTool{},
+		&tools.ChecksumTool{},
+		&tools.FindDuplicateFilesTool{},
+		&tools.CreateArchiveTool{},
+		&tools.ExtractArchiveTool{},
+		&tools.CreateDiffTool{},
+		&tools.ApplyDiffTool{},
 		&tools.RunShellCommandTool{},
+		&tools.ListProcessesTool{},
+		&tools.KillProcessTool{},
+		&tools.EnvGetTool{},
+		&tools.EnvSetTool{},
+		&tools.EnvListTool{},
+		&tools.GitStatusTool{},
+		&tools.GitDiffTool{},
+		&tools.GitLogTool{},
+		&tools.GitCommitTool{},
+		&tools.InitGoProjectTool{},
+		&tools.ParseGoErrorsTool{},
+		&tools.GoComplexityTool{},
+		&tools.GenerateGoTestsTool{},
+		&tools.CheckLatestVersionTool{},
+		&tools.HTTPRequestTool{},
+		&tools.WebFetchTool{},
+		&tools.DNSLookupTool{},
+		&tools.PortCheckTool{},
+		&tools.JSONQueryTool{},
+		&tools.SQLiteQueryTool{},
+		&tools.Base64Tool{},
+		&tools.RegexExtractTool{},
+		&tools.TemplateRenderTool{},
+		&tools.CalculatorTool{},
+		&tools.DateTimeTool{},
+		&tools.EvaluateResponseTool{},
+		&tools.GenerateReadmeTool{},
+		&tools.InjectStdinTool{},
+		&tools.GetPermissionsTool{},
+		&tools.SetPermissionsTool{},
+		&tools.SetOwnerTool{},
 	}
 
 	toolRegistry := make(map[string]tools.Tool)
@@ -45,14 +380,106 @@ func NewAgent(client *Client, modelName string) *Agent {
 		toolRegistry[tool.Name()] = tool
 	}
 
-	return &Agent{
-		client:       client,
-		modelName:    modelName,
-		toolRegistry: toolRegistry,
+	agent := &Agent{
+		client:            client,
+		modelName:         modelName,
+		toolRegistry:      toolRegistry,
+		maxToolIterations: DefaultMaxToolIterations,
+		contextLimit:      DefaultContextLimit,
+		logger:            discardLogger,
+		ctx:               context.Background(),
 		messages: []Message{
 			{Role: "system", Content: systemPromptContent},
 		},
 	}
+	if client != nil && client.logger != nil {
+		agent.logger = client.logger
+	}
+	for _, opt := range opts {
+		opt(agent)
+	}
+
+	if agent.toolsDisabled {
+		agent.toolRegistry = make(map[string]tools.Tool)
+	} else if agent.pluginDir != "" {
+		agent.registerPlugins()
+	}
+
+	if agent.systemPrompt != "" && agent.messages[0].Role == "system" && agent.messages[0].Content == systemPromptContent {
+		agent.messages[0].Content = agent.systemPrompt
+	}
+	if agent.jsonMode && agent.messages[0].Role == "system" && !strings.Contains(agent.messages[0].Content, JSONModeInstruction) {
+		agent.messages[0].Content += "\n\n" + JSONModeInstruction
+	}
+
+	if httpTool, ok := agent.toolRegistry["http_request"].(*tools.HTTPRequestTool); ok {
+		httpTool.Timeout = agent.httpTimeout
+	}
+	if shellTool, ok := agent.toolRegistry["run_shell_command"].(*tools.RunShellCommandTool); ok {
+		shellTool.Timeout = agent.shellTimeout
+		if agent.shellDenylist != nil {
+			shellTool.ShellCommandDenylist = agent.shellDenylist
+		}
+	}
+	if appendTool, ok := agent.toolRegistry["append_file"].(*tools.AppendFileTool); ok {
+		appendTool.RequireConfirmation = agent.appendConfirm
+	}
+
+	// Give tools that call the model themselves access to the agent's client, and keep file tools
+	// inside the allowed base path.
+	for _, tool := range agent.toolRegistry {
+		if aware, ok := tool.(tools.ClientAware); ok {
+			aware.SetCompleter(agentCompleter{agent: agent})
+		}
+		if restricted, ok := tool.(tools.PathRestricted); ok && agent.allowedBasePath != "" {
+			restricted.SetBasePath(agent.allowedBasePath)
+		}
+	}
+	return agent
+}
+
+// registerPlugins adds the tools from the plugin directory. Plugins that fail to load,
+// or whose tool name is already taken, are skipped with a warning.
+func (a *Agent) registerPlugins() {
+	pluginTools, errs := tools.LoadPlugins(a.pluginDir)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	for _, tool := range pluginTools {
+		if _, exists := a.toolRegistry[tool.Name()]; exists {
+			fmt.Fprintf(os.Stderr, "Warning: plugin tool '%s' conflicts with an existing tool and was skipped\n", tool.Name())
+			continue
+		}
+		a.toolRegistry[tool.Name()] = tool
+	}
+}
+
+// agentCompleter lets ClientAware tools send one-off prompts with the agent's client and model.
+// The prompts are the tools' own, so the user's sampling options are not applied to them:
+// a stop sequence or token limit meant for the conversation would cut their replies short.
+type agentCompleter struct {
+	agent *Agent
+}
+
+func (c agentCompleter) Complete(prompt string) (string, error) {
+	messages := []Message{{Role: "user", Content: prompt}}
+	return c.agent.client.Completion(messages, c.agent.modelName, CompletionOptions{})
+}
+
+// SessionID returns the ID of the session the conversation is saved to, or "" if there is none.
+func (a *Agent) SessionID() string {
+	if a.session == nil {
+		return ""
+	}
+	return a.session.GetID()
+}
+
+// SaveSession writes the conversation to the agent's session. It does nothing without a session.
+func (a *Agent) SaveSession() error {
+	if a.session == nil {
+		return nil
+	}
+	return a.session.Save(a.messages)
 }
 
 // ViewState is a snapshot of the agent's state, intended for rendering by the UI.
@@ -61,6 +488,15 @@ type ViewState struct {
 	LastStreamedContent string
 	IsConfirming        bool
 	ConfirmingToolCall  ToolCall
+	ConfirmationSummary string // Optional tool-provided description of the pending call
+	IsAwaitingInput     bool   // A tool is waiting for the user to paste its result
+	Plan                []PlanStep
+	Usage               Usage    // Cumulative token usage of the conversation
+	Model               string   // Name of the model in use
+	ReadOnly            bool     // Tools that require confirmation are disabled
+	NoTools             bool     // Tool use is disabled entirely
+	Temperature         *float64 // Nil when the server default is used
+	JSONMode            bool     // Responses are JSON objects
 }
 
 // GetViewState returns a snapshot of the current state for rendering.
@@ -70,11 +506,34 @@ func (a *Agent) GetViewState() ViewState {
 		LastStreamedContent: a.lastStreamedContent,
 		IsConfirming:        a.isConfirming,
 		ConfirmingToolCall:  a.confirmingToolCall,
+		ConfirmationSummary: a.confirmationSummary,
+		IsAwaitingInput:     a.isAwaitingInput,
+		Plan:                a.plan,
+		Usage:               a.usage,
+		Model:               a.modelName,
+		ReadOnly:            a.readOnly,
+		NoTools:             a.toolsDisabled,
+		Temperature:         a.temperature,
+		JSONMode:            a.jsonMode,
 	}
 }
 
+// Tools returns the registered tools sorted by name. It is empty when tools are disabled.
+func (a *Agent) Tools() []tools.Tool {
+	registered := make([]tools.Tool, 0, len(a.toolRegistry))
+	for _, tool := range a.toolRegistry {
+		registered = append(registered, tool)
+	}
+	sort.Slice(registered, func(i, j int) bool { return registered[i].Name() < registered[j].Name() })
+	return registered
+}
+
 // getAvailableToolsAsJSON converts the registered tools into the JSON format expected by the API.
 func (a *Agent) getAvailableToolsAsJSON() []Tool {
+	if a.toolsDisabled {
+		return nil
+	}
+
 	var availableTools []Tool
 	for _, tool := range a.toolRegistry {
 		availableTools = append(availableTools, Tool{
@@ -93,10 +552,59 @@ func (a *Agent) getAvailableToolsAsJSON() []Tool {
 	return availableTools
 }
 
-// HandleUserInput starts a new conversation turn.
-func (a *Agent) HandleUserInput(input string) tea.Cmd {
-	a.messages = append(a.messages, Message{Role: "user", Content: input})
-	return a.client.CompletionStream(a.messages, a.modelName, a.getAvailableToolsAsJSON())
+// getCompletionOptions returns the request parameters sent with every completion.
+func (a *Agent) getCompletionOptions() CompletionOptions {
+	return CompletionOptions{MaxTokens: a.maxResponseTokens, Seed: a.seed, Stop: a.stopSequences, Temperature: a.temperature}
+}
+
+// conversationOptions returns the request parameters for completions that continue the conversation.
+// Unlike side requests such as plans and summaries, their replies are shown to the user.
+func (a *Agent) conversationOptions() CompletionOptions {
+	opts := a.getCompletionOptions()
+	if a.jsonMode {
+		opts.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	}
+	return opts
+}
+
+// HandleUserInput starts a new conversation turn. Cancelling ctx aborts every
+// completion streamed during the turn, including those that follow tool calls.
+func (a *Agent) HandleUserInput(ctx context.Context, input string) tea.Cmd {
+	a.ctx = ctx
+	a.planRequested = false
+	a.plan = nil
+	a.toolIterations = 0
+	a.messages = append(a.messages, Message{Role: "user", Content: input, Timestamp: time.Now()})
+	return a.streamCompletion(a.getAvailableToolsAsJSON())
+}
+
+// streamCompletion streams the model's reply to the conversation, warning first if the
+// conversation is close to filling the context window.
+func (a *Agent) streamCompletion(availableTools []Tool) tea.Cmd {
+	stream := a.client.CompletionStream(a.ctx, a.messages, a.modelName, availableTools, a.conversationOptions())
+	if warning := a.contextWarning(); warning != nil {
+		return tea.Batch(func() tea.Msg { return *warning }, stream)
+	}
+	return stream
+}
+
+// Reset clears the conversation back to the system prompt and drops all per-turn state.
+// The client, tools and options are kept. A resumed session is overwritten on the next save.
+func (a *Agent) Reset() {
+	a.messages = a.messages[:1:1]
+	a.pendingToolCalls = nil
+	a.confirmingToolCall = ToolCall{}
+	a.confirmationSummary = ""
+	a.isConfirming = false
+	a.inputToolCall = ToolCall{}
+	a.isAwaitingInput = false
+	a.planRequested = false
+	a.plan = nil
+	a.summarizing = false
+	a.toolIterations = 0
+	a.usage = Usage{}
+	a.lastStreamedContent = ""
+	a.ClearToolCache()
 }
 
 // HandleStreamStart prepares the agent for a new stream of messages.
@@ -114,6 +622,131 @@ func (a *Agent) HandleStreamContent(content string) {
 	}
 }
 
+// HandleStreamEnd records the stream's token usage, and when and how it finished, on the last assistant message.
+func (a *Agent) HandleStreamEnd(msg StreamEndMsg) {
+	a.usage = a.usage.Add(msg.Usage)
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		if a.messages[i].Role == "assistant" {
+			a.messages[i].Truncated = msg.FinishReason == "length"
+			a.messages[i].Timestamp = time.Now()
+			return
+		}
+	}
+}
+
+// summaryKeepTurns is the number of recent turns left intact by automatic summarization.
+const summaryKeepTurns = 2
+
+const summaryPrompt = "Summarize the following conversation in 3 bullet points. Keep file names, decisions and open questions.\n\n"
+
+// AutoSummarize starts summarizing the older messages if the finished turn left the
+// conversation longer than the context limit. It returns nil otherwise.
+func (a *Agent) AutoSummarize() tea.Cmd {
+	if a.contextLimit <= 0 || a.summarizing || len(a.pendingToolCalls) > 0 {
+		return nil
+	}
+	if last := a.messages[len(a.messages)-1]; last.Role != "assistant" || len(last.ToolCalls) > 0 {
+		return nil // The turn is still in progress
+	}
+	if EstimateTokens(a.messages) <= a.contextLimit {
+		return nil
+	}
+	return a.SummarizeHistory(summaryKeepTurns)
+}
+
+// SummarizeHistory asks the model, without streaming, to summarize every message except
+// the system prompt and the last keepLast turns. HandleSummary replaces those messages
+// with the summary. It returns nil if there is nothing old enough to summarize.
+func (a *Agent) SummarizeHistory(keepLast int) tea.Cmd {
+	cut := summaryCut(a.messages, keepLast)
+	if cut <= 1 {
+		return nil
+	}
+	a.summarizing = true
+
+	prompt := []Message{{Role: "user", Content: summaryPrompt + transcript(a.messages[1:cut])}}
+	return func() tea.Msg {
+		reply, usage, err := a.client.complete(context.Background(), prompt, a.modelName, nil, a.getCompletionOptions())
+		return SummaryMsg{Summary: strings.TrimSpace(reply.Content), Replaced: cut - 1, Usage: usage, Err: err}
+	}
+}
+
+// HandleSummary replaces the summarized messages with a single user message holding the summary.
+func (a *Agent) HandleSummary(msg SummaryMsg) {
+	if !a.summarizing {
+		return // The conversation was reset in the meantime
+	}
+	a.summarizing = false
+	a.usage = a.usage.Add(msg.Usage)
+	if msg.Err != nil || msg.Summary == "" || 1+msg.Replaced > len(a.messages) {
+		return
+	}
+
+	summary := Message{Role: "user", Content: "Conversation summary: " + msg.Summary}
+	messages := append([]Message{a.messages[0], summary}, a.messages[1+msg.Replaced:]...)
+	a.messages = messages
+}
+
+// summaryCut returns the index of the user message that starts the keepLast-th most recent
+// turn. Cutting there never separates a tool call from its result.
+func summaryCut(messages []Message, keepLast int) int {
+	turns := 0
+	for i := len(messages) - 1; i > 0; i-- {
+		if messages[i].Role == "user" {
+			turns++
+			if turns == keepLast {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// transcript renders messages as plain text for the summarization prompt.
+func transcript(messages []Message) string {
+	var b strings.Builder
+	for _, m := range messages {
+		if m.Content != "" {
+			fmt.Fprintf(&b, "%s: %s\n\n", m.Role, m.Content)
+		}
+		for _, call := range m.ToolCalls {
+			fmt.Fprintf(&b, "assistant called %s(%s)\n\n", call.Function.Name, call.Function.Arguments)
+		}
+	}
+	return b.String()
+}
+
+// EvaluateLastResponse grades the final assistant answer of the current turn.
+// It returns nil when auto-eval is disabled or the turn did not end with a text answer.
+func (a *Agent) EvaluateLastResponse() tea.Cmd {
+	if !a.autoEval || len(a.messages) == 0 {
+		return nil
+	}
+
+	last := a.messages[len(a.messages)-1]
+	if last.Role != "assistant" || last.Content == "" || len(last.ToolCalls) > 0 {
+		return nil
+	}
+
+	var question string
+	for i := len(a.messages) - 1; i >= 0; i-- {
+		if a.messages[i].Role == "user" {
+			question = a.messages[i].Content
+			break
+		}
+	}
+
+	evaluator, ok := a.toolRegistry["evaluate_response"].(*tools.EvaluateResponseTool)
+	if !ok {
+		return nil
+	}
+
+	return func() tea.Msg {
+		evaluation, err := evaluator.Evaluate(question, last.Content, nil)
+		return EvaluationMsg{Overall: evaluation.Overall, Err: err}
+	}
+}
+
 // HandleToolCallRequest sets up the agent to process tool calls.
 func (a *Agent) HandleToolCallRequest(msg AssistantToolCallMsg) tea.Cmd {
 	// 如果最后一条消息是 assistant 消息（在流式输出过程中创建的），
@@ -127,6 +760,16 @@ func (a *Agent) HandleToolCallRequest(msg AssistantToolCallMsg) tea.Cmd {
 	}
 	a.pendingToolCalls = msg.Message.ToolCalls
 	a.lastStreamedContent = ""
+	if a.requiresPlan && !a.planRequested {
+		a.planRequested = true
+		return a.requestPlan()
+	}
+	return a.processToolCalls()
+}
+
+// HandlePlan stores the advisory plan and starts executing the pending tool calls.
+func (a *Agent) HandlePlan(msg PlanMsg) tea.Cmd {
+	a.plan = msg.Steps
 	return a.processToolCalls()
 }
 
@@ -140,6 +783,19 @@ func (a *Agent) HandleToolResult(toolCallID, result string) tea.Cmd {
 	return a.processToolCalls()
 }
 
+// HandleToolResults adds the results of tool calls that ran in parallel, in call order,
+// and continues processing.
+func (a *Agent) HandleToolResults(results []ToolResultMsg) tea.Cmd {
+	for _, result := range results {
+		a.messages = append(a.messages, Message{
+			Role:       "tool",
+			ToolCallID: result.ToolCallID,
+			Content:    result.Result,
+		})
+	}
+	return a.processToolCalls()
+}
+
 // HandleConfirmation handles the user's decision on a tool call confirmation.
 func (a *Agent) HandleConfirmation(confirmed bool) tea.Cmd {
 	a.isConfirming = false
@@ -147,19 +803,264 @@ func (a *Agent) HandleConfirmation(confirmed bool) tea.Cmd {
 	a.pendingToolCalls = a.pendingToolCalls[1:] // Consume the call
 
 	if confirmed {
-		return a.executeTool(toolCall)
+		return a.executeTool(toolCall, true)
 	}
 
 	// User denied, create a synthetic result and handle it.
-	result := "User denied execution of tool: " + toolCall.Function.Name
-	return a.HandleToolResult(toolCall.ID, result)
+	return a.HandleToolResult(toolCall.ID, a.denyTool(toolCall))
+}
+
+// HandlePastedInput supplies the user's pasted text as the result of the pending input tool call.
+func (a *Agent) HandlePastedInput(content string) tea.Cmd {
+	a.isAwaitingInput = false
+	toolCall := a.inputToolCall
+	a.pendingToolCalls = a.pendingToolCalls[1:] // Consume the call
+	return a.HandleToolResult(toolCall.ID, tools.FormatPastedContent(content))
+}
+
+// Run processes a single prompt without a UI, executing the tool calls the model requests
+// until it answers with text or the tool limit is reached, and returns the final assistant text.
+// Tools that require confirmation run without asking unless WithConfirmFunc was given.
+func (a *Agent) Run(input string) (string, error) {
+	a.toolIterations = 0
+	a.messages = append(a.messages, Message{Role: "user", Content: input, Timestamp: time.Now()})
+
+	for {
+		availableTools := a.getAvailableToolsAsJSON()
+		if a.toolLimitReached() {
+			availableTools = nil // Force a final text answer
+		}
+
+		message, usage, err := a.client.complete(context.Background(), a.messages, a.modelName, availableTools, a.conversationOptions())
+		if err != nil {
+			return "", err
+		}
+		a.usage = a.usage.Add(usage)
+		message.Timestamp = time.Now()
+		a.messages = append(a.messages, message)
+
+		if len(message.ToolCalls) == 0 {
+			return message.Content, nil
+		}
+
+		for i := 0; i < len(message.ToolCalls); i++ {
+			if batch := a.parallelBatch(message.ToolCalls[i:]); len(batch) > 1 {
+				a.toolIterations += len(batch)
+				for _, result := range a.runToolsParallel(batch) {
+					a.messages = append(a.messages, Message{
+						Role:       "tool",
+						ToolCallID: result.ToolCallID,
+						Content:    result.Result,
+					})
+				}
+				i += len(batch) - 1
+				continue
+			}
+
+			toolCall := message.ToolCalls[i]
+			var result string
+			if err := a.validateToolCall(toolCall); err != nil {
+				result = err.Error()
+			} else if a.disabledByReadOnly(toolCall) {
+				result = readOnlyResult
+			} else if a.toolLimitReached() {
+				result = maxToolIterationsResult
+			} else if a.needsConfirmation(toolCall) && !a.confirmFunc(toolCall) {
+				result = a.denyTool(toolCall)
+			} else {
+				a.toolIterations++
+				result = a.runTool(toolCall, a.needsConfirmation(toolCall))
+			}
+			a.messages = append(a.messages, Message{
+				Role:       "tool",
+				ToolCallID: toolCall.ID,
+				Content:    result,
+			})
+		}
+	}
 }
 
 // --- Internal Logic ---
 
+// argumentErrorPrefix starts the tool result of a call whose arguments failed validation,
+// telling the model that the call was not run and should be retried with corrected arguments.
+const argumentErrorPrefix = "Argument error: "
+
+// validateToolCall checks the call's arguments if the tool implements tools.ArgsValidator.
+// The error's message is ready to be used as the tool result.
+func (a *Agent) validateToolCall(toolCall ToolCall) error {
+	tool, ok := a.toolRegistry[toolCall.Function.Name]
+	if !ok {
+		return nil // runTool reports unknown tools
+	}
+	if validator, ok := tool.(tools.ArgsValidator); ok {
+		if err := validator.ValidateArgs(toolCall.Function.Arguments); err != nil {
+			return fmt.Errorf("%s%w", argumentErrorPrefix, err)
+		}
+	}
+	return nil
+}
+
+// needsConfirmation reports whether Run must ask the confirm function before running the call.
+func (a *Agent) needsConfirmation(toolCall ToolCall) bool {
+	tool, ok := a.toolRegistry[toolCall.Function.Name]
+	return a.confirmFunc != nil && ok && tools.NeedsConfirmation(tool, toolCall.Function.Arguments)
+}
+
+// deniedResult is the tool result given for a call the user refused.
+func deniedResult(toolCall ToolCall) string {
+	return "User denied execution of tool: " + toolCall.Function.Name
+}
+
+// denyTool reports a call the user refused to the post-tool hooks and returns its result.
+func (a *Agent) denyTool(toolCall ToolCall) string {
+	a.runPostToolHooks(toolCall.Function.Name, toolCall.Function.Arguments, "", ErrToolDenied, false)
+	return deniedResult(toolCall)
+}
+
+func (a *Agent) runPostToolHooks(toolName, args, result string, err error, confirmed bool) {
+	for _, hook := range a.postToolHooks {
+		hook(toolName, args, result, err, confirmed)
+	}
+}
+
+// disabledByReadOnly reports whether read-only mode refuses the call, because the tool modifies
+// files or the call needs confirmation.
+func (a *Agent) disabledByReadOnly(toolCall ToolCall) bool {
+	tool, ok := a.toolRegistry[toolCall.Function.Name]
+	if !a.readOnly || !ok {
+		return false
+	}
+	return tools.ModifiesFiles(tool) || tools.NeedsConfirmation(tool, toolCall.Function.Arguments)
+}
+
+// toolLimitReached reports whether the agent has run as many tools this turn as it may.
+func (a *Agent) toolLimitReached() bool {
+	return a.maxToolIterations > 0 && a.toolIterations >= a.maxToolIterations
+}
+
+// runTool executes a tool call synchronously and returns its result as a string.
+// confirmed tells the audit log whether the user approved the call.
+func (a *Agent) runTool(toolCall ToolCall, confirmed bool) string {
+	tool, ok := a.toolRegistry[toolCall.Function.Name]
+	if !ok {
+		return fmt.Sprintf("Error: tool %s not found in registry", toolCall.Function.Name)
+	}
+
+	name, args := toolCall.Function.Name, toolCall.Function.Arguments
+	if a.preToolHook != nil {
+		if err := a.preToolHook(name, args); err != nil {
+			return fmt.Sprintf("Error: tool %s was blocked: %v", name, err)
+		}
+	}
+
+	// Calls that need confirmation, or tools that modify files, may change what cached results describe
+	modifies := tools.ModifiesFiles(tool) || tools.NeedsConfirmation(tool, args)
+	cacheable := a.toolCache != nil && !modifies && !uncachedTools[name]
+	if cacheable {
+		if result, ok := a.toolCache.Get(name, args); ok {
+			a.logger.Debug("tool result from cache", "tool", name)
+			return result
+		}
+	}
+
+	start := time.Now()
+	result, err := tools.ExecuteContext(a.ctx, tool, args)
+	if err != nil {
+		a.logger.Debug("tool executed", "tool", name, "duration", time.Since(start), "error", err)
+	} else {
+		a.logger.Debug("tool executed", "tool", name, "duration", time.Since(start))
+	}
+	a.runPostToolHooks(name, args, result, err, confirmed)
+	if modifies && a.toolCache != nil {
+		// Even a failed call may have changed something
+		a.toolCache.Invalidate(args)
+	}
+	if err != nil {
+		return fmt.Sprintf("Error executing tool %s: %v", toolCall.Function.Name, err)
+	}
+
+	if cacheable {
+		a.toolCache.Put(name, args, result)
+	}
+	return result
+}
+
+// ClearToolCache forgets every cached tool result. It does nothing if caching is disabled.
+func (a *Agent) ClearToolCache() {
+	if a.toolCache != nil {
+		a.toolCache.Clear()
+	}
+}
+
+// planTimeout bounds how long the first tool call waits for the advisory plan.
+const planTimeout = 5 * time.Second
+
+const planPrompt = `Before you run any tools: list all tool calls you plan to make to complete my request, in order, as a JSON array.
+Each element must be an object like {"tool": "<tool name>", "purpose": "<what the call is for>"}. Reply with the JSON array only.`
+
+// requestPlan asks the model, without streaming, for the list of tool calls it intends to make.
+// The plan is advisory only, so errors and timeouts yield an empty plan instead of failing the turn.
+func (a *Agent) requestPlan() tea.Cmd {
+	// Leave out the assistant message that requested tools: the API rejects
+	// tool calls that are not followed by their results.
+	history := append([]Message{}, a.messages[:len(a.messages)-1]...)
+	history = append(history, Message{Role: "user", Content: planPrompt})
+	opts := a.getCompletionOptions()
+	parent := a.ctx
+
+	return func() tea.Msg {
+		// Derived from the turn's context, so cancelling the turn also abandons the plan
+		ctx, cancel := context.WithTimeout(parent, planTimeout)
+		defer cancel()
+
+		reply, _, err := a.client.complete(ctx, history, a.modelName, nil, opts)
+		if err != nil {
+			return PlanMsg{}
+		}
+		return PlanMsg{Steps: parsePlan(reply.Content)}
+	}
+}
+
+// parsePlan extracts the JSON array of plan steps from the model's reply.
+func parsePlan(reply string) []PlanStep {
+	start := strings.Index(reply, "[")
+	end := strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil
+	}
+
+	var steps []PlanStep
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &steps); err != nil {
+		return nil
+	}
+	return steps
+}
+
 func (a *Agent) processToolCalls() tea.Cmd {
 	if len(a.pendingToolCalls) == 0 {
-		return a.client.CompletionStream(a.messages, a.modelName, a.getAvailableToolsAsJSON())
+		return a.streamCompletion(a.getAvailableToolsAsJSON())
+	}
+
+	if a.toolLimitReached() {
+		// Every tool call needs a result, so answer the remaining ones before the final completion.
+		for _, pending := range a.pendingToolCalls {
+			a.messages = append(a.messages, Message{
+				Role:       "tool",
+				ToolCallID: pending.ID,
+				Content:    maxToolIterationsResult,
+			})
+		}
+		a.pendingToolCalls = nil
+		return a.streamCompletion(nil)
+	}
+
+	if batch := a.parallelBatch(a.pendingToolCalls); len(batch) > 1 {
+		a.pendingToolCalls = a.pendingToolCalls[len(batch):]
+		a.toolIterations += len(batch)
+		return func() tea.Msg {
+			return ToolResultsMsg{Results: a.runToolsParallel(batch)}
+		}
 	}
 
 	toolCall := a.pendingToolCalls[0]
@@ -170,8 +1071,31 @@ func (a *Agent) processToolCalls() tea.Cmd {
 		}
 	}
 
-	if tool.RequiresConfirmation() {
+	// Reject malformed arguments before asking for confirmation, so the model can retry.
+	if err := a.validateToolCall(toolCall); err != nil {
+		a.pendingToolCalls = a.pendingToolCalls[1:]
+		return a.HandleToolResult(toolCall.ID, err.Error())
+	}
+
+	if a.disabledByReadOnly(toolCall) {
+		a.pendingToolCalls = a.pendingToolCalls[1:]
+		return a.HandleToolResult(toolCall.ID, readOnlyResult)
+	}
+
+	if inputTool, ok := tool.(tools.UserInputTool); ok && inputTool.RequiresUserInput() {
+		a.inputToolCall = toolCall
+		a.isAwaitingInput = true
+		return func() tea.Msg {
+			return UserInputRequiredMsg{ToolCall: toolCall}
+		}
+	}
+
+	if tools.NeedsConfirmation(tool, toolCall.Function.Arguments) {
 		a.confirmingToolCall = toolCall
+		a.confirmationSummary = ""
+		if summarizer, ok := tool.(tools.ConfirmationSummarizer); ok {
+			a.confirmationSummary = summarizer.ConfirmationSummary(toolCall.Function.Arguments)
+		}
 		a.isConfirming = true
 		// 返回一个命令来通知 UI 需要确认，而不是返回 nil
 		return func() tea.Msg {
@@ -180,20 +1104,61 @@ func (a *Agent) processToolCalls() tea.Cmd {
 	}
 
 	a.pendingToolCalls = a.pendingToolCalls[1:]
-	return a.executeTool(toolCall)
+	return a.executeTool(toolCall, false)
 }
 
-func (a *Agent) executeTool(toolCall ToolCall) tea.Cmd {
-	return func() tea.Msg {
-		tool, _ := a.toolRegistry[toolCall.Function.Name]
-		result, err := tool.Execute(toolCall.Function.Arguments)
-		if err != nil {
-			result = fmt.Sprintf("Error executing tool %s: %v", toolCall.Function.Name, err)
+// parallelBatch returns the leading calls of toolCalls that may run concurrently: those of
+// known tools with valid arguments that need neither confirmation nor user input, up to the
+// tool limit. It returns nothing unless parallel tools are enabled.
+func (a *Agent) parallelBatch(toolCalls []ToolCall) []ToolCall {
+	if !a.parallelTools {
+		return nil
+	}
+	limit := len(toolCalls)
+	if a.maxToolIterations > 0 {
+		limit = min(limit, a.maxToolIterations-a.toolIterations)
+	}
+
+	n := 0
+	for n < limit {
+		toolCall := toolCalls[n]
+		tool, ok := a.toolRegistry[toolCall.Function.Name]
+		if !ok || a.validateToolCall(toolCall) != nil || a.disabledByReadOnly(toolCall) {
+			break
+		}
+		if inputTool, ok := tool.(tools.UserInputTool); ok && inputTool.RequiresUserInput() {
+			break
+		}
+		if tools.NeedsConfirmation(tool, toolCall.Function.Arguments) {
+			break
 		}
+		n++
+	}
+	return toolCalls[:n]
+}
 
+// runToolsParallel runs every call in its own goroutine and returns the results in call order.
+// The caller counts the calls towards the tool limit.
+func (a *Agent) runToolsParallel(toolCalls []ToolCall) []ToolResultMsg {
+	results := make([]ToolResultMsg, len(toolCalls))
+	var wg sync.WaitGroup
+	for i, toolCall := range toolCalls {
+		wg.Add(1)
+		go func(i int, toolCall ToolCall) {
+			defer wg.Done()
+			results[i] = ToolResultMsg{ToolCallID: toolCall.ID, Result: a.runTool(toolCall, false)}
+		}(i, toolCall)
+	}
+	wg.Wait()
+	return results
+}
+
+func (a *Agent) executeTool(toolCall ToolCall, confirmed bool) tea.Cmd {
+	a.toolIterations++
+	return func() tea.Msg {
 		return ToolResultMsg{
 			ToolCallID: toolCall.ID,
-			Result:     result,
+			Result:     a.runTool(toolCall, confirmed),
 		}
 	}
 }
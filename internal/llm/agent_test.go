@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tachigoma/internal/audit"
+)
+
+func TestDisabledByReadOnly(t *testing.T) {
+	tests := []struct {
+		tool string
+		args string
+		want bool
+	}{
+		{tool: "read_file", args: `{"path": "go.mod"}`, want: false},
+		{tool: "write_file", args: `{"path": "x.txt", "content": "x"}`, want: true},
+		{tool: "create_directory", args: `{"path": "x"}`, want: true},
+		{tool: "append_file", args: `{"path": "x.txt", "content": "x"}`, want: true},
+		{tool: "env_get", args: `{"name": "HOME"}`, want: false},
+		{tool: "env_get", args: `{"name": "API_TOKEN"}`, want: true},
+	}
+
+	agent := NewAgent(nil, "test-model", WithReadOnly(true))
+	writable := NewAgent(nil, "test-model")
+	for _, tt := range tests {
+		t.Run(tt.tool+" "+tt.args, func(t *testing.T) {
+			if _, ok := agent.toolRegistry[tt.tool]; !ok {
+				t.Fatalf("tool %s is not registered", tt.tool)
+			}
+			call := ToolCall{ID: "call_1", Type: "function"}
+			call.Function.Name = tt.tool
+			call.Function.Arguments = tt.args
+			if got := agent.disabledByReadOnly(call); got != tt.want {
+				t.Errorf("disabledByReadOnly() = %v, want %v", got, tt.want)
+			}
+			if writable.disabledByReadOnly(call) {
+				t.Error("disabledByReadOnly() = true without read-only mode")
+			}
+		})
+	}
+}
+
+// recordingAuditLogger keeps the entries it is given.
+type recordingAuditLogger struct {
+	entries []audit.AuditEntry
+}
+
+func (l *recordingAuditLogger) Log(entry audit.AuditEntry) {
+	l.entries = append(l.entries, entry)
+}
+
+func TestAuditLogsRefusedAndRedactedCalls(t *testing.T) {
+	t.Setenv("TACHIGOMA_TEST_API_KEY", "sk-0123456789abcdef")
+	replies := []string{
+		`{"choices": [{"message": {"role": "assistant", "tool_calls": [
+			{"id": "call_1", "type": "function", "function": {"name": "env_get", "arguments": "{\"name\": \"TACHIGOMA_TEST_API_KEY\"}"}}
+		]}, "finish_reason": "tool_calls"}]}`,
+		`{"choices": [{"message": {"role": "assistant", "tool_calls": [
+			{"id": "call_2", "type": "function", "function": {"name": "env_get", "arguments": "{\"name\": \"TACHIGOMA_TEST_API_KEY\"}"}}
+		]}, "finish_reason": "tool_calls"}]}`,
+		okResponse,
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, replies[requests])
+		requests++
+	}))
+	defer server.Close()
+
+	logger := &recordingAuditLogger{}
+	var hooked []string
+	answers := []bool{false, true}
+	agent := NewAgent(NewClient(server.URL, "test-key"), "test-model",
+		WithAuditLogger(logger),
+		WithPostToolHook(func(toolName, args, result string, err error, confirmed bool) {
+			hooked = append(hooked, toolName)
+		}),
+		WithConfirmFunc(func(ToolCall) bool {
+			answer := answers[0]
+			answers = answers[1:]
+			return answer
+		}))
+	if _, err := agent.Run("show the key"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(logger.entries) != 2 || len(hooked) != 2 {
+		t.Fatalf("audit log has %d entries and the hook ran %d times, want 2 each: %+v", len(logger.entries), len(hooked), logger.entries)
+	}
+	refused, ran := logger.entries[0], logger.entries[1]
+	if refused.UserConfirmed || !refused.Error || !strings.Contains(refused.Result, ErrToolDenied.Error()) {
+		t.Errorf("refused call logged as %+v, want an unconfirmed error entry", refused)
+	}
+	if !ran.UserConfirmed || ran.Error || strings.Contains(ran.Result, "sk-") {
+		t.Errorf("confirmed call logged as %+v, want a confirmed entry with the secret redacted", ran)
+	}
+}
+
+func TestAgentCompleterIgnoresUserOptions(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, okResponse)
+	}))
+	defer server.Close()
+
+	agent := NewAgent(NewClient(server.URL, "test-key"), "test-model",
+		WithStopSequences("END"), WithSeed(7), WithMaxResponseTokens(16), WithJSONMode())
+	if _, err := (agentCompleter{agent: agent}).Complete("judge this"); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	for _, field := range []string{"stop", "seed", "max_tokens", "response_format"} {
+		if _, ok := body[field]; ok {
+			t.Errorf("request sent %q = %v, want the server default", field, body[field])
+		}
+	}
+}
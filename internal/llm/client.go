@@ -3,90 +3,315 @@ package llm
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbletea"
 )
 
+// TruncatedNote is shown after a response that was cut off by the max tokens limit.
+const TruncatedNote = "(response was cut off at max tokens limit)"
+
 // Client is the API client for the LLM.
 type Client struct {
 	apiURL string
 	apiKey string
 	http   *http.Client
+	opts   ClientOptions
+	proxy  *url.URL // Nil uses HTTP_PROXY and HTTPS_PROXY from the environment
+	logger *slog.Logger
+}
+
+// ClientOptions holds the request settings of a Client.
+type ClientOptions struct {
+	// Timeout bounds each request, including reading a streamed response. Zero means no timeout.
+	Timeout time.Duration
+	// MaxRetries is how many times a request is retried after a network error or 5xx status.
+	MaxRetries int
+	// RetryBackoff caps the delay between retries, which starts at retryBaseDelay and doubles.
+	RetryBackoff time.Duration
+}
+
+// retryBaseDelay is the delay before the first retry.
+const retryBaseDelay = 500 * time.Millisecond
+
+// ClientOption configures optional Client behaviour.
+type ClientOption func(*Client)
+
+// WithTimeout limits how long a single request, including its streamed response, may take.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.opts.Timeout = d
+	}
+}
+
+// WithRetry retries requests that fail with a network error or 5xx status up to maxRetries times,
+// with an exponential backoff of at most maxBackoff between attempts.
+func WithRetry(maxRetries int, maxBackoff time.Duration) ClientOption {
+	return func(c *Client) {
+		c.opts.MaxRetries = maxRetries
+		c.opts.RetryBackoff = maxBackoff
+	}
+}
+
+// WithHTTPTransport makes the client send requests through t, e.g. to tune connection pooling.
+func WithHTTPTransport(t *http.Transport) ClientOption {
+	return func(c *Client) {
+		c.http.Transport = t
+	}
+}
+
+// discardLogger is the default logger, so logging is silent unless WithLogger is given.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// WithLogger logs requests and stream events to l at debug level. Agents created with
+// the client log their tool executions to it too.
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithProxy sends every request through the HTTP proxy at proxyURL instead of the one
+// named by the HTTP_PROXY and HTTPS_PROXY environment variables.
+func WithProxy(proxyURL *url.URL) ClientOption {
+	return func(c *Client) {
+		c.proxy = proxyURL
+	}
 }
 
-// NewClient creates a new LLM client.
-func NewClient(apiURL, apiKey string) *Client {
-	return &Client{
+// NewClient creates a new LLM client. Unless WithProxy is given, requests go through the
+// proxy configured in the environment, if any.
+func NewClient(apiURL, apiKey string, opts ...ClientOption) *Client {
+	c := &Client{
 		apiURL: apiURL,
 		apiKey: apiKey,
 		http:   &http.Client{},
+		logger: discardLogger,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// Applied last, so the proxy also holds for a transport given by WithHTTPTransport
+	if c.proxy != nil {
+		transport, ok := c.http.Transport.(*http.Transport)
+		if !ok {
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+			c.http.Transport = transport
+		}
+		transport.Proxy = http.ProxyURL(c.proxy)
+	}
+	return c
+}
+
+// NewClientWithPool creates a client with its own transport and connection limits. The default
+// transport also reuses connections but keeps at most two idle ones per host and never limits
+// how many are open, which matters for parallel tool calls and servers with few slots.
+//
+// Recommended settings:
+//   - Interactive use against a single API host: maxIdleConns 10, maxConnsPerHost 4.
+//   - Scripted or batch use with many sequential requests: maxIdleConns 32, maxConnsPerHost 16.
+//   - Local servers (Ollama, llama.cpp) that handle one request at a time: maxConnsPerHost 1.
+//
+// A maxConnsPerHost of 0 means no limit.
+func NewClientWithPool(apiURL, apiKey string, maxIdleConns, maxConnsPerHost int) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxIdleConns
+	transport.MaxIdleConnsPerHost = maxIdleConns
+	transport.MaxConnsPerHost = maxConnsPerHost
+	transport.IdleConnTimeout = 90 * time.Second
+
+	return NewClient(apiURL, apiKey, WithHTTPTransport(transport))
 }
 
 // Completion sends a list of messages to the LLM and returns the response.
-func (c *Client) Completion(messages []Message, model string) (string, error) {
-	// For this non-streaming mode, we won't send tools, just a simple chat.
-	reqBody := CompletionRequest{
-		Model:    model,
-		Messages: messages,
-	}
+func (c *Client) Completion(messages []Message, model string, opts CompletionOptions) (string, error) {
+	response, _, err := c.CompletionWithUsage(messages, model, opts)
+	return response, err
+}
 
-	jsonBody, err := json.Marshal(reqBody)
+// CompletionWithUsage is like Completion but also returns the tokens the request used.
+func (c *Client) CompletionWithUsage(messages []Message, model string, opts CompletionOptions) (string, Usage, error) {
+	// No tools are sent, so this is a plain chat; Agent.Run executes tool calls instead.
+	message, usage, err := c.complete(context.Background(), messages, model, nil, opts)
 	if err != nil {
-		return "", fmt.Errorf("error marshalling request body: %w", err)
+		return "", usage, err
+	}
+
+	if message.Content != "" && message.Truncated {
+		return message.Content + "\n\n" + TruncatedNote, usage, nil
+	}
+	if message.Content != "" {
+		return message.Content, usage, nil
+	}
+
+	// The model may still ask for a tool it saw earlier in the conversation.
+	if len(message.ToolCalls) > 0 {
+		return "[Tachigoma wanted to use a tool, but tools are not available for this request.]", usage, nil
+	}
+
+	return "", usage, fmt.Errorf("no response choices found")
+}
+
+// APIError is returned when the API answers with a status other than 200 OK.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// newCompletionRequest builds the request body. stream_options is only valid together with
+// stream, and some backends reject it otherwise, so it is only set for streamed requests.
+func newCompletionRequest(messages []Message, model string, tools []Tool, opts CompletionOptions, stream bool) CompletionRequest {
+	req := CompletionRequest{
+		Model:          model,
+		Messages:       messages,
+		Tools:          tools,
+		MaxTokens:      opts.MaxTokens,
+		Seed:           opts.Seed,
+		Stop:           opts.Stop,
+		Temperature:    opts.Temperature,
+		ResponseFormat: opts.ResponseFormat,
+	}
+	if stream {
+		req.Stream = true
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
 	}
+	return req
+}
 
-	req, err := http.NewRequest("POST", c.apiURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+// complete performs a single non-streaming request and returns the assistant message,
+// including any tool calls the model asked for, and the tokens the request used.
+// Cancelling ctx aborts the request.
+func (c *Client) complete(ctx context.Context, messages []Message, model string, tools []Tool, opts CompletionOptions) (Message, Usage, error) {
+	jsonBody, err := json.Marshal(newCompletionRequest(messages, model, tools, opts, false))
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %w", err)
+		return Message{}, Usage{}, fmt.Errorf("error marshalling request body: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
 
-	resp, err := c.http.Do(req)
+	resp, err := c.post(ctx, jsonBody, false)
 	if err != nil {
-		return "", fmt.Errorf("error making request: %w", err)
+		return Message{}, Usage{}, err
 	}
-	defer resp.Body.Close()
+	defer drainAndClose(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return Message{}, Usage{}, &APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
 	}
 
 	var compResp CompletionResponse
 	if err := json.NewDecoder(resp.Body).Decode(&compResp); err != nil {
-		return "", fmt.Errorf("error decoding response: %w", err)
+		return Message{}, Usage{}, fmt.Errorf("error decoding response: %w", err)
 	}
 
-	if len(compResp.Choices) > 0 && compResp.Choices[0].Message.Content != "" {
-		return compResp.Choices[0].Message.Content, nil
+	if len(compResp.Choices) == 0 {
+		return Message{}, Usage{}, fmt.Errorf("no response choices found")
 	}
 
-	// Handle the case where the model wants to call a tool, even in non-streaming mode.
-	// For this simple mode, we'll just indicate that a tool call was attempted.
-	if len(compResp.Choices) > 0 && len(compResp.Choices[0].Message.ToolCalls) > 0 {
-		return "[Tachigoma wanted to use a tool. Please use interactive mode to allow tool usage.]", nil
+	var usage Usage
+	if compResp.Usage != nil {
+		usage = *compResp.Usage
 	}
 
-	return "", fmt.Errorf("no response choices found")
+	c.logger.Debug("completion", "model", model, "finish_reason", compResp.Choices[0].FinishReason, "system_fingerprint", compResp.SystemFingerprint)
+	message := compResp.Choices[0].Message
+	message.Truncated = compResp.Choices[0].FinishReason == "length"
+	return message, usage, nil
+}
+
+// requestContext returns the context for a single request derived from parent, bounded by the configured timeout.
+func (c *Client) requestContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if c.opts.Timeout > 0 {
+		return context.WithTimeout(parent, c.opts.Timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// post sends a chat completion request, retrying network errors and 5xx responses as configured.
+// Other non-200 responses are returned to the caller as is.
+func (c *Client) post(ctx context.Context, jsonBody []byte, stream bool) (*http.Response, error) {
+	delay := retryBaseDelay
+	if c.opts.RetryBackoff > 0 && delay > c.opts.RetryBackoff {
+		delay = c.opts.RetryBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.apiURL+"/chat/completions", bytes.NewReader(jsonBody))
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+		if stream {
+			req.Header.Set("Accept", "text/event-stream")
+			req.Header.Set("Cache-Control", "no-cache")
+			req.Header.Set("Connection", "keep-alive")
+		}
+
+		start := time.Now()
+		resp, err := c.http.Do(req)
+		if err != nil {
+			c.logger.Debug("http request failed", "method", req.Method, "url", req.URL.String(), "attempt", attempt+1, "duration", time.Since(start), "error", err)
+		} else {
+			c.logger.Debug("http request", "method", req.Method, "url", req.URL.String(), "status", resp.StatusCode, "attempt", attempt+1, "duration", time.Since(start))
+		}
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+
+		if attempt >= c.opts.MaxRetries || ctx.Err() != nil {
+			if err != nil {
+				return nil, fmt.Errorf("error making request: %w", err)
+			}
+			return resp, nil // The caller reports the 5xx status
+		}
+		if resp != nil {
+			drainAndClose(resp.Body)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error making request: %w", ctx.Err())
+		}
+		delay *= 2
+		if c.opts.RetryBackoff > 0 && delay > c.opts.RetryBackoff {
+			delay = c.opts.RetryBackoff
+		}
+	}
+}
+
+// drainAndClose reads any unread bytes before closing the body so the connection can be reused.
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(io.Discard, body)
+	body.Close()
 }
 
 // --- Client Methods ---
 // CompletionStream sends a list of messages and returns a command that streams the response.
-func (c *Client) CompletionStream(messages []Message, model string, tools []Tool) tea.Cmd {
+// Cancelling ctx aborts the request and stops the streaming goroutine.
+func (c *Client) CompletionStream(ctx context.Context, messages []Message, model string, tools []Tool, opts CompletionOptions) tea.Cmd {
 	return func() tea.Msg {
 		ch := make(chan tea.Msg)
 
 		go func() {
 			defer close(ch)
-			c.runCompletionStream(messages, model, tools, ch)
+			c.runCompletionStream(ctx, messages, model, tools, opts, ch)
 		}()
 
 		return Stream(ch)
@@ -94,56 +319,59 @@ func (c *Client) CompletionStream(messages []Message, model string, tools []Tool
 }
 
 // runCompletionStream handles the actual logic of streaming, tool calls, and looping.
-func (c *Client) runCompletionStream(messages []Message, model string, tools []Tool, ch chan tea.Msg) {
-	reqBody := CompletionRequest{
-		Model:    model,
-		Messages: messages,
-		Stream:   true,
-		Tools:    tools,
-	}
-
-	jsonBody, err := json.Marshal(reqBody)
-	if err != nil {
-		ch <- ErrorMsg{fmt.Errorf("error marshalling request body: %w", err)}
-		return
+func (c *Client) runCompletionStream(ctx context.Context, messages []Message, model string, tools []Tool, opts CompletionOptions, ch chan tea.Msg) {
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	// Nobody reads the channel once the stream is cancelled, so sends must not block.
+	send := func(msg tea.Msg) bool {
+		select {
+		case ch <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
 	}
 
-	req, err := http.NewRequest("POST", c.apiURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	jsonBody, err := json.Marshal(newCompletionRequest(messages, model, tools, opts, true))
 	if err != nil {
-		ch <- ErrorMsg{fmt.Errorf("error creating request: %w", err)}
+		send(ErrorMsg{fmt.Errorf("error marshalling request body: %w", err)})
 		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("Accept", "text/event-stream")
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("Connection", "keep-alive")
-
-	resp, err := c.http.Do(req)
+	resp, err := c.post(ctx, jsonBody, true)
 	if err != nil {
-		ch <- ErrorMsg{fmt.Errorf("error making request: %w", err)}
+		send(ErrorMsg{err})
 		return
 	}
-	defer resp.Body.Close()
+	defer drainAndClose(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		ch <- ErrorMsg{fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))}
+		send(ErrorMsg{&APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}})
 		return
 	}
 
-	ch <- StreamStartMsg{}
+	if !send(StreamStartMsg{}) {
+		return
+	}
+	start := time.Now()
+	chunks := 0
+	c.logger.Debug("stream start", "model", model)
 
 	// Variables to aggregate the response
 	var toolCalls []ToolCall
+	var finishReason string
+	var fingerprint string
+	var usage Usage
 
 	reader := bufio.NewReader(resp.Body)
 	for {
 		line, err := reader.ReadBytes('\n')
 		if err != nil {
 			if err != io.EOF {
-				ch <- ErrorMsg{fmt.Errorf("error reading stream: %w", err)}
+				c.logger.Debug("stream error", "chunks", chunks, "duration", time.Since(start), "error", err)
+				send(ErrorMsg{fmt.Errorf("error reading stream: %w", err)})
 			}
 			break // End of stream
 		}
@@ -165,12 +393,25 @@ func (c *Client) runCompletionStream(messages []Message, model string, tools []T
 			continue
 		}
 
+		if streamResp.SystemFingerprint != "" {
+			fingerprint = streamResp.SystemFingerprint
+		}
+		if streamResp.Usage != nil {
+			usage = *streamResp.Usage
+		}
+
 		if len(streamResp.Choices) > 0 {
 			choice := streamResp.Choices[0]
+			if choice.FinishReason != "" {
+				finishReason = choice.FinishReason
+			}
 
 			// Aggregate content
 			if choice.Delta.Content != "" {
-				ch <- StreamContentMsg{Content: choice.Delta.Content}
+				chunks++
+				if !send(StreamContentMsg{Content: choice.Delta.Content}) {
+					return
+				}
 			}
 
 			// Aggregate tool calls
@@ -204,11 +445,14 @@ func (c *Client) runCompletionStream(messages []Message, model string, tools []T
 
 		// Send this message to the TUI. The TUI will handle execution,
 		// user confirmation, and continuing the conversation.
-		ch <- AssistantToolCallMsg{Message: assistantMessage}
+		if !send(AssistantToolCallMsg{Message: assistantMessage}) {
+			return
+		}
 
 		// The rest of the stream processing for this turn is now complete.
 		// The TUI will initiate the next turn.
 	}
 
-	ch <- StreamEndMsg{}
+	c.logger.Debug("stream end", "chunks", chunks, "tool_calls", len(toolCalls), "finish_reason", finishReason, "system_fingerprint", fingerprint, "duration", time.Since(start))
+	send(StreamEndMsg{FinishReason: finishReason, Usage: usage})
 }
@@ -0,0 +1,374 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+)
+
+// okResponse is a minimal successful chat completion.
+const okResponse = `{"choices": [{"message": {"role": "assistant", "content": "ok"}, "finish_reason": "stop"}]}`
+
+// newRecordingServer returns a server answering every chat completion with okResponse.
+// The bodies of the requests it received are sent to the returned channel.
+func newRecordingServer(t *testing.T) (*httptest.Server, <-chan map[string]any) {
+	t.Helper()
+	bodies := make(chan map[string]any, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("error reading request body: %v", err)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		bodies <- body
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, okResponse)
+	}))
+	t.Cleanup(server.Close)
+	return server, bodies
+}
+
+func TestCompletionSeed(t *testing.T) {
+	zero, fortyTwo := 0, 42
+	tests := []struct {
+		name     string
+		seed     *int
+		wantSeed any // Nil means the field must be absent
+	}{
+		{name: "unset", seed: nil, wantSeed: nil},
+		{name: "zero", seed: &zero, wantSeed: float64(0)},
+		{name: "set", seed: &fortyTwo, wantSeed: float64(42)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, bodies := newRecordingServer(t)
+			client := NewClient(server.URL, "test-key")
+
+			if _, err := client.Completion([]Message{{Role: "user", Content: "hi"}}, "test-model", CompletionOptions{Seed: tt.seed}); err != nil {
+				t.Fatalf("Completion() error = %v", err)
+			}
+
+			body := <-bodies
+			seed, ok := body["seed"]
+			if tt.wantSeed == nil {
+				if ok {
+					t.Errorf("request contains seed %v, want no seed", seed)
+				}
+				return
+			}
+			if !ok || seed != tt.wantSeed {
+				t.Errorf("request seed = %v (present: %v), want %v", seed, ok, tt.wantSeed)
+			}
+		})
+	}
+}
+
+func TestAgentSendsSeed(t *testing.T) {
+	server, bodies := newRecordingServer(t)
+	client := NewClient(server.URL, "test-key")
+
+	agent := NewAgent(client, "test-model", WithSeed(7), WithTools(false))
+	if _, err := agent.Run("hi"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if seed := (<-bodies)["seed"]; seed != float64(7) {
+		t.Errorf("request seed = %v, want 7", seed)
+	}
+
+	agent = NewAgent(client, "test-model", WithTools(false))
+	if _, err := agent.Run("hi"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if seed, ok := (<-bodies)["seed"]; ok {
+		t.Errorf("request contains seed %v, want no seed", seed)
+	}
+}
+
+// BenchmarkRequests compares NewClientWithPool with the default client, whose transport already
+// reuses connections but keeps at most two idle ones per host, for sequential requests (as in a
+// turn with many tool calls) and parallel ones.
+func BenchmarkRequests(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, okResponse)
+	}))
+	defer server.Close()
+
+	clients := []struct {
+		name   string
+		client *Client
+	}{
+		{"default", NewClient(server.URL, "test-key")},
+		{"pooled", NewClientWithPool(server.URL, "test-key", 10, 4)},
+	}
+
+	messages := []Message{{Role: "user", Content: "hi"}}
+	for _, c := range clients {
+		b.Run("sequential/"+c.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := c.client.Completion(messages, "test-model", CompletionOptions{}); err != nil {
+					b.Fatalf("Completion() error = %v", err)
+				}
+			}
+		})
+		b.Run("parallel/"+c.name, func(b *testing.B) {
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := c.client.Completion(messages, "test-model", CompletionOptions{}); err != nil {
+						b.Errorf("Completion() error = %v", err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// newBlockingStreamServer returns a server that streams one content chunk and then keeps the
+// response open until the client goes away.
+func newBlockingStreamServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"choices": [{"delta": {"content": "partial"}}]}`+"\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) }) // Runs before server.Close
+	return server
+}
+
+func TestCompletionStreamCancel(t *testing.T) {
+	tests := []struct {
+		name string
+		read int // Messages read before cancelling
+	}{
+		// The goroutine is blocked sending StreamStartMsg, which nobody reads
+		{name: "blocked on send", read: 0},
+		// The goroutine is blocked reading the response body
+		{name: "blocked on read", read: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newBlockingStreamServer(t)
+			client := NewClient(server.URL, "test-key")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			stream, ok := client.CompletionStream(ctx, []Message{{Role: "user", Content: "hi"}}, "test-model", nil, CompletionOptions{})().(Stream)
+			if !ok {
+				t.Fatal("CompletionStream() did not return a Stream")
+			}
+
+			for i := 0; i < tt.read; i++ {
+				select {
+				case msg := <-stream:
+					if err, isErr := msg.(ErrorMsg); isErr {
+						t.Fatalf("unexpected error before cancelling: %v", err.Err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("timed out waiting for message %d", i+1)
+				}
+			}
+			cancel()
+
+			timeout := time.After(5 * time.Second)
+			for {
+				select {
+				case _, open := <-stream:
+					if !open {
+						return
+					}
+				case <-timeout:
+					t.Fatal("stream was not closed after the context was cancelled")
+				}
+			}
+		})
+	}
+}
+
+// newRecordingProxy returns an HTTP proxy that answers every forwarded request with okResponse
+// and refuses CONNECT tunnels. It sends "METHOD host" for each request to the returned channel.
+func newRecordingProxy(t *testing.T) (*httptest.Server, <-chan string) {
+	t.Helper()
+	requests := make(chan string, 10)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.Method + " " + r.Host
+		if r.Method == http.MethodConnect {
+			http.Error(w, "tunnels are not supported", http.StatusForbidden)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, okResponse)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, requests
+}
+
+func TestClientWithProxy(t *testing.T) {
+	proxy, requests := newRecordingProxy(t)
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients := map[string]*Client{
+		"default transport": NewClient("http://api.example.test/v1", "test-key", WithProxy(proxyURL)),
+		"custom transport":  NewClient("http://api.example.test/v1", "test-key", WithHTTPTransport(&http.Transport{}), WithProxy(proxyURL)),
+	}
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			// api.example.test does not resolve, so the request only succeeds through the proxy
+			if _, err := client.Completion([]Message{{Role: "user", Content: "hi"}}, "test-model", CompletionOptions{}); err != nil {
+				t.Fatalf("Completion() error = %v", err)
+			}
+			if got := <-requests; got != "POST api.example.test" {
+				t.Errorf("proxy received %q, want POST api.example.test", got)
+			}
+		})
+	}
+}
+
+// proxyEnvTarget is set in the environment of the child process run by
+// TestClientProxyFromEnvironment to the API URL it should request.
+const proxyEnvTarget = "TACHIGOMA_TEST_PROXY_TARGET"
+
+// TestClientProxyFromEnvironment checks that a client without WithProxy uses HTTP_PROXY and
+// HTTPS_PROXY. The standard library reads them once per process, so each case runs the test
+// binary again with the variables set.
+func TestClientProxyFromEnvironment(t *testing.T) {
+	if target := os.Getenv(proxyEnvTarget); target != "" {
+		// Child process: the parent checks what reached the proxy
+		NewClient(target, "test-key").Completion([]Message{{Role: "user", Content: "hi"}}, "test-model", CompletionOptions{})
+		return
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		env    string
+		want   string
+	}{
+		{name: "HTTP_PROXY", target: "http://api.example.test/v1", env: "HTTP_PROXY", want: "POST api.example.test"},
+		{name: "HTTPS_PROXY", target: "https://api.example.test/v1", env: "HTTPS_PROXY", want: "CONNECT api.example.test:443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy, requests := newRecordingProxy(t)
+
+			cmd := exec.Command(os.Args[0], "-test.run=^TestClientProxyFromEnvironment$")
+			cmd.Env = append(os.Environ(),
+				"HTTP_PROXY=", "HTTPS_PROXY=", "NO_PROXY=", "http_proxy=", "https_proxy=", "no_proxy=",
+				tt.env+"="+proxy.URL, proxyEnvTarget+"="+tt.target)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("child process failed: %v\n%s", err, output)
+			}
+
+			select {
+			case got := <-requests:
+				if got != tt.want {
+					t.Errorf("proxy received %q, want %q", got, tt.want)
+				}
+			default:
+				t.Errorf("no request reached the proxy named by %s", tt.env)
+			}
+		})
+	}
+}
+
+func TestRequestPlanCancelled(t *testing.T) {
+	received, aborted := make(chan struct{}), make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The server notices a closed connection only once the body was read
+		io.Copy(io.Discard, r.Body)
+		close(received)
+		select {
+		case <-r.Context().Done():
+			close(aborted)
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) }) // Runs before server.Close
+
+	agent := NewAgent(NewClient(server.URL, "test-key"), "test-model", WithTools(false))
+	ctx, cancel := context.WithCancel(context.Background())
+	agent.HandleUserInput(ctx, "hi") // Only sets up the turn; the stream command is not run
+
+	plan := make(chan tea.Msg, 1)
+	go func() { plan <- agent.requestPlan()() }()
+	<-received
+	cancel()
+
+	select {
+	case msg := <-plan:
+		if got, ok := msg.(PlanMsg); !ok || got.Steps != nil {
+			t.Errorf("requestPlan() = %#v, want an empty PlanMsg", msg)
+		}
+	case <-time.After(planTimeout / 2):
+		t.Fatal("requestPlan() did not return after the turn was cancelled")
+	}
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Error("the plan request was not aborted")
+	}
+}
+
+func TestCompletionLogsSystemFingerprint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"system_fingerprint": "fp_abc123", "choices": [{"message": {"role": "assistant", "content": "ok"}, "finish_reason": "stop"}]}`)
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := NewClient(server.URL, "test-key", WithLogger(logger))
+	if _, err := client.Completion([]Message{{Role: "user", Content: "hi"}}, "test-model", CompletionOptions{}); err != nil {
+		t.Fatalf("Completion() error = %v", err)
+	}
+	if !strings.Contains(logs.String(), "system_fingerprint=fp_abc123") {
+		t.Errorf("debug log does not contain the system fingerprint:\n%s", logs.String())
+	}
+}
+
+func TestCompletionRequestStreamOptions(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		data, err := json.Marshal(newCompletionRequest([]Message{{Role: "user", Content: "hi"}}, "test-model", nil, CompletionOptions{}, stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(string(data), `"stream_options"`); got != stream {
+			t.Errorf("stream=%v: request contains stream_options = %v\n%s", stream, got, data)
+		}
+	}
+}
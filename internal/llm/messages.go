@@ -1,6 +1,10 @@
 package llm
 
-import "github.com/charmbracelet/bubbletea"
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbletea"
+)
 
 // --- API Data Structures ---
 
@@ -10,6 +14,11 @@ type Message struct {
 	Content    string     `json:"content,omitempty"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
+
+	// Truncated is set locally when the response was cut off by the max tokens limit.
+	Truncated bool `json:"-"`
+	// Timestamp is set locally when the message was sent or its response finished streaming.
+	Timestamp time.Time `json:"-"`
 }
 
 // ToolCall represents a complete tool call.
@@ -44,18 +53,70 @@ type Tool struct {
 }
 
 // CompletionRequest is the request body for a chat completion.
+//
+// Seed is a pointer so that an explicit seed of 0 can be told apart from no seed.
+// Seeded sampling is best effort: the server only aims for repeatable output while
+// its SystemFingerprint stays the same, so compare fingerprints before relying on it.
 type CompletionRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
-	Stream   bool      `json:"stream,omitempty"`
-	Tools    []Tool    `json:"tools,omitempty"`
+	Model          string          `json:"model"`
+	Messages       []Message       `json:"messages"`
+	Stream         bool            `json:"stream,omitempty"`
+	Tools          []Tool          `json:"tools,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Seed           *int            `json:"seed,omitempty"`
+	Stop           []string        `json:"stop,omitempty"`        // Omitted when empty, so the server default applies
+	Temperature    *float64        `json:"temperature,omitempty"` // Nil leaves the server default
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	StreamOptions  *StreamOptions  `json:"stream_options,omitempty"` // Only sent with stream
+}
+
+// ResponseFormat constrains the format of the model's reply. Type "json_object" asks for valid JSON;
+// the API requires the word "JSON" to appear in the messages when it is used.
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
+// StreamOptions asks the server to include token usage in the final chunk of a stream.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// Usage is the number of tokens a request consumed.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add returns the sum of two usages.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+	}
+}
+
+// CompletionOptions holds the optional request parameters the agent forwards with every completion.
+type CompletionOptions struct {
+	MaxTokens int      // Zero means no limit
+	Seed      *int     // Nil means the server picks a random seed
+	Stop      []string // Generation ends before any of these sequences; empty means none
+
+	Temperature    *float64        // Nil means the server default
+	ResponseFormat *ResponseFormat // Nil means free-form text
 }
 
 // CompletionResponse is the response body for a non-streaming chat completion.
+// SystemFingerprint identifies the backend configuration; when it changes, seeded
+// requests are no longer guaranteed to reproduce earlier output.
 type CompletionResponse struct {
 	Choices []struct {
-		Message Message `json:"message"`
+		Message      Message `json:"message"`
+		FinishReason string  `json:"finish_reason"`
 	} `json:"choices"`
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+	Usage             *Usage `json:"usage,omitempty"`
 }
 
 // StreamChoice is a single choice in a streaming chat completion response.
@@ -70,7 +131,9 @@ type StreamChoice struct {
 
 // StreamCompletionResponse is the response body for a streaming chat completion.
 type StreamCompletionResponse struct {
-	Choices []StreamChoice `json:"choices"`
+	Choices           []StreamChoice `json:"choices"`
+	SystemFingerprint string         `json:"system_fingerprint,omitempty"`
+	Usage             *Usage         `json:"usage,omitempty"` // Usually only on the final chunk
 }
 
 // --- TUI Message Types ---
@@ -87,7 +150,10 @@ type StreamContentMsg struct {
 }
 
 // StreamEndMsg is sent when the stream ends.
-type StreamEndMsg struct{}
+type StreamEndMsg struct {
+	FinishReason string
+	Usage        Usage // Zero if the server did not report usage
+}
 
 // AssistantToolCallMsg is sent when the model requests tool calls.
 type AssistantToolCallMsg struct {
@@ -97,13 +163,55 @@ type AssistantToolCallMsg struct {
 // ErrorMsg is sent when an error occurs.
 type ErrorMsg struct{ Err error }
 
+// WarningMsg is sent to show the user a warning that does not stop the current turn.
+type WarningMsg struct {
+	Text string
+}
+
 // ToolResultMsg is sent when a tool has finished executing.
 type ToolResultMsg struct {
 	ToolCallID string
 	Result     string
 }
 
+// ToolResultsMsg is sent when tool calls that ran in parallel have all finished.
+// The results are in the order the model made the calls.
+type ToolResultsMsg struct {
+	Results []ToolResultMsg
+}
+
 // ConfirmationRequiredMsg is sent when a tool requires user confirmation.
 type ConfirmationRequiredMsg struct {
 	ToolCall ToolCall
 }
+
+// UserInputRequiredMsg is sent when a tool needs the user to paste its result.
+type UserInputRequiredMsg struct {
+	ToolCall ToolCall
+}
+
+// PlanStep is one tool call the model expects to make, as described in its advisory plan.
+type PlanStep struct {
+	Tool    string `json:"tool"`
+	Purpose string `json:"purpose"`
+}
+
+// PlanMsg is sent when the advisory tool plan is available. Steps is empty if planning failed or timed out.
+type PlanMsg struct {
+	Steps []PlanStep
+}
+
+// SummaryMsg is sent when the older part of the conversation has been summarized.
+// Replaced is the number of messages after the system prompt that the summary stands for.
+type SummaryMsg struct {
+	Summary  string
+	Replaced int
+	Usage    Usage
+	Err      error
+}
+
+// EvaluationMsg is sent when an automatic evaluation of the last response has finished.
+type EvaluationMsg struct {
+	Overall float64
+	Err     error
+}
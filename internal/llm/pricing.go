@@ -0,0 +1,52 @@
+package llm
+
+import "strings"
+
+// ModelPrice is what a model costs in USD per 1K tokens.
+type ModelPrice struct {
+	Input  float64 `mapstructure:"input"`  // Per 1K prompt tokens
+	Output float64 `mapstructure:"output"` // Per 1K completion tokens
+}
+
+// Cost returns the estimated cost of usage in USD.
+func (p ModelPrice) Cost(usage Usage) float64 {
+	return float64(usage.PromptTokens)/1000*p.Input + float64(usage.CompletionTokens)/1000*p.Output
+}
+
+// Pricing maps model names to their prices.
+type Pricing map[string]ModelPrice
+
+// DefaultPricing holds the list prices of common models, used unless the user configures others.
+var DefaultPricing = Pricing{
+	"gpt-4o":        {Input: 0.0025, Output: 0.01},
+	"gpt-4o-mini":   {Input: 0.00015, Output: 0.0006},
+	"gpt-4.1":       {Input: 0.002, Output: 0.008},
+	"gpt-4.1-mini":  {Input: 0.0004, Output: 0.0016},
+	"gpt-4.1-nano":  {Input: 0.0001, Output: 0.0004},
+	"gpt-4-turbo":   {Input: 0.01, Output: 0.03},
+	"gpt-4":         {Input: 0.03, Output: 0.06},
+	"gpt-3.5-turbo": {Input: 0.0005, Output: 0.0015},
+	"o1":            {Input: 0.015, Output: 0.06},
+	"o1-mini":       {Input: 0.0011, Output: 0.0044},
+	"o3-mini":       {Input: 0.0011, Output: 0.0044},
+	"deepseek-chat": {Input: 0.00027, Output: 0.0011},
+}
+
+// Lookup returns the price of model. Models without an entry of their own match the longest
+// entry they start with, so dated versions like gpt-4o-2024-08-06 use the gpt-4o price.
+func (p Pricing) Lookup(model string) (ModelPrice, bool) {
+	model = strings.ToLower(model)
+	if price, ok := p[model]; ok {
+		return price, true
+	}
+	best := ""
+	for name := range p {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return p[best], true
+}
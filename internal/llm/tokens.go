@@ -0,0 +1,37 @@
+package llm
+
+import "fmt"
+
+// DefaultContextLimit is the context window, in tokens, assumed when none is configured.
+const DefaultContextLimit = 128000
+
+// contextWarningRatio is the share of the context limit above which the agent warns before a request.
+const contextWarningRatio = 0.9
+
+// charsPerToken is the average number of characters per token used by EstimateTokens.
+const charsPerToken = 4
+
+// EstimateTokens approximates the number of tokens messages take up, assuming about four
+// characters per token. It is only meant for comparisons against the context limit.
+func EstimateTokens(messages []Message) int {
+	chars := 0
+	for _, m := range messages {
+		chars += len(m.Content)
+		for _, call := range m.ToolCalls {
+			chars += len(call.Function.Name) + len(call.Function.Arguments)
+		}
+	}
+	return chars / charsPerToken
+}
+
+// contextWarning returns a WarningMsg if the conversation is close to the context limit, or nil.
+func (a *Agent) contextWarning() *WarningMsg {
+	if a.contextLimit <= 0 {
+		return nil
+	}
+	used := float64(EstimateTokens(a.messages)) / float64(a.contextLimit)
+	if used <= contextWarningRatio {
+		return nil
+	}
+	return &WarningMsg{Text: fmt.Sprintf("Context is %d%% full — consider clearing or summarizing.", int(used*100))}
+}
@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultToolCacheTTL is how long a cached tool result is reused when no TTL is given.
+const DefaultToolCacheTTL = 60 * time.Second
+
+// uncachedTools have side effects or report what happens while they run, so calling them
+// again is not the same as reusing the earlier result. Calls that need confirmation are
+// never cached either.
+var uncachedTools = map[string]bool{
+	"write_file":        true,
+	"append_file":       true,
+	"replace":           true,
+	"run_shell_command": true,
+	"inject_stdin":      true,
+	"watch_file":        true,
+	"create_directory":  true,
+	"list_processes":    true,
+	"datetime":          true,
+}
+
+// ToolCache remembers tool results so that identical calls made shortly after each other
+// are answered without running the tool again.
+type ToolCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	result  string
+	expires time.Time
+	paths   []string    // Absolute paths named in the arguments
+	stamps  []fileStamp // State of each path when the result was stored
+}
+
+// fileStamp is what a cached result assumes about a file: a change means it is stale.
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func statFile(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// pathArgs are the argument names tools use for files and directories.
+var pathArgs = map[string]bool{
+	"path": true, "paths": true, "file": true, "root": true, "directory": true,
+	"from_path": true, "to_path": true, "source": true, "destination": true, "target": true,
+	"link_name": true, "output_path": true, "template_path": true, "archive_path": true, "db_path": true,
+}
+
+// argPaths returns the absolute paths named in a call's JSON arguments.
+func argPaths(args string) []string {
+	var values map[string]any
+	if err := json.Unmarshal([]byte(args), &values); err != nil {
+		return nil
+	}
+	var paths []string
+	add := func(value any) {
+		if path, ok := value.(string); ok && path != "" {
+			if abs, err := filepath.Abs(path); err == nil {
+				paths = append(paths, abs)
+			}
+		}
+	}
+	for key, value := range values {
+		if !pathArgs[key] {
+			continue
+		}
+		if list, ok := value.([]any); ok {
+			for _, item := range list {
+				add(item)
+			}
+		} else {
+			add(value)
+		}
+	}
+	return paths
+}
+
+// overlaps reports whether a and b are the same path or one contains the other.
+func overlaps(a, b string) bool {
+	within := func(path, dir string) bool {
+		return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+	}
+	return within(a, b) || within(b, a)
+}
+
+// NewToolCache returns an empty cache whose entries expire after ttl.
+func NewToolCache(ttl time.Duration) *ToolCache {
+	if ttl <= 0 {
+		ttl = DefaultToolCacheTTL
+	}
+	return &ToolCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+// Get returns the cached result of calling toolName with args, if it has not expired and
+// none of the files named in args has changed since.
+func (c *ToolCache) Get(toolName, args string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := toolName + ":" + args
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return "", false
+	}
+	for i, path := range entry.paths {
+		if statFile(path) != entry.stamps[i] {
+			delete(c.entries, key)
+			return "", false
+		}
+	}
+	return entry.result, true
+}
+
+// Put stores the result of calling toolName with args.
+func (c *ToolCache) Put(toolName, args, result string) {
+	entry := cacheEntry{result: result, expires: time.Now().Add(c.ttl), paths: argPaths(args)}
+	for _, path := range entry.paths {
+		entry.stamps = append(entry.stamps, statFile(path))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[toolName+":"+args] = entry
+}
+
+// Invalidate removes the entries a call with args may have made stale: those naming a path
+// that overlaps one in args, and those naming no path, such as git_status. A call without
+// paths, such as a shell command, may have changed anything and removes every entry.
+func (c *ToolCache) Invalidate(args string) {
+	changed := argPaths(args)
+	if len(changed) == 0 {
+		c.Clear()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		if len(entry.paths) == 0 || overlapsAny(entry.paths, changed) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func overlapsAny(paths, others []string) bool {
+	for _, path := range paths {
+		for _, other := range others {
+			if overlaps(path, other) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Clear removes every entry.
+func (c *ToolCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]cacheEntry)
+}
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func pathArgsJSON(key, path string) string {
+	data, _ := json.Marshal(map[string]string{key: path})
+	return string(data)
+}
+
+func TestToolCacheInvalidate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "src", "main.go")
+	other := filepath.Join(t.TempDir(), "notes.txt")
+
+	tests := []struct {
+		name     string
+		args     string // Arguments of the modifying call
+		wantKept []string
+	}{
+		{name: "same file", args: pathArgsJSON("path", file), wantKept: []string{"read_file other"}},
+		{name: "parent directory", args: pathArgsJSON("path", dir), wantKept: []string{"read_file other"}},
+		{name: "unrelated file", args: pathArgsJSON("path", filepath.Join(dir, "README.md")), wantKept: []string{"read_file file", "read_file other"}},
+		{name: "path list", args: `{"paths": ["` + other + `"]}`, wantKept: []string{"read_file file", "list_directory dir"}},
+		{name: "no path", args: `{"command": "make"}`, wantKept: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewToolCache(time.Minute)
+			calls := map[string][2]string{
+				"read_file file":     {"read_file", pathArgsJSON("path", file)},
+				"read_file other":    {"read_file", pathArgsJSON("path", other)},
+				"list_directory dir": {"list_directory", pathArgsJSON("path", dir)},
+				"git_status":         {"git_status", `{}`},
+			}
+			for _, call := range calls {
+				cache.Put(call[0], call[1], "result")
+			}
+
+			cache.Invalidate(tt.args)
+
+			kept := map[string]bool{}
+			for _, name := range tt.wantKept {
+				kept[name] = true
+			}
+			for name, call := range calls {
+				if _, ok := cache.Get(call[0], call[1]); ok != kept[name] {
+					t.Errorf("after Invalidate(%s): %s cached = %v, want %v", tt.args, name, ok, kept[name])
+				}
+			}
+		})
+	}
+}
+
+func TestToolCacheExternalChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("port: 80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := NewToolCache(time.Minute)
+	args := pathArgsJSON("path", path)
+	cache.Put("read_file", args, "port: 80\n")
+	if _, ok := cache.Get("read_file", args); !ok {
+		t.Fatal("result was not cached")
+	}
+
+	if err := os.WriteFile(path, []byte("port: 8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("read_file", args); ok {
+		t.Error("cached result was returned after the file changed")
+	}
+}
+
+func TestRunToolCaching(t *testing.T) {
+	dir := t.TempDir()
+	path, other := filepath.Join(dir, "notes.txt"), filepath.Join(dir, "other.txt")
+	for _, p := range []string{path, other} {
+		if err := os.WriteFile(p, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("TACHIGOMA_TEST_VALUE", "value")
+
+	agent := NewAgent(nil, "test-model", WithToolCache(time.Minute))
+	call := func(name, args string) string {
+		toolCall := ToolCall{ID: "call", Type: "function"}
+		toolCall.Function.Name = name
+		toolCall.Function.Arguments = args
+		return agent.runTool(toolCall, false)
+	}
+	cached := func(name, args string) bool {
+		_, ok := agent.toolCache.Get(name, args)
+		return ok
+	}
+
+	readArgs, otherArgs := pathArgsJSON("path", path), pathArgsJSON("path", other)
+	envArgs := pathArgsJSON("name", "TACHIGOMA_TEST_VALUE")
+	call("read_file", readArgs)
+	call("read_file", otherArgs)
+	call("env_get", envArgs)
+	if !cached("read_file", readArgs) || !cached("env_get", envArgs) {
+		t.Fatal("read_file and env_get of a plain variable should be cached")
+	}
+
+	// Tools that change nothing leave the cache alone
+	call("datetime", `{}`)
+	if !cached("read_file", readArgs) {
+		t.Error("datetime removed cached results")
+	}
+
+	// A write evicts results for the same path only
+	writeArgs, _ := json.Marshal(map[string]string{"path": path, "content": "new"})
+	call("write_file", string(writeArgs))
+	if cached("read_file", readArgs) {
+		t.Error("read_file result is still cached after write_file changed the file")
+	}
+	if !cached("read_file", otherArgs) {
+		t.Error("write_file removed the cached result for another file")
+	}
+	if got := call("read_file", readArgs); got != "new" {
+		t.Errorf("read_file after write_file = %q, want %q", got, "new")
+	}
+}
@@ -0,0 +1,65 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the conversation as a Markdown document. User and assistant messages
+// get their own headings, tool calls become fenced code blocks labelled with the tool name
+// and tool results are quoted. System messages are left out unless includeSystem is set.
+func (s *Session) Markdown(includeSystem bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %s\n\n", s.ID)
+	if !s.CreatedAt.IsZero() {
+		fmt.Fprintf(&b, "_Created %s_\n\n", s.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+
+	for _, m := range s.Messages {
+		switch m.Role {
+		case "system":
+			if !includeSystem {
+				continue
+			}
+			b.WriteString("## System\n\n")
+			b.WriteString(strings.TrimSpace(m.Content) + "\n\n")
+		case "user":
+			b.WriteString("## You\n\n")
+			b.WriteString(strings.TrimSpace(m.Content) + "\n\n")
+		case "assistant":
+			if m.Content == "" && len(m.ToolCalls) == 0 {
+				continue
+			}
+			b.WriteString("## Assistant\n\n")
+			if content := strings.TrimSpace(m.Content); content != "" {
+				b.WriteString(content + "\n\n")
+			}
+			for _, call := range m.ToolCalls {
+				fmt.Fprintf(&b, "```%s\n%s\n```\n\n", call.Function.Name, indentJSON(call.Function.Arguments))
+			}
+		case "tool":
+			b.WriteString(quote(m.Content) + "\n\n")
+		}
+	}
+	return b.String()
+}
+
+// indentJSON pretty-prints tool arguments, leaving them as is if they are not valid JSON.
+func indentJSON(s string) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(s), "", "  "); err != nil {
+		return s
+	}
+	return out.String()
+}
+
+// quote turns text into a Markdown blockquote.
+func quote(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
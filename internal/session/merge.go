@@ -0,0 +1,89 @@
+package session
+
+import (
+	"fmt"
+	"time"
+)
+
+// Merge strategies.
+const (
+	StrategyInterleave = "interleave" // Order turns from both sessions by timestamp
+	StrategyAppend     = "append"     // All of a, then all of b
+)
+
+// Merge combines two sessions into one conversation. Only a's system messages are kept.
+// Messages are merged a turn at a time (a user message and everything up to the next one)
+// so that tool calls stay next to their results. Identical consecutive assistant messages
+// are collapsed into one.
+func Merge(a, b *Session, strategy string) (*Session, error) {
+	aSystem, aTurns := splitTurns(a.Messages)
+	_, bTurns := splitTurns(b.Messages)
+
+	var turns [][]Entry
+	switch strategy {
+	case StrategyAppend:
+		turns = append(aTurns, bTurns...)
+	case StrategyInterleave:
+		turns = interleave(aTurns, bTurns)
+	default:
+		return nil, fmt.Errorf("unknown merge strategy '%s' (expected %s or %s)", strategy, StrategyInterleave, StrategyAppend)
+	}
+
+	merged := &Session{CreatedAt: time.Now(), Messages: aSystem}
+	for _, turn := range turns {
+		for _, entry := range turn {
+			if n := len(merged.Messages); n > 0 && isDuplicateReply(merged.Messages[n-1], entry) {
+				continue
+			}
+			merged.Messages = append(merged.Messages, entry)
+		}
+	}
+	return merged, nil
+}
+
+// splitTurns separates system messages from the rest and groups the rest into turns.
+// Messages before the first user message form a turn of their own.
+func splitTurns(entries []Entry) (system []Entry, turns [][]Entry) {
+	for _, entry := range entries {
+		switch {
+		case entry.Role == "system":
+			system = append(system, entry)
+		case entry.Role == "user" || len(turns) == 0:
+			turns = append(turns, []Entry{entry})
+		default:
+			turns[len(turns)-1] = append(turns[len(turns)-1], entry)
+		}
+	}
+	return system, turns
+}
+
+// interleave merges two turn sequences by the timestamp of each turn's first message,
+// keeping each session's own order. Turns without a timestamp inherit the previous one.
+func interleave(a, b [][]Entry) [][]Entry {
+	merged := make([][]Entry, 0, len(a)+len(b))
+	var lastA, lastB time.Time
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if ts := a[i][0].Timestamp; !ts.IsZero() {
+			lastA = ts
+		}
+		if ts := b[j][0].Timestamp; !ts.IsZero() {
+			lastB = ts
+		}
+		if !lastB.Before(lastA) {
+			merged = append(merged, a[i])
+			i++
+		} else {
+			merged = append(merged, b[j])
+			j++
+		}
+	}
+	merged = append(merged, a[i:]...)
+	return append(merged, b[j:]...)
+}
+
+func isDuplicateReply(prev, next Entry) bool {
+	return prev.Role == "assistant" && next.Role == "assistant" &&
+		len(prev.ToolCalls) == 0 && len(next.ToolCalls) == 0 &&
+		prev.Content == next.Content
+}
@@ -0,0 +1,161 @@
+package session
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tachigoma/internal/llm"
+)
+
+// messageSummary renders each message as "role: content", or "role: [tool names]" for tool calls.
+func messageSummary(entries []Entry) []string {
+	var out []string
+	for _, entry := range entries {
+		content := entry.Content
+		if len(entry.ToolCalls) > 0 {
+			var names []string
+			for _, call := range entry.ToolCalls {
+				names = append(names, call.Function.Name)
+			}
+			content = "[" + strings.Join(names, ", ") + "]"
+		}
+		out = append(out, entry.Role+": "+content)
+	}
+	return out
+}
+
+func loadFixture(t *testing.T, name string) *Session {
+	t.Helper()
+	s, err := Load(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func entry(role, content string, ts time.Time) Entry {
+	return Entry{Message: llm.Message{Role: role, Content: content}, Timestamp: ts}
+}
+
+func TestMerge(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2024, 5, 1, 10, minute, 0, 0, time.UTC) }
+	noSystem := &Session{Messages: []Entry{
+		entry("user", "c1", at(5)),
+		entry("assistant", "reply c1", at(5)),
+	}}
+	leadingReply := &Session{Messages: []Entry{
+		entry("assistant", "welcome", time.Time{}),
+		entry("user", "d1", at(15)),
+	}}
+
+	tests := []struct {
+		name     string
+		a, b     *Session
+		strategy string
+		want     []string
+		wantErr  string
+	}{
+		{
+			// b2 has no timestamp and inherits b1's 10:10, so it still comes before a2 at 10:20.
+			// Only a's system message is kept and the repeated "reply a2" is collapsed.
+			name:     "interleave",
+			a:        loadFixture(t, "session_a.json"),
+			b:        loadFixture(t, "session_b.json"),
+			strategy: StrategyInterleave,
+			want: []string{
+				"system: You are session A.",
+				"user: a1", "assistant: reply a1",
+				"user: b1", "assistant: [read_file]", "tool: module example.com/app", "assistant: reply b1",
+				"user: b2", "assistant: reply b2",
+				"user: a2", "assistant: reply a2",
+			},
+		},
+		{
+			name:     "append",
+			a:        loadFixture(t, "session_a.json"),
+			b:        loadFixture(t, "session_b.json"),
+			strategy: StrategyAppend,
+			want: []string{
+				"system: You are session A.",
+				"user: a1", "assistant: reply a1",
+				"user: a2", "assistant: reply a2",
+				"user: b1", "assistant: [read_file]", "tool: module example.com/app", "assistant: reply b1",
+				"user: b2", "assistant: reply b2",
+			},
+		},
+		{
+			// a has no system message, so b's is not used in its place
+			name:     "missing system message",
+			a:        noSystem,
+			b:        loadFixture(t, "session_b.json"),
+			strategy: StrategyInterleave,
+			want: []string{
+				"user: c1", "assistant: reply c1",
+				"user: b1", "assistant: [read_file]", "tool: module example.com/app", "assistant: reply b1",
+				"user: b2", "assistant: reply b2",
+			},
+		},
+		{
+			// A reply before the first user message forms its own turn without a timestamp
+			name:     "leading reply without timestamp",
+			a:        noSystem,
+			b:        leadingReply,
+			strategy: StrategyInterleave,
+			want: []string{
+				"assistant: welcome",
+				"user: c1", "assistant: reply c1",
+				"user: d1",
+			},
+		},
+		{
+			name:     "empty session",
+			a:        &Session{},
+			b:        noSystem,
+			strategy: StrategyInterleave,
+			want:     []string{"user: c1", "assistant: reply c1"},
+		},
+		{
+			name:     "unknown strategy",
+			a:        noSystem,
+			b:        noSystem,
+			strategy: "zip",
+			wantErr:  "unknown merge strategy 'zip'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := Merge(tt.a, tt.b, tt.strategy)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Merge() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+			if got := messageSummary(merged.Messages); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestMergeKeepsToolCallWithResult(t *testing.T) {
+	merged, err := Merge(loadFixture(t, "session_b.json"), loadFixture(t, "session_a.json"), StrategyInterleave)
+	if err != nil {
+		t.Fatalf("Merge() error = %v", err)
+	}
+	for i, entry := range merged.Messages {
+		if len(entry.ToolCalls) == 0 {
+			continue
+		}
+		if i+1 >= len(merged.Messages) || merged.Messages[i+1].ToolCallID != entry.ToolCalls[0].ID {
+			t.Errorf("tool call %s is not followed by its result", entry.ToolCalls[0].ID)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Package session stores conversations on disk so they can be resumed, merged and exported.
+package session
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tachigoma/internal/llm"
+)
+
+// Session is a saved conversation.
+type Session struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Messages  []Entry   `json:"messages"`
+
+	path string // File the session was loaded from or will be saved to
+}
+
+// Entry is a message together with the time it was added to the conversation.
+type Entry struct {
+	llm.Message
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Dir returns the directory sessions are stored in, ~/.tachigoma/sessions.
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding home directory: %w", err)
+	}
+	return filepath.Join(home, ".tachigoma", "sessions"), nil
+}
+
+// Open returns the session with the given ID, or a new empty one if it has not been saved yet.
+// The ID "new" creates a session with a freshly generated ID. An argument ending in .json is
+// treated as the path of a session file, e.g. one written by the merge command.
+func Open(id string) (*Session, error) {
+	if strings.HasSuffix(id, ".json") {
+		return Load(id)
+	}
+
+	if id == "new" {
+		id = NewID()
+	}
+	if err := CheckID(id); err != nil {
+		return nil, err
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, id+".json")
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &Session{ID: id, CreatedAt: time.Now(), path: path}, nil
+	}
+	return Load(path)
+}
+
+// CheckID returns an error if id cannot name a file in Dir, such as an ID containing a
+// path separator that would refer to a file elsewhere.
+func CheckID(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\`) || id == "." || id == ".." {
+		return fmt.Errorf("invalid session ID '%s'", id)
+	}
+	return nil
+}
+
+// Load reads a session file.
+func Load(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading session '%s': %w", path, err)
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error decoding session '%s': %w", path, err)
+	}
+	s.path = path
+	return &s, nil
+}
+
+// SaveTo writes the session to path as indented JSON.
+func (s *Session) SaveTo(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating session directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding session: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing session '%s': %w", path, err)
+	}
+	s.path = path
+	return nil
+}
+
+// GetID returns the session ID.
+func (s *Session) GetID() string {
+	return s.ID
+}
+
+// History returns the messages, ready to send to the API. Each message keeps its
+// timestamp locally so it can be displayed.
+func (s *Session) History() []llm.Message {
+	messages := make([]llm.Message, len(s.Messages))
+	for i, entry := range s.Messages {
+		messages[i] = entry.Message
+		messages[i].Timestamp = entry.Timestamp
+	}
+	return messages
+}
+
+// Save replaces the session's messages and writes it back to the file it came from.
+// Messages keep the timestamp the agent gave them or, failing that, the one they were saved with
+// before; the rest are stamped with the current time.
+func (s *Session) Save(messages []llm.Message) error {
+	now := time.Now()
+	entries := make([]Entry, len(messages))
+	for i, message := range messages {
+		entries[i] = Entry{Message: message, Timestamp: now}
+		if !message.Timestamp.IsZero() {
+			entries[i].Timestamp = message.Timestamp
+		} else if i < len(s.Messages) && s.Messages[i].Role == message.Role && s.Messages[i].Content == message.Content {
+			entries[i].Timestamp = s.Messages[i].Timestamp
+		}
+	}
+	s.Messages = entries
+
+	path := s.path
+	if path == "" {
+		dir, err := Dir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(dir, s.ID+".json")
+	}
+	return s.SaveTo(path)
+}
+
+// Fork returns a new session, with a fresh ID, containing the first n messages of s.
+// The cut may not separate a tool call from its results.
+func (s *Session) Fork(n int) (*Session, error) {
+	if n < 1 || n > len(s.Messages) {
+		return nil, fmt.Errorf("message index %d is out of range: the session has %d messages", n, len(s.Messages))
+	}
+	if n < len(s.Messages) && s.Messages[n].Role == "tool" {
+		return nil, fmt.Errorf("cannot fork at message %d: it is the result of a tool call made by an earlier message", n)
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	id := NewID()
+	fork := &Session{
+		ID:        id,
+		CreatedAt: time.Now(),
+		Messages:  append([]Entry(nil), s.Messages[:n]...),
+		path:      filepath.Join(dir, id+".json"),
+	}
+	return fork, nil
+}
+
+// Summary describes a saved session for listings.
+type Summary struct {
+	ID           string
+	CreatedAt    time.Time
+	MessageCount int
+	FirstPrompt  string
+}
+
+// List summarizes every session in Dir, newest first.
+func List() ([]Summary, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading session directory '%s': %w", dir, err)
+	}
+
+	var summaries []Summary
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		summary, err := summarize(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue // Skip files that are not sessions
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].CreatedAt.After(summaries[j].CreatedAt)
+	})
+	return summaries, nil
+}
+
+// summarize reads the summary of the session file at path. It streams the file and
+// decodes only the role and content of each message, so long sessions are cheap to list.
+func summarize(path string) (Summary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer f.Close()
+
+	var summary Summary
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return Summary{}, fmt.Errorf("'%s' is not a session file", path)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return Summary{}, err
+		}
+		switch tok {
+		case "id":
+			err = dec.Decode(&summary.ID)
+		case "created_at":
+			err = dec.Decode(&summary.CreatedAt)
+		case "messages":
+			err = summarizeMessages(dec, &summary)
+		default:
+			var skip json.RawMessage
+			err = dec.Decode(&skip)
+		}
+		if err != nil {
+			return Summary{}, fmt.Errorf("error decoding session '%s': %w", path, err)
+		}
+	}
+	return summary, nil
+}
+
+// summarizeMessages counts the messages of the array dec is positioned at and records
+// the first user prompt.
+func summarizeMessages(dec *json.Decoder, summary *Summary) error {
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok == nil {
+		return nil // "messages": null
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("messages is not an array")
+	}
+	for dec.More() {
+		var m struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}
+		if err := dec.Decode(&m); err != nil {
+			return err
+		}
+		summary.MessageCount++
+		if summary.FirstPrompt == "" && m.Role == "user" {
+			summary.FirstPrompt = m.Content
+		}
+	}
+	_, err := dec.Token() // Closing ]
+	return err
+}
+
+// Delete removes the saved session with the given ID.
+func Delete(id string) error {
+	if err := CheckID(id); err != nil {
+		return err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(dir, id+".json")); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("session '%s' not found", id)
+		}
+		return fmt.Errorf("error deleting session '%s': %w", id, err)
+	}
+	return nil
+}
+
+// DeleteAll removes every saved session and returns how many were deleted. Other
+// files in the session directory are left alone.
+func DeleteAll() (int, error) {
+	dir, err := Dir()
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading session directory '%s': %w", dir, err)
+	}
+
+	deleted := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if _, err := summarize(path); err != nil {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return deleted, fmt.Errorf("error deleting session '%s': %w", path, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// NewID returns a random (version 4) UUID.
+func NewID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
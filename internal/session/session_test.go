@@ -0,0 +1,26 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenRejectsInvalidID(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	for _, id := range []string{"", ".", "..", "../escape", `..\escape`, "a/b"} {
+		if _, err := Open(id); err == nil {
+			t.Errorf("Open(%q) succeeded, want an invalid ID error", id)
+		}
+	}
+
+	s, err := Open("1b9d6bcd")
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if want := filepath.Join(home, ".tachigoma", "sessions", "1b9d6bcd.json"); s.path != want {
+		t.Errorf("session path = %q, want %q", s.path, want)
+	}
+}
@@ -0,0 +1,450 @@
+package tools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archiveFormat returns "zip" or "tar.gz" for an archive path, judged by its extension.
+func archiveFormat(path string) (string, error) {
+	lower := strings.ToLower(path)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip", nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz", nil
+	}
+	return "", fmt.Errorf("unsupported archive '%s'; expected a .zip, .tar.gz or .tgz file", path)
+}
+
+// archiveWriter adds files and directories to an archive being created.
+type archiveWriter interface {
+	addDir(name string, info os.FileInfo) error
+	addFile(name, path string, info os.FileInfo) error
+	Close() error
+}
+
+type zipArchiveWriter struct {
+	zw *zip.Writer
+}
+
+func (w *zipArchiveWriter) addDir(name string, info os.FileInfo) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name + "/"
+	_, err = w.zw.CreateHeader(header)
+	return err
+}
+
+func (w *zipArchiveWriter) addFile(name, path string, info os.FileInfo) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+	dst, err := w.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	return copyFileTo(dst, path)
+}
+
+func (w *zipArchiveWriter) Close() error {
+	return w.zw.Close()
+}
+
+type tarGzArchiveWriter struct {
+	gz *gzip.Writer
+	tw *tar.Writer
+}
+
+func (w *tarGzArchiveWriter) addDir(name string, info os.FileInfo) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name + "/"
+	return w.tw.WriteHeader(header)
+}
+
+func (w *tarGzArchiveWriter) addFile(name, path string, info os.FileInfo) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	return copyFileTo(w.tw, path)
+}
+
+func (w *tarGzArchiveWriter) Close() error {
+	if err := w.tw.Close(); err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// copyFileTo copies the contents of the file at path to dst.
+func copyFileTo(dst io.Writer, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// --- CreateArchiveTool ---
+
+// CreateArchiveTool packs files and directories into a zip or gzip-compressed tar archive.
+type CreateArchiveTool struct {
+	pathGuard
+}
+
+func (t *CreateArchiveTool) Name() string {
+	return "create_archive"
+}
+
+func (t *CreateArchiveTool) RequiresConfirmation() bool {
+	return true
+}
+
+func (t *CreateArchiveTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *CreateArchiveTool) Description() string {
+	return "Creates a zip or tar.gz archive of the given files and directories, which are added recursively under their own names. Overwrites output_path if it exists. Usage: {\"format\": \"tar.gz\", \"output_path\": \"logs.tar.gz\", \"paths\": [\"logs\", \"config.yaml\"]}"
+}
+
+func (t *CreateArchiveTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"format": map[string]any{
+				"type":        "string",
+				"enum":        []string{"zip", "tar.gz"},
+				"description": "The archive format.",
+			},
+			"output_path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path of the archive to create.",
+			},
+			"paths": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string", "minLength": 1},
+				"minItems":    1,
+				"description": "The files and directories to add to the archive.",
+			},
+		},
+		"required": []string{"format", "output_path", "paths"},
+	}
+}
+
+type CreateArchiveArgs struct {
+	Format     string   `json:"format"`
+	OutputPath string   `json:"output_path"`
+	Paths      []string `json:"paths"`
+}
+
+func (t *CreateArchiveTool) Execute(args string) (string, error) {
+	var toolArgs CreateArchiveArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for create_archive: %w. Expected JSON: {\"format\": \"zip\", \"output_path\": \"...\", \"paths\": [\"...\"]}", err)
+	}
+
+	if toolArgs.OutputPath == "" || len(toolArgs.Paths) == 0 {
+		return "", fmt.Errorf("output_path and paths arguments are required for create_archive")
+	}
+	if toolArgs.Format != "zip" && toolArgs.Format != "tar.gz" {
+		return "", fmt.Errorf("unknown format '%s'; expected \"zip\" or \"tar.gz\"", toolArgs.Format)
+	}
+	for _, path := range append([]string{toolArgs.OutputPath}, toolArgs.Paths...) {
+		if err := t.checkPath(path); err != nil {
+			return "", err
+		}
+	}
+
+	out, err := os.Create(toolArgs.OutputPath)
+	if err != nil {
+		return "", fmt.Errorf("error creating archive '%s': %w", toolArgs.OutputPath, err)
+	}
+	defer out.Close()
+
+	var writer archiveWriter
+	if toolArgs.Format == "zip" {
+		writer = &zipArchiveWriter{zw: zip.NewWriter(out)}
+	} else {
+		gz := gzip.NewWriter(out)
+		writer = &tarGzArchiveWriter{gz: gz, tw: tar.NewWriter(gz)}
+	}
+
+	outputAbs, _ := filepath.Abs(toolArgs.OutputPath)
+	var files int
+	var total int64
+	for _, root := range toolArgs.Paths {
+		root = filepath.Clean(root)
+		parent := filepath.Dir(root)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if abs, _ := filepath.Abs(path); abs == outputAbs {
+				return nil // Don't add the archive to itself
+			}
+
+			name, err := filepath.Rel(parent, path)
+			if err != nil {
+				return err
+			}
+			name = filepath.ToSlash(name)
+
+			switch {
+			case name == ".":
+				return nil // The working directory itself has no name to store
+			case info.IsDir():
+				return writer.addDir(name, info)
+			case info.Mode().IsRegular():
+				files++
+				total += info.Size()
+				return writer.addFile(name, path, info)
+			}
+			return nil // Skip symlinks, devices and other special files
+		})
+		if err != nil {
+			writer.Close()
+			return "", fmt.Errorf("error adding '%s' to archive: %w", root, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("error writing archive '%s': %w", toolArgs.OutputPath, err)
+	}
+	info, err := out.Stat()
+	if err != nil {
+		return "", fmt.Errorf("error reading archive '%s': %w", toolArgs.OutputPath, err)
+	}
+	return fmt.Sprintf("Created %s with %d file(s), %s uncompressed, %s archived.", toolArgs.OutputPath, files, formatSize(total), formatSize(info.Size())), nil
+}
+
+// --- ExtractArchiveTool ---
+
+// ExtractArchiveTool unpacks a zip or gzip-compressed tar archive into a directory.
+type ExtractArchiveTool struct {
+	pathGuard
+}
+
+func (t *ExtractArchiveTool) Name() string {
+	return "extract_archive"
+}
+
+func (t *ExtractArchiveTool) RequiresConfirmation() bool {
+	return true
+}
+
+func (t *ExtractArchiveTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *ExtractArchiveTool) Description() string {
+	return "Extracts a .zip, .tar.gz or .tgz archive into a destination directory, creating it if needed and overwriting existing files. Entries that would land outside the destination are refused. Usage: {\"archive_path\": \"release.zip\", \"destination\": \"release\"}"
+}
+
+func (t *ExtractArchiveTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"archive_path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path of the archive to extract.",
+			},
+			"destination": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The directory to extract the archive into.",
+			},
+		},
+		"required": []string{"archive_path", "destination"},
+	}
+}
+
+type ExtractArchiveArgs struct {
+	ArchivePath string `json:"archive_path"`
+	Destination string `json:"destination"`
+}
+
+// extractStats counts what an extraction wrote.
+type extractStats struct {
+	files   int
+	total   int64
+	skipped int // Links and special files, which could point outside the destination
+}
+
+func (t *ExtractArchiveTool) Execute(args string) (string, error) {
+	var toolArgs ExtractArchiveArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for extract_archive: %w. Expected JSON: {\"archive_path\": \"...\", \"destination\": \"...\"}", err)
+	}
+
+	if toolArgs.ArchivePath == "" || toolArgs.Destination == "" {
+		return "", fmt.Errorf("archive_path and destination arguments are required for extract_archive")
+	}
+	for _, path := range []string{toolArgs.ArchivePath, toolArgs.Destination} {
+		if err := t.checkPath(path); err != nil {
+			return "", err
+		}
+	}
+
+	format, err := archiveFormat(toolArgs.ArchivePath)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(toolArgs.Destination, 0755); err != nil {
+		return "", fmt.Errorf("error creating directory '%s': %w", toolArgs.Destination, err)
+	}
+
+	var stats extractStats
+	if format == "zip" {
+		err = extractZip(toolArgs.ArchivePath, toolArgs.Destination, &stats)
+	} else {
+		err = extractTarGz(toolArgs.ArchivePath, toolArgs.Destination, &stats)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	result := fmt.Sprintf("Extracted %d file(s), %s, from %s to %s.", stats.files, formatSize(stats.total), toolArgs.ArchivePath, toolArgs.Destination)
+	if stats.skipped > 0 {
+		result += fmt.Sprintf(" Skipped %d link(s) or special file(s).", stats.skipped)
+	}
+	return result, nil
+}
+
+// extractTarget returns where the archive entry name is extracted to, refusing names that
+// would escape destination (zip slip).
+func extractTarget(destination, name string) (string, error) {
+	target := filepath.Join(destination, filepath.FromSlash(name))
+	if filepath.IsAbs(name) || validatePath(target, destination) != nil {
+		return "", fmt.Errorf("archive entry '%s' would be extracted outside '%s'", name, destination)
+	}
+	return target, nil
+}
+
+// writeExtractedFile writes the contents of an archive entry to target.
+func writeExtractedFile(target string, src io.Reader, mode os.FileMode) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, fmt.Errorf("error creating directory '%s': %w", filepath.Dir(target), err)
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return 0, fmt.Errorf("error creating file '%s': %w", target, err)
+	}
+	n, err := io.Copy(out, src)
+	if err != nil {
+		out.Close()
+		return n, fmt.Errorf("error writing file '%s': %w", target, err)
+	}
+	if err := out.Close(); err != nil {
+		return n, fmt.Errorf("error writing file '%s': %w", target, err)
+	}
+	return n, nil
+}
+
+func extractZip(archivePath, destination string, stats *extractStats) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return fmt.Errorf("error opening archive '%s': %w", archivePath, err)
+	}
+	defer zr.Close()
+
+	for _, file := range zr.File {
+		target, err := extractTarget(destination, file.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("error creating directory '%s': %w", target, err)
+			}
+		case mode.IsRegular():
+			src, err := file.Open()
+			if err != nil {
+				return fmt.Errorf("error reading '%s' from archive: %w", file.Name, err)
+			}
+			n, err := writeExtractedFile(target, src, mode)
+			src.Close()
+			if err != nil {
+				return err
+			}
+			stats.files++
+			stats.total += n
+		default:
+			stats.skipped++
+		}
+	}
+	return nil
+}
+
+func extractTarGz(archivePath, destination string, stats *extractStats) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("error opening archive '%s': %w", archivePath, err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("error reading archive '%s': %w", archivePath, err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading archive '%s': %w", archivePath, err)
+		}
+
+		target, err := extractTarget(destination, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("error creating directory '%s': %w", target, err)
+			}
+		case tar.TypeReg:
+			n, err := writeExtractedFile(target, tr, header.FileInfo().Mode())
+			if err != nil {
+				return err
+			}
+			stats.files++
+			stats.total += n
+		default:
+			stats.skipped++
+		}
+	}
+}
@@ -0,0 +1,1118 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itchyny/gojq"
+)
+
+// --- JSONQueryTool ---
+
+// JSONQueryTool evaluates a jq query against JSON given inline or read from a file.
+type JSONQueryTool struct {
+	pathGuard
+}
+
+// maxJSONQueryOutput caps the size of the query result returned to the model.
+const maxJSONQueryOutput = 4 * 1024
+
+func (t *JSONQueryTool) Name() string {
+	return "json_query"
+}
+
+func (t *JSONQueryTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *JSONQueryTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *JSONQueryTool) Description() string {
+	return "Runs a jq query against JSON and returns only the matching values, which is much cheaper than reading a large JSON file in full. Pass the JSON inline as input or give the path of a JSON file. Usage: {\"path\": \"package.json\", \"query\": \".dependencies | keys\"}"
+}
+
+func (t *JSONQueryTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"query": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The jq query, e.g. '.items[0].name' or '[.[] | select(.active)] | length'.",
+			},
+			"input": map[string]any{
+				"type":        "string",
+				"description": "Optional: The JSON document to query. Either input or path is required.",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional: The path of a JSON file to query instead of input.",
+			},
+		},
+		"required": []string{"query"},
+	}
+}
+
+type JSONQueryArgs struct {
+	Query string `json:"query"`
+	Input string `json:"input"`
+	Path  string `json:"path"`
+}
+
+func (t *JSONQueryTool) Execute(args string) (string, error) {
+	var toolArgs JSONQueryArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for json_query: %w. Expected JSON: {\"query\": \"...\", \"input\": \"...\"} or {\"query\": \"...\", \"path\": \"...\"}", err)
+	}
+
+	input := toolArgs.Input
+	source := "input"
+	if toolArgs.Path != "" {
+		if err := t.checkPath(toolArgs.Path); err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(toolArgs.Path)
+		if err != nil {
+			return "", fmt.Errorf("error reading file '%s': %w", toolArgs.Path, err)
+		}
+		input = string(data)
+		source = "'" + toolArgs.Path + "'"
+	} else if input == "" {
+		return "", fmt.Errorf("either input or path is required")
+	}
+
+	query, err := gojq.Parse(toolArgs.Query)
+	if err != nil {
+		return "", fmt.Errorf("invalid jq query '%s': %w", toolArgs.Query, err)
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(input), &value); err != nil {
+		return "", fmt.Errorf("invalid JSON in %s: %w", source, err)
+	}
+
+	var results []string
+	iter := query.RunWithContext(context.Background(), value)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := v.(error); ok {
+			if err, ok := err.(*gojq.HaltError); ok && err.Value() == nil {
+				break
+			}
+			return "", fmt.Errorf("error evaluating jq query: %w", err)
+		}
+
+		out, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("error encoding query result: %w", err)
+		}
+		results = append(results, string(out))
+	}
+
+	if len(results) == 0 {
+		return "The query produced no results.", nil
+	}
+
+	output := strings.Join(results, "\n")
+	if len(output) > maxJSONQueryOutput {
+		output = output[:maxJSONQueryOutput] + fmt.Sprintf("\n... (output truncated to %d bytes; narrow the query to see more)", maxJSONQueryOutput)
+	}
+	return output, nil
+}
+
+// --- Base64Tool ---
+
+// Base64Tool encodes text or a file as base64 and decodes base64 back to text, so binary
+// data can be passed through tool arguments.
+type Base64Tool struct {
+	pathGuard
+}
+
+// maxBase64FileSize caps the size of a file the base64 tool reads.
+const maxBase64FileSize = 1024 * 1024
+
+func (t *Base64Tool) Name() string {
+	return "base64"
+}
+
+func (t *Base64Tool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *Base64Tool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *Base64Tool) Description() string {
+	return "Encodes a string or a file (up to 1 MB) as base64, or decodes base64 given inline or read from a file back to text. Useful for passing binary data, e.g. embedding a binary patch in a shell command. Usage: {\"operation\": \"encode\", \"file\": \"patch.bin\"}"
+}
+
+func (t *Base64Tool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"operation": map[string]any{
+				"type":        "string",
+				"enum":        []string{"encode", "decode"},
+				"description": "Whether to encode to base64 or decode from base64.",
+			},
+			"input": map[string]any{
+				"type":        "string",
+				"description": "Optional: The string to encode or decode. Either input or file is required.",
+			},
+			"file": map[string]any{
+				"type":        "string",
+				"description": "Optional: The path of a file whose contents are encoded or decoded instead of input.",
+			},
+		},
+		"required": []string{"operation"},
+	}
+}
+
+type Base64Args struct {
+	Operation string `json:"operation"`
+	Input     string `json:"input"`
+	File      string `json:"file"`
+}
+
+func (t *Base64Tool) Execute(args string) (string, error) {
+	var toolArgs Base64Args
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for base64: %w. Expected JSON: {\"operation\": \"encode\", \"input\": \"...\"} or {\"operation\": \"encode\", \"file\": \"...\"}", err)
+	}
+
+	data := []byte(toolArgs.Input)
+	if toolArgs.File != "" {
+		if err := t.checkPath(toolArgs.File); err != nil {
+			return "", err
+		}
+		info, err := os.Stat(toolArgs.File)
+		if err != nil {
+			return "", fmt.Errorf("error reading file '%s': %w", toolArgs.File, err)
+		}
+		if info.Size() > maxBase64FileSize {
+			return "", fmt.Errorf("file '%s' is %s, larger than the 1 MB limit", toolArgs.File, formatSize(info.Size()))
+		}
+		data, err = os.ReadFile(toolArgs.File)
+		if err != nil {
+			return "", fmt.Errorf("error reading file '%s': %w", toolArgs.File, err)
+		}
+	} else if toolArgs.Input == "" {
+		return "", fmt.Errorf("either input or file is required")
+	}
+
+	switch toolArgs.Operation {
+	case "encode":
+		return base64.StdEncoding.EncodeToString(data), nil
+	case "decode":
+		decoded, err := decodeBase64(string(data))
+		if err != nil {
+			return "", err
+		}
+		if !utf8.Valid(decoded) {
+			return "", fmt.Errorf("the decoded data is binary (%d bytes) and cannot be returned as text", len(decoded))
+		}
+		return string(decoded), nil
+	default:
+		return "", fmt.Errorf("unknown operation '%s'; expected \"encode\" or \"decode\"", toolArgs.Operation)
+	}
+}
+
+// decodeBase64 decodes standard or URL-safe base64, with or without padding. Whitespace,
+// such as the line breaks of wrapped output, is ignored.
+func decodeBase64(encoded string) ([]byte, error) {
+	encoded = strings.Join(strings.Fields(encoded), "")
+	var err error
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		var decoded []byte
+		if decoded, err = encoding.DecodeString(encoded); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64 input: %w", err)
+}
+
+// --- RegexExtractTool ---
+
+// RegexExtractTool returns the capture groups of the matches of a regular expression.
+type RegexExtractTool struct {
+	pathGuard
+}
+
+// maxRegexMatches caps the number of matches regex_extract returns.
+const maxRegexMatches = 500
+
+func (t *RegexExtractTool) Name() string {
+	return "regex_extract"
+}
+
+func (t *RegexExtractTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *RegexExtractTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *RegexExtractTool) Description() string {
+	return "Runs a Go regular expression over a string or file and returns the matches with their capture groups as JSON: an array of [full_match, group1, ...] arrays, or of objects keyed by group name (\"0\" for the full match) if the pattern has named groups like (?P<name>...). Returns at most 500 matches. Usage: {\"file\": \"go.mod\", \"pattern\": \"(\\\\S+) v(\\\\S+)\", \"all_matches\": true}"
+}
+
+func (t *RegexExtractTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pattern": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The regular expression (Go RE2 syntax), e.g. '(?P<key>\\w+)=(?P<value>\\S+)'.",
+			},
+			"input": map[string]any{
+				"type":        "string",
+				"description": "Optional: The text to search. Either input or file is required.",
+			},
+			"file": map[string]any{
+				"type":        "string",
+				"description": "Optional: The path of a file to search instead of input.",
+			},
+			"all_matches": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Whether to return every match rather than only the first. Defaults to true.",
+			},
+		},
+		"required": []string{"pattern"},
+	}
+}
+
+type RegexExtractArgs struct {
+	Pattern    string `json:"pattern"`
+	Input      string `json:"input"`
+	File       string `json:"file"`
+	AllMatches *bool  `json:"all_matches"`
+}
+
+func (t *RegexExtractTool) Execute(args string) (string, error) {
+	var toolArgs RegexExtractArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for regex_extract: %w. Expected JSON: {\"pattern\": \"...\", \"input\": \"...\"} or {\"pattern\": \"...\", \"file\": \"...\"}", err)
+	}
+
+	input := toolArgs.Input
+	if toolArgs.File != "" {
+		if err := t.checkPath(toolArgs.File); err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(toolArgs.File)
+		if err != nil {
+			return "", fmt.Errorf("error reading file '%s': %w", toolArgs.File, err)
+		}
+		input = string(data)
+	} else if input == "" {
+		return "", fmt.Errorf("either input or file is required")
+	}
+
+	regex, err := regexp.Compile(toolArgs.Pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex pattern: %w", err)
+	}
+
+	limit := maxRegexMatches + 1 // One extra to detect truncation
+	if toolArgs.AllMatches != nil && !*toolArgs.AllMatches {
+		limit = 1
+	}
+	matches := regex.FindAllStringSubmatch(input, limit)
+	if len(matches) == 0 {
+		return "No matches found.", nil
+	}
+	truncated := len(matches) > maxRegexMatches
+	if truncated {
+		matches = matches[:maxRegexMatches]
+	}
+
+	// With named groups, each match becomes an object keyed by group name, or by
+	// group number for the full match and unnamed groups.
+	names := regex.SubexpNames()
+	named := false
+	for _, name := range names {
+		named = named || name != ""
+	}
+
+	results := make([]any, len(matches))
+	for i, match := range matches {
+		if !named {
+			results[i] = match
+			continue
+		}
+		groups := make(map[string]string, len(match))
+		for j, value := range match {
+			key := names[j]
+			if key == "" {
+				key = strconv.Itoa(j)
+			}
+			groups[key] = value
+		}
+		results[i] = groups
+	}
+
+	out, err := json.Marshal(results)
+	if err != nil {
+		return "", fmt.Errorf("error encoding matches: %w", err)
+	}
+	output := string(out)
+	if truncated {
+		output += fmt.Sprintf("\n... (matches truncated to %d; narrow the pattern to see more)", maxRegexMatches)
+	}
+	return output, nil
+}
+
+// --- TemplateRenderTool ---
+
+// TemplateRenderTool expands a Go text/template with the given variables, e.g. to generate
+// config files.
+type TemplateRenderTool struct {
+	pathGuard
+}
+
+// maxTemplateSize caps the size of a template file the template_render tool reads.
+const maxTemplateSize = 1024 * 1024
+
+func (t *TemplateRenderTool) Name() string {
+	return "template_render"
+}
+
+// RequiresConfirmation is true because writing the result to output_path needs approval;
+// RequiresConfirmationFor lets calls that only return the result run without asking.
+func (t *TemplateRenderTool) RequiresConfirmation() bool {
+	return true
+}
+
+func (t *TemplateRenderTool) RequiresConfirmationFor(args string) bool {
+	var toolArgs TemplateRenderArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return true
+	}
+	return toolArgs.OutputPath != ""
+}
+
+func (t *TemplateRenderTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *TemplateRenderTool) Description() string {
+	return "Renders a Go text/template, from a file or an inline string, with variables as the data object (e.g. {{.port}}). Returns the result, or writes it to output_path, which requires user confirmation. Referencing a variable that is not given is an error. Usage: {\"template_path\": \"config.yaml.tmpl\", \"variables\": {\"port\": 8080}, \"output_path\": \"config.yaml\"}"
+}
+
+func (t *TemplateRenderTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"template_path": map[string]any{
+				"type":        "string",
+				"description": "Optional: The path of the template file. Either template_path or template_string is required.",
+			},
+			"template_string": map[string]any{
+				"type":        "string",
+				"description": "Optional: The template text, used instead of template_path.",
+			},
+			"variables": map[string]any{
+				"type":        "object",
+				"description": "Optional: The data the template is executed with. Values may be strings, numbers, booleans, arrays or objects.",
+			},
+			"output_path": map[string]any{
+				"type":        "string",
+				"description": "Optional: Write the result to this file instead of returning it. Requires user confirmation.",
+			},
+		},
+	}
+}
+
+type TemplateRenderArgs struct {
+	TemplatePath   string         `json:"template_path"`
+	TemplateString string         `json:"template_string"`
+	Variables      map[string]any `json:"variables"`
+	OutputPath     string         `json:"output_path"`
+}
+
+func (t *TemplateRenderTool) Execute(args string) (string, error) {
+	var toolArgs TemplateRenderArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for template_render: %w. Expected JSON: {\"template_path\": \"...\", \"variables\": {...}} or {\"template_string\": \"...\", \"variables\": {...}}", err)
+	}
+
+	// The template name prefixes parse and execution errors, which carry line numbers
+	name, text := "template", toolArgs.TemplateString
+	switch {
+	case toolArgs.TemplatePath != "" && toolArgs.TemplateString != "":
+		return "", fmt.Errorf("give either template_path or template_string, not both")
+	case toolArgs.TemplatePath != "":
+		if err := t.checkPath(toolArgs.TemplatePath); err != nil {
+			return "", err
+		}
+		info, err := os.Stat(toolArgs.TemplatePath)
+		if err != nil {
+			return "", fmt.Errorf("error reading template '%s': %w", toolArgs.TemplatePath, err)
+		}
+		if info.Size() > maxTemplateSize {
+			return "", fmt.Errorf("template '%s' is %s, larger than the 1 MB limit", toolArgs.TemplatePath, formatSize(info.Size()))
+		}
+		data, err := os.ReadFile(toolArgs.TemplatePath)
+		if err != nil {
+			return "", fmt.Errorf("error reading template '%s': %w", toolArgs.TemplatePath, err)
+		}
+		name, text = filepath.Base(toolArgs.TemplatePath), string(data)
+	case toolArgs.TemplateString == "":
+		return "", fmt.Errorf("either template_path or template_string is required")
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, toolArgs.Variables); err != nil {
+		return "", fmt.Errorf("error executing template: %w", err)
+	}
+
+	if toolArgs.OutputPath == "" {
+		return out.String(), nil
+	}
+	if err := t.checkPath(toolArgs.OutputPath); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(toolArgs.OutputPath, []byte(out.String())); err != nil {
+		return "", fmt.Errorf("error writing file '%s': %w", toolArgs.OutputPath, err)
+	}
+	return fmt.Sprintf("Rendered template to %s (%d bytes).", toolArgs.OutputPath, out.Len()), nil
+}
+
+// --- CalculatorTool ---
+
+// CalculatorTool evaluates arithmetic expressions exactly, instead of leaving the arithmetic
+// to the model.
+type CalculatorTool struct{}
+
+func (t *CalculatorTool) Name() string {
+	return "calculator"
+}
+
+func (t *CalculatorTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *CalculatorTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *CalculatorTool) Description() string {
+	return "Evaluates a mathematical expression and returns the result with 6 significant digits. Supports + - * / % ^ (power), parentheses, the functions sqrt, abs, floor, ceil and mod(a, b), and the constants pi and e. Use it for any arithmetic instead of computing by hand. Usage: {\"expression\": \"2^10 + sqrt(144) / 3\"}"
+}
+
+func (t *CalculatorTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"expression": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The expression to evaluate.",
+			},
+		},
+		"required": []string{"expression"},
+	}
+}
+
+type CalculatorArgs struct {
+	Expression string `json:"expression"`
+}
+
+func (t *CalculatorTool) Execute(args string) (string, error) {
+	var toolArgs CalculatorArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for calculator: %w. Expected JSON: {\"expression\": \"...\"}", err)
+	}
+
+	if strings.TrimSpace(toolArgs.Expression) == "" {
+		return "", fmt.Errorf("expression argument is required for calculator")
+	}
+
+	result, err := evaluateExpression(toolArgs.Expression)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(result, 'g', 6, 64), nil
+}
+
+// evaluateExpression evaluates an arithmetic expression as described for the calculator
+// tool. Errors for malformed expressions give the 1-based position of the offending token.
+func evaluateExpression(expression string) (float64, error) {
+	p := &exprParser{input: expression}
+	p.next()
+	value, err := p.parseExpr()
+	if err != nil {
+		return 0, err
+	}
+	if p.tok.kind != tokEOF {
+		return 0, p.unexpected()
+	}
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("the result is not a finite number")
+	}
+	return value, nil
+}
+
+type exprTokenKind int
+
+const (
+	tokEOF exprTokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp // One of + - * / % ^ ( ) ,
+)
+
+type exprToken struct {
+	kind  exprTokenKind
+	text  string
+	value float64
+	pos   int // 1-based position in the input
+}
+
+// exprParser is a recursive descent parser that evaluates while parsing:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("+" | "-") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number | constant | function "(" expr { "," expr } ")" | "(" expr ")"
+type exprParser struct {
+	input  string
+	offset int
+	tok    exprToken
+	err    error // Tokenizer error, reported when the bad token is reached
+}
+
+// next advances to the next token.
+func (p *exprParser) next() {
+	for p.offset < len(p.input) && (p.input[p.offset] == ' ' || p.input[p.offset] == '\t' || p.input[p.offset] == '\n') {
+		p.offset++
+	}
+	start := p.offset
+	if start >= len(p.input) {
+		p.tok = exprToken{kind: tokEOF, pos: start + 1}
+		return
+	}
+
+	c := p.input[start]
+	switch {
+	case c >= '0' && c <= '9' || c == '.':
+		end := start
+		for end < len(p.input) && (isDigit(p.input[end]) || p.input[end] == '.') {
+			end++
+		}
+		// Exponent, as in 1.5e-3
+		if end < len(p.input) && (p.input[end] == 'e' || p.input[end] == 'E') {
+			exp := end + 1
+			if exp < len(p.input) && (p.input[exp] == '+' || p.input[exp] == '-') {
+				exp++
+			}
+			if exp < len(p.input) && isDigit(p.input[exp]) {
+				for exp < len(p.input) && isDigit(p.input[exp]) {
+					exp++
+				}
+				end = exp
+			}
+		}
+		text := p.input[start:end]
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			p.err = fmt.Errorf("parse error at position %d: invalid number '%s'", start+1, text)
+		}
+		p.tok = exprToken{kind: tokNumber, text: text, value: value, pos: start + 1}
+		p.offset = end
+	case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_':
+		end := start
+		for end < len(p.input) && (isDigit(p.input[end]) || p.input[end] >= 'a' && p.input[end] <= 'z' || p.input[end] >= 'A' && p.input[end] <= 'Z' || p.input[end] == '_') {
+			end++
+		}
+		p.tok = exprToken{kind: tokIdent, text: strings.ToLower(p.input[start:end]), pos: start + 1}
+		p.offset = end
+	case strings.IndexByte("+-*/%^(),", c) >= 0:
+		p.tok = exprToken{kind: tokOp, text: string(c), pos: start + 1}
+		p.offset++
+	default:
+		_, size := utf8.DecodeRuneInString(p.input[start:])
+		p.tok = exprToken{kind: tokOp, text: p.input[start : start+size], pos: start + 1}
+		p.offset += size
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// unexpected returns the error for the current token.
+func (p *exprParser) unexpected() error {
+	if p.err != nil {
+		return p.err
+	}
+	if p.tok.kind == tokEOF {
+		return fmt.Errorf("parse error at position %d: unexpected end of expression", p.tok.pos)
+	}
+	return fmt.Errorf("parse error at position %d: unexpected '%s'", p.tok.pos, p.tok.text)
+}
+
+func (p *exprParser) isOp(op string) bool {
+	return p.tok.kind == tokOp && p.tok.text == op
+}
+
+func (p *exprParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		op := p.tok.text
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+		if op == "+" {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseTerm() (float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for p.isOp("*") || p.isOp("/") || p.isOp("%") {
+		op := p.tok
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		switch op.text {
+		case "*":
+			left *= right
+		case "/":
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero at position %d", op.pos)
+			}
+			left /= right
+		case "%":
+			if right == 0 {
+				return 0, fmt.Errorf("modulo by zero at position %d", op.pos)
+			}
+			left = math.Mod(left, right)
+		}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (float64, error) {
+	if p.isOp("-") || p.isOp("+") {
+		negate := p.isOp("-")
+		p.next()
+		value, err := p.parseUnary()
+		if negate {
+			value = -value
+		}
+		return value, err
+	}
+	return p.parsePower()
+}
+
+func (p *exprParser) parsePower() (float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return 0, err
+	}
+	if !p.isOp("^") {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.parseUnary() // Right-associative: 2^3^2 is 2^9
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exponent), nil
+}
+
+// exprFunctions are the functions expressions may call, by number of arguments.
+var exprFunctions = map[string]struct {
+	arity int
+	call  func(args []float64) (float64, error)
+}{
+	"sqrt": {1, func(args []float64) (float64, error) {
+		if args[0] < 0 {
+			return 0, fmt.Errorf("sqrt of a negative number")
+		}
+		return math.Sqrt(args[0]), nil
+	}},
+	"abs":   {1, func(args []float64) (float64, error) { return math.Abs(args[0]), nil }},
+	"floor": {1, func(args []float64) (float64, error) { return math.Floor(args[0]), nil }},
+	"ceil":  {1, func(args []float64) (float64, error) { return math.Ceil(args[0]), nil }},
+	"mod": {2, func(args []float64) (float64, error) {
+		if args[1] == 0 {
+			return 0, fmt.Errorf("modulo by zero")
+		}
+		return math.Mod(args[0], args[1]), nil
+	}},
+}
+
+// exprConstants are the named constants expressions may use.
+var exprConstants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+func (p *exprParser) parsePrimary() (float64, error) {
+	tok := p.tok
+	switch {
+	case p.err != nil:
+		return 0, p.err
+	case tok.kind == tokNumber:
+		p.next()
+		return tok.value, nil
+	case p.isOp("("):
+		p.next()
+		value, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if !p.isOp(")") {
+			return 0, p.unexpected()
+		}
+		p.next()
+		return value, nil
+	case tok.kind == tokIdent:
+		p.next()
+		if value, ok := exprConstants[tok.text]; ok {
+			return value, nil
+		}
+		fn, ok := exprFunctions[tok.text]
+		if !ok {
+			return 0, fmt.Errorf("parse error at position %d: unknown function or constant '%s'", tok.pos, tok.text)
+		}
+		if !p.isOp("(") {
+			return 0, p.unexpected()
+		}
+		p.next()
+		var args []float64
+		for {
+			value, err := p.parseExpr()
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, value)
+			if !p.isOp(",") {
+				break
+			}
+			p.next()
+		}
+		if !p.isOp(")") {
+			return 0, p.unexpected()
+		}
+		p.next()
+		if len(args) != fn.arity {
+			return 0, fmt.Errorf("%s at position %d takes %d argument(s), got %d", tok.text, tok.pos, fn.arity, len(args))
+		}
+		value, err := fn.call(args)
+		if err != nil {
+			return 0, fmt.Errorf("%w at position %d", err, tok.pos)
+		}
+		return value, nil
+	default:
+		return 0, p.unexpected()
+	}
+}
+
+// --- DateTimeTool ---
+
+// DateTimeTool does date and time arithmetic, which models often get wrong.
+type DateTimeTool struct{}
+
+func (t *DateTimeTool) Name() string {
+	return "datetime"
+}
+
+func (t *DateTimeTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *DateTimeTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *DateTimeTool) Description() string {
+	return "Works with dates and times. Operations: now (current time), parse (read a date in almost any common format), format (convert a time to another layout), add (add a duration like \"36h\", \"-90m\" or \"2w3d\"), diff (the duration from time to other_time). Results include a readable form and the Unix timestamp. Times without a zone are UTC. Usage: {\"operation\": \"add\", \"time\": \"2024-02-27\", \"duration\": \"3d\"}"
+}
+
+func (t *DateTimeTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"operation": map[string]any{
+				"type":        "string",
+				"enum":        []string{"now", "parse", "format", "add", "diff"},
+				"description": "The operation to perform.",
+			},
+			"time": map[string]any{
+				"type":        "string",
+				"description": "Optional: The time to work on, e.g. \"2024-03-01T12:00:00Z\", \"2024-03-01\", \"Mar 1, 2024\", a Unix timestamp, or now, today, yesterday or tomorrow. Required except for now.",
+			},
+			"other_time": map[string]any{
+				"type":        "string",
+				"description": "Optional: For diff, the second time. Defaults to now.",
+			},
+			"duration": map[string]any{
+				"type":        "string",
+				"description": "Optional: For add, a Go duration such as \"1h30m\", which may also use d (days) and w (weeks), e.g. \"-2w\".",
+			},
+			"format": map[string]any{
+				"type":        "string",
+				"description": "Optional: For format, the output layout: RFC3339, RFC1123, RFC822, ANSIC, DateTime, DateOnly, TimeOnly, Kitchen, unix, or a Go layout such as \"2006-01-02 15:04\".",
+			},
+			"timezone": map[string]any{
+				"type":        "string",
+				"description": "Optional: IANA time zone, e.g. \"Europe/Berlin\", in which results are shown. Defaults to UTC.",
+			},
+		},
+		"required": []string{"operation"},
+	}
+}
+
+type DateTimeArgs struct {
+	Operation string `json:"operation"`
+	Time      string `json:"time"`
+	OtherTime string `json:"other_time"`
+	Duration  string `json:"duration"`
+	Format    string `json:"format"`
+	Timezone  string `json:"timezone"`
+}
+
+func (t *DateTimeTool) Execute(args string) (string, error) {
+	var toolArgs DateTimeArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for datetime: %w. Expected JSON: {\"operation\": \"now\"} or {\"operation\": \"add\", \"time\": \"...\", \"duration\": \"...\"}", err)
+	}
+
+	loc := time.UTC
+	if toolArgs.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(toolArgs.Timezone); err != nil {
+			return "", fmt.Errorf("unknown time zone '%s': %w", toolArgs.Timezone, err)
+		}
+	}
+
+	if toolArgs.Operation == "now" {
+		return describeTime(time.Now().In(loc)), nil
+	}
+
+	if toolArgs.Time == "" {
+		return "", fmt.Errorf("time argument is required for the %s operation", toolArgs.Operation)
+	}
+	tm, err := parseTime(toolArgs.Time)
+	if err != nil {
+		return "", err
+	}
+	tm = tm.In(loc)
+
+	switch toolArgs.Operation {
+	case "parse":
+		return describeTime(tm), nil
+	case "format":
+		if toolArgs.Format == "" {
+			return "", fmt.Errorf("format argument is required for the format operation")
+		}
+		if strings.EqualFold(toolArgs.Format, "unix") {
+			return strconv.FormatInt(tm.Unix(), 10), nil
+		}
+		layout := toolArgs.Format
+		if named, ok := timeLayouts[strings.ToLower(layout)]; ok {
+			layout = named
+		}
+		return tm.Format(layout), nil
+	case "add":
+		if toolArgs.Duration == "" {
+			return "", fmt.Errorf("duration argument is required for the add operation")
+		}
+		d, err := parseDuration(toolArgs.Duration)
+		if err != nil {
+			return "", err
+		}
+		return describeTime(tm.Add(d)), nil
+	case "diff":
+		other := time.Now()
+		if toolArgs.OtherTime != "" {
+			if other, err = parseTime(toolArgs.OtherTime); err != nil {
+				return "", err
+			}
+		}
+		d := other.Sub(tm)
+		return fmt.Sprintf("Duration: %s\nReadable: %s\nSeconds: %d", d, humanDuration(d), int64(d.Seconds())), nil
+	default:
+		return "", fmt.Errorf("unknown operation '%s'; expected now, parse, format, add or diff", toolArgs.Operation)
+	}
+}
+
+// timeLayouts maps the layout names accepted by the format operation to Go layouts.
+var timeLayouts = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"rfc1123":  time.RFC1123,
+	"rfc822":   time.RFC822,
+	"ansic":    time.ANSIC,
+	"datetime": time.DateTime,
+	"dateonly": time.DateOnly,
+	"timeonly": time.TimeOnly,
+	"kitchen":  time.Kitchen,
+}
+
+// parseLayouts are tried in order when parsing a time.
+var parseLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.UnixDate,
+	time.RubyDate,
+	time.ANSIC,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 MST",
+	time.DateTime,
+	"2006-01-02 15:04",
+	time.DateOnly,
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"01/02/2006 15:04:05",
+	"01/02/2006 15:04",
+	"01/02/2006",
+	"02.01.2006 15:04",
+	"02.01.2006",
+	"Jan 2, 2006 15:04:05",
+	"Jan 2, 2006 3:04 PM",
+	"Jan 2, 2006",
+	"January 2, 2006 15:04",
+	"January 2, 2006",
+	"Mon, Jan 2, 2006",
+	"Monday, January 2, 2006",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2 2006",
+	"20060102",
+}
+
+// parseTime reads a time in any of parseLayouts, a Unix timestamp in seconds or
+// milliseconds, or one of now, today, yesterday and tomorrow (midnight UTC).
+func parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	switch strings.ToLower(value) {
+	case "now":
+		return time.Now().UTC(), nil
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	// Nine or more digits is a Unix timestamp rather than a date like 20240301
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil && len(strings.TrimPrefix(value, "-")) > 8 {
+		if len(value) >= 13 {
+			return time.UnixMilli(n).UTC(), nil
+		}
+		return time.Unix(n, 0).UTC(), nil
+	}
+
+	for _, layout := range parseLayouts {
+		if tm, err := time.Parse(layout, value); err == nil {
+			return tm, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time '%s'; use a format like 2006-01-02T15:04:05Z07:00, 2006-01-02 or a Unix timestamp", value)
+}
+
+// durationUnit matches the day and week components parseDuration adds to Go durations.
+var durationUnit = regexp.MustCompile(`(\d+(?:\.\d+)?)([dw])`)
+
+// parseDuration reads a Go duration that may also use d (24h) and w (7d).
+func parseDuration(value string) (time.Duration, error) {
+	expanded := durationUnit.ReplaceAllStringFunc(strings.TrimSpace(value), func(match string) string {
+		parts := durationUnit.FindStringSubmatch(match)
+		n, _ := strconv.ParseFloat(parts[1], 64)
+		hours := n * 24
+		if parts[2] == "w" {
+			hours *= 7
+		}
+		return strconv.FormatFloat(hours, 'f', -1, 64) + "h"
+	})
+	d, err := time.ParseDuration(expanded)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s': use a form like \"1h30m\", \"-45m\" or \"2d12h\"", value)
+	}
+	return d, nil
+}
+
+// describeTime renders a time as RFC 3339, in words and as a Unix timestamp.
+func describeTime(tm time.Time) string {
+	return fmt.Sprintf("%s\nReadable: %s\nUnix: %d", tm.Format(time.RFC3339), tm.Format("Monday, 2 January 2006, 15:04:05 MST"), tm.Unix())
+}
+
+// humanDuration renders d in days, hours, minutes and seconds, e.g. "3 days 4 hours".
+func humanDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	units := []struct {
+		name string
+		size time.Duration
+	}{{"day", 24 * time.Hour}, {"hour", time.Hour}, {"minute", time.Minute}, {"second", time.Second}}
+
+	var parts []string
+	for _, unit := range units {
+		if n := d / unit.size; n > 0 {
+			name := unit.name
+			if n != 1 {
+				name += "s"
+			}
+			parts = append(parts, fmt.Sprintf("%d %s", n, name))
+			d -= n * unit.size
+		}
+	}
+	if len(parts) == 0 {
+		return "0 seconds"
+	}
+	return sign + strings.Join(parts, " ")
+}
@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite" // Registers the CGO-free "sqlite" driver
+)
+
+const (
+	// defaultSQLiteRows is the number of rows sqlite_query returns unless max_rows says otherwise.
+	defaultSQLiteRows = 50
+	// sqliteQueryTimeout bounds every query.
+	sqliteQueryTimeout = 30 * time.Second
+)
+
+// sqlComment matches SQL line and block comments.
+var sqlComment = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+
+// sqlWriteKeyword matches statements that change the database inside a WITH query.
+var sqlWriteKeyword = regexp.MustCompile(`(?i)\b(insert|update|delete|replace)\b`)
+
+// readPragma matches pragmas that take an argument but only report information.
+var readPragma = regexp.MustCompile(`(?i)^pragma\s+(\w+\.)?(table_x?info|table_list|index_list|index_x?info|foreign_key_list|foreign_key_check|integrity_check|quick_check)\b`)
+
+// isReadOnlyQuery reports whether query only reads the database. Read-only queries also
+// run on a query_only connection, so a misjudged query fails instead of writing.
+func isReadOnlyQuery(query string) bool {
+	query = strings.TrimSpace(sqlComment.ReplaceAllString(query, " "))
+	keyword, _, _ := strings.Cut(query, " ")
+	switch strings.ToUpper(strings.TrimRight(keyword, "(;\n\t")) {
+	case "SELECT", "EXPLAIN", "VALUES":
+		return true
+	case "WITH":
+		return !sqlWriteKeyword.MatchString(query)
+	case "PRAGMA":
+		// PRAGMA name = value and PRAGMA name(value) change settings, except for the
+		// pragmas that take a table or index name
+		return !strings.Contains(query, "=") && (!strings.Contains(query, "(") || readPragma.MatchString(query))
+	default:
+		return false
+	}
+}
+
+// --- SQLiteQueryTool ---
+
+// SQLiteQueryTool runs SQL against a local SQLite database.
+type SQLiteQueryTool struct {
+	pathGuard
+}
+
+func (t *SQLiteQueryTool) Name() string {
+	return "sqlite_query"
+}
+
+// RequiresConfirmation is true because statements that modify the database need approval;
+// RequiresConfirmationFor lets read-only queries run without asking.
+func (t *SQLiteQueryTool) RequiresConfirmation() bool {
+	return true
+}
+
+func (t *SQLiteQueryTool) RequiresConfirmationFor(args string) bool {
+	var toolArgs SQLiteQueryArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return true
+	}
+	return !isReadOnlyQuery(toolArgs.Query)
+}
+
+func (t *SQLiteQueryTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *SQLiteQueryTool) Description() string {
+	return "Runs a SQL query against a SQLite database file. SELECT, EXPLAIN and read-only PRAGMA queries return a Markdown table; other statements (INSERT, UPDATE, DELETE, DROP, ...) require user confirmation and return the number of rows affected. Usage: {\"db_path\": \"data.db\", \"query\": \"SELECT * FROM users\", \"max_rows\": 50}"
+}
+
+func (t *SQLiteQueryTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"db_path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the SQLite database file. Statements that modify the database create it if it does not exist.",
+			},
+			"query": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The SQL to run.",
+			},
+			"max_rows": map[string]any{
+				"type":        "integer",
+				"minimum":     1,
+				"maximum":     1000,
+				"description": fmt.Sprintf("Optional: The maximum number of rows to return. Defaults to %d.", defaultSQLiteRows),
+			},
+		},
+		"required": []string{"db_path", "query"},
+	}
+}
+
+type SQLiteQueryArgs struct {
+	DBPath  string `json:"db_path"`
+	Query   string `json:"query"`
+	MaxRows int    `json:"max_rows"`
+}
+
+func (t *SQLiteQueryTool) Execute(args string) (string, error) {
+	var toolArgs SQLiteQueryArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for sqlite_query: %w. Expected JSON: {\"db_path\": \"...\", \"query\": \"...\"}", err)
+	}
+
+	if toolArgs.DBPath == "" || strings.TrimSpace(toolArgs.Query) == "" {
+		return "", fmt.Errorf("db_path and query arguments are required for sqlite_query")
+	}
+	if err := t.checkPath(toolArgs.DBPath); err != nil {
+		return "", err
+	}
+	maxRows := toolArgs.MaxRows
+	if maxRows <= 0 {
+		maxRows = defaultSQLiteRows
+	}
+
+	readOnly := isReadOnlyQuery(toolArgs.Query)
+	dsn := toolArgs.DBPath + "?_pragma=busy_timeout(5000)"
+	if readOnly {
+		// Opening a missing file would create an empty database
+		if _, err := os.Stat(toolArgs.DBPath); err != nil {
+			return "", fmt.Errorf("error opening database '%s': %w", toolArgs.DBPath, err)
+		}
+		dsn += "&_pragma=query_only(1)"
+	}
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return "", fmt.Errorf("error opening database '%s': %w", toolArgs.DBPath, err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), sqliteQueryTimeout)
+	defer cancel()
+
+	if !readOnly {
+		res, err := db.ExecContext(ctx, toolArgs.Query)
+		if err != nil {
+			return "", fmt.Errorf("error executing query: %w", err)
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return "Query executed.", nil
+		}
+		return fmt.Sprintf("Query executed, %d rows affected.", affected), nil
+	}
+
+	rows, err := db.QueryContext(ctx, toolArgs.Query)
+	if err != nil {
+		return "", fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", fmt.Errorf("error reading columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return "Query executed, it returned no columns.", nil
+	}
+
+	var table strings.Builder
+	table.WriteString("| " + strings.Join(markdownCells(columns), " | ") + " |\n")
+	table.WriteString("|" + strings.Repeat(" --- |", len(columns)) + "\n")
+
+	values := make([]any, len(columns))
+	scanArgs := make([]any, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	count, truncated := 0, false
+	for rows.Next() {
+		if count == maxRows {
+			truncated = true
+			break
+		}
+		if err := rows.Scan(scanArgs...); err != nil {
+			return "", fmt.Errorf("error reading row: %w", err)
+		}
+		cells := make([]string, len(values))
+		for i, value := range values {
+			cells[i] = formatSQLValue(value)
+		}
+		table.WriteString("| " + strings.Join(markdownCells(cells), " | ") + " |\n")
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("error reading rows: %w", err)
+	}
+
+	if truncated {
+		fmt.Fprintf(&table, "\nShowing the first %d rows; the query returned more. Add a LIMIT or raise max_rows to see others.", maxRows)
+	} else {
+		rowWord := "rows"
+		if count == 1 {
+			rowWord = "row"
+		}
+		fmt.Fprintf(&table, "\n(%d %s)", count, rowWord)
+	}
+	return table.String(), nil
+}
+
+// formatSQLValue renders a scanned column value for a table cell.
+func formatSQLValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return fmt.Sprintf("<%d bytes>", len(v))
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// markdownCells escapes values so each stays within one Markdown table cell.
+func markdownCells(values []string) []string {
+	cells := make([]string, len(values))
+	for i, value := range values {
+		value = strings.ReplaceAll(value, "|", `\|`)
+		cells[i] = strings.Join(strings.Fields(value), " ")
+	}
+	return cells
+}
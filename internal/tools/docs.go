@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// --- GenerateReadmeTool ---
+
+// GenerateReadmeTool writes a README.md generated by the LLM from a repository's structure.
+type GenerateReadmeTool struct {
+	pathGuard
+	completer Completer
+}
+
+func (t *GenerateReadmeTool) Name() string {
+	return "generate_readme"
+}
+
+func (t *GenerateReadmeTool) RequiresConfirmation() bool {
+	return true // Writes (and possibly overwrites) README.md
+}
+
+func (t *GenerateReadmeTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *GenerateReadmeTool) SetCompleter(c Completer) {
+	t.completer = c
+}
+
+func (t *GenerateReadmeTool) Description() string {
+	return "Generates a README.md for a Go repository from its go.mod, package docs, top-level directories and Makefile targets, and writes it to <root>/README.md. An existing README is backed up to README.md.bak. Usage: {\"root\": \"<repository_path>\"}"
+}
+
+func (t *GenerateReadmeTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"root": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The root directory of the repository.",
+			},
+		},
+		"required": []string{"root"},
+	}
+}
+
+type GenerateReadmeArgs struct {
+	Root string `json:"root"`
+}
+
+// ConfirmationSummary shows the README that would be replaced, if any.
+func (t *GenerateReadmeTool) ConfirmationSummary(args string) string {
+	var toolArgs GenerateReadmeArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil || toolArgs.Root == "" {
+		return ""
+	}
+
+	existing, err := os.ReadFile(filepath.Join(toolArgs.Root, "README.md"))
+	if err != nil {
+		return "README.md does not exist yet and will be created."
+	}
+
+	const maxLines = 15
+	lines := strings.Split(strings.TrimSpace(string(existing)), "\n")
+	summary := "README.md already exists and will be replaced (backup: README.md.bak). Current content:\n"
+	if len(lines) > maxLines {
+		return summary + strings.Join(lines[:maxLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-maxLines)
+	}
+	return summary + strings.Join(lines, "\n")
+}
+
+var makeTargetRegex = regexp.MustCompile(`^([A-Za-z0-9_./-]+):`)
+
+const readmePromptTemplate = `Write a README.md for the Go repository described below.
+Use these sections: a title with a one-paragraph overview, Features, Installation, Usage, Project Structure and Development.
+Only describe what the context supports. Reply with the Markdown document only.
+
+Module: %s
+
+Packages:
+%s
+
+Top-level directories:
+%s
+
+Makefile targets:
+%s`
+
+func (t *GenerateReadmeTool) Execute(args string) (string, error) {
+	var toolArgs GenerateReadmeArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for generate_readme: %w", err)
+	}
+
+	if toolArgs.Root == "" {
+		return "", fmt.Errorf("root argument is required for generate_readme")
+	}
+	if err := t.checkPath(toolArgs.Root); err != nil {
+		return "", err
+	}
+	if t.completer == nil {
+		return "", fmt.Errorf("generate_readme is not connected to an LLM client")
+	}
+
+	module, err := readModulePath(filepath.Join(toolArgs.Root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+
+	prompt := fmt.Sprintf(readmePromptTemplate,
+		module,
+		orNone(packageDocs(toolArgs.Root)),
+		orNone(topLevelDirs(toolArgs.Root)),
+		orNone(makeTargets(filepath.Join(toolArgs.Root, "Makefile"))),
+	)
+
+	readme, err := t.completer.Complete(prompt)
+	if err != nil {
+		return "", fmt.Errorf("error calling LLM to generate README: %w", err)
+	}
+
+	readmePath := filepath.Join(toolArgs.Root, "README.md")
+	if err := t.checkPath(readmePath); err != nil {
+		return "", err
+	}
+	backupPath, err := backupFile(readmePath)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(readmePath, []byte(strings.TrimSpace(readme)+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("error writing to file '%s': %w", readmePath, err)
+	}
+
+	result := fmt.Sprintf("Successfully wrote %d bytes to %s", len(readme), readmePath)
+	if backupPath != "" {
+		result += fmt.Sprintf(" (previous version backed up to %s)", backupPath)
+	}
+	return result, nil
+}
+
+// readModulePath returns the module path declared in a go.mod file.
+func readModulePath(goModPath string) (string, error) {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading '%s': %w", goModPath, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "module ")), nil
+		}
+	}
+	return "", fmt.Errorf("no module directive found in '%s'", goModPath)
+}
+
+// packageDocs lists every package in the module with its doc synopsis.
+func packageDocs(root string) string {
+	cmd := exec.Command("go", "list", "-f", "{{.ImportPath}}: {{.Doc}}", "./...")
+	cmd.Dir = root
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// topLevelDirs lists the non-hidden directories directly under root.
+func topLevelDirs(root string) string {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return ""
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			dirs = append(dirs, entry.Name()+"/")
+		}
+	}
+	return strings.Join(dirs, "\n")
+}
+
+// makeTargets lists the targets defined in a Makefile, if there is one.
+func makeTargets(makefilePath string) string {
+	file, err := os.Open(makefilePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	var targets []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		match := makeTargetRegex.FindStringSubmatch(scanner.Text())
+		if match != nil && !strings.HasPrefix(match[1], ".") {
+			targets = append(targets, match[1])
+		}
+	}
+	return strings.Join(targets, "\n")
+}
+
+// backupFile copies path to path+".bak" if it exists and returns the backup path.
+func backupFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading file '%s': %w", path, err)
+	}
+
+	backupPath := path + ".bak"
+	if err := os.WriteFile(backupPath, content, 0644); err != nil {
+		return "", fmt.Errorf("error writing backup '%s': %w", backupPath, err)
+	}
+	return backupPath, nil
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// secretEnvMarkers mark environment variables whose values env_list does not reveal.
+var secretEnvMarkers = []string{"KEY", "SECRET", "TOKEN", "PASSWORD"}
+
+// isSecretEnv reports whether the variable name suggests that its value is a secret.
+func isSecretEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range secretEnvMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// --- EnvGetTool ---
+
+// EnvGetTool reads one environment variable of the agent's process.
+type EnvGetTool struct{}
+
+func (t *EnvGetTool) Name() string {
+	return "env_get"
+}
+
+// RequiresConfirmation is true because the value is sent to the model, which must not see
+// secrets without approval; RequiresConfirmationFor lets other variables be read without asking.
+func (t *EnvGetTool) RequiresConfirmation() bool {
+	return true
+}
+
+func (t *EnvGetTool) RequiresConfirmationFor(args string) bool {
+	var toolArgs EnvGetArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return true
+	}
+	return isSecretEnv(toolArgs.Name)
+}
+
+func (t *EnvGetTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *EnvGetTool) Description() string {
+	return "Returns the value of an environment variable, or notes that it is unset. Reading a variable whose name suggests a secret (containing KEY, SECRET, TOKEN or PASSWORD) requires user confirmation. Usage: {\"name\": \"GOPATH\"}"
+}
+
+func (t *EnvGetTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The name of the environment variable.",
+			},
+		},
+		"required": []string{"name"},
+	}
+}
+
+type EnvGetArgs struct {
+	Name string `json:"name"`
+}
+
+func (t *EnvGetTool) Execute(args string) (string, error) {
+	var toolArgs EnvGetArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for env_get: %w. Expected JSON: {\"name\": \"...\"}", err)
+	}
+
+	value, ok := os.LookupEnv(toolArgs.Name)
+	if !ok {
+		return fmt.Sprintf("(%s is not set)", toolArgs.Name), nil
+	}
+	return value, nil
+}
+
+// --- EnvSetTool ---
+
+// EnvSetTool sets an environment variable of the agent's process, which the commands
+// it runs afterwards inherit.
+type EnvSetTool struct{}
+
+func (t *EnvSetTool) Name() string {
+	return "env_set"
+}
+
+func (t *EnvSetTool) RequiresConfirmation() bool {
+	return true
+}
+
+func (t *EnvSetTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *EnvSetTool) Description() string {
+	return "Sets an environment variable for the rest of the session; shell commands run afterwards see it. Requires user confirmation. Usage: {\"name\": \"GOFLAGS\", \"value\": \"-mod=mod\"}"
+}
+
+func (t *EnvSetTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The name of the environment variable.",
+			},
+			"value": map[string]any{
+				"type":        "string",
+				"description": "The value to set. An empty string sets the variable to empty rather than unsetting it.",
+			},
+		},
+		"required": []string{"name", "value"},
+	}
+}
+
+type EnvSetArgs struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (t *EnvSetTool) Execute(args string) (string, error) {
+	var toolArgs EnvSetArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for env_set: %w. Expected JSON: {\"name\": \"...\", \"value\": \"...\"}", err)
+	}
+
+	if err := os.Setenv(toolArgs.Name, toolArgs.Value); err != nil {
+		return "", fmt.Errorf("error setting environment variable '%s': %w", toolArgs.Name, err)
+	}
+	return fmt.Sprintf("Set %s.", toolArgs.Name), nil
+}
+
+// --- EnvListTool ---
+
+// EnvListTool lists the environment of the agent's process, hiding secret values.
+type EnvListTool struct{}
+
+func (t *EnvListTool) Name() string {
+	return "env_list"
+}
+
+func (t *EnvListTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *EnvListTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *EnvListTool) Description() string {
+	return "Lists all environment variables sorted by name. Values of variables whose names contain KEY, SECRET, TOKEN or PASSWORD are shown as [REDACTED]. Usage: {}"
+}
+
+func (t *EnvListTool) Parameters() any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+func (t *EnvListTool) Execute(args string) (string, error) {
+	env := os.Environ()
+	sort.Strings(env)
+
+	var output strings.Builder
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		if isSecretEnv(name) {
+			entry = name + "=[REDACTED]"
+		}
+		output.WriteString(entry + "\n")
+	}
+	return output.String(), nil
+}
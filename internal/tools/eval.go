@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// --- EvaluateResponseTool ---
+
+// EvaluateResponseTool asks the LLM to grade an answer against a set of criteria.
+type EvaluateResponseTool struct {
+	completer Completer
+}
+
+func (t *EvaluateResponseTool) Name() string {
+	return "evaluate_response"
+}
+
+func (t *EvaluateResponseTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *EvaluateResponseTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *EvaluateResponseTool) SetCompleter(c Completer) {
+	t.completer = c
+}
+
+func (t *EvaluateResponseTool) Description() string {
+	return "Evaluates a response to a question and returns a JSON rubric with a 1-5 score and reasoning per criterion plus an overall score. Usage: {\"question\": \"<question>\", \"response\": \"<response>\", \"criteria\": [\"factual\", \"complete\", \"safe\"]}"
+}
+
+func (t *EvaluateResponseTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"question": map[string]any{
+				"type":        "string",
+				"description": "The question or task the response answers.",
+			},
+			"response": map[string]any{
+				"type":        "string",
+				"description": "The response to evaluate.",
+			},
+			"criteria": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional: The criteria to score, e.g. [\"factual\", \"complete\", \"safe\"]. Defaults to those three.",
+			},
+		},
+		"required": []string{"question", "response"},
+	}
+}
+
+type EvaluateResponseArgs struct {
+	Question string   `json:"question"`
+	Response string   `json:"response"`
+	Criteria []string `json:"criteria"`
+}
+
+// CriterionScore is the grade for a single evaluation criterion.
+type CriterionScore struct {
+	Criterion string `json:"criterion"`
+	Score     int    `json:"score"`
+	Reasoning string `json:"reasoning"`
+}
+
+// Evaluation is the rubric returned by evaluate_response.
+type Evaluation struct {
+	Scores  []CriterionScore `json:"scores"`
+	Overall float64          `json:"overall"`
+}
+
+var defaultEvalCriteria = []string{"factual", "complete", "safe"}
+
+const evalPromptTemplate = `You are a strict reviewer grading an AI assistant's response.
+
+Question:
+%s
+
+Response:
+%s
+
+Score the response from 1 (very poor) to 5 (excellent) on each of these criteria: %s.
+Reply with JSON only, no Markdown, in exactly this shape:
+{"scores": [{"criterion": "<criterion>", "score": <1-5>, "reasoning": "<one sentence>"}], "overall": <1-5>}`
+
+func (t *EvaluateResponseTool) Execute(args string) (string, error) {
+	var toolArgs EvaluateResponseArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for evaluate_response: %w", err)
+	}
+
+	if toolArgs.Question == "" || toolArgs.Response == "" {
+		return "", fmt.Errorf("question and response arguments are required for evaluate_response")
+	}
+
+	evaluation, err := t.Evaluate(toolArgs.Question, toolArgs.Response, toolArgs.Criteria)
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.MarshalIndent(evaluation, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding evaluation: %w", err)
+	}
+	return string(out), nil
+}
+
+// Evaluate grades response against criteria using the injected Completer.
+func (t *EvaluateResponseTool) Evaluate(question, response string, criteria []string) (Evaluation, error) {
+	if t.completer == nil {
+		return Evaluation{}, fmt.Errorf("evaluate_response is not connected to an LLM client")
+	}
+	if len(criteria) == 0 {
+		criteria = defaultEvalCriteria
+	}
+
+	prompt := fmt.Sprintf(evalPromptTemplate, question, response, strings.Join(criteria, ", "))
+	reply, err := t.completer.Complete(prompt)
+	if err != nil {
+		return Evaluation{}, fmt.Errorf("error calling LLM for evaluation: %w", err)
+	}
+
+	// Models often wrap JSON in a Markdown code fence despite being told not to.
+	reply = strings.TrimSpace(reply)
+	reply = strings.TrimPrefix(reply, "```json")
+	reply = strings.TrimPrefix(reply, "```")
+	reply = strings.TrimSuffix(reply, "```")
+
+	var evaluation Evaluation
+	if err := json.Unmarshal([]byte(strings.TrimSpace(reply)), &evaluation); err != nil {
+		return Evaluation{}, fmt.Errorf("evaluation was not valid JSON: %w. Raw reply:\n%s", err, reply)
+	}
+
+	return evaluation, nil
+}
@@ -2,20 +2,43 @@ package tools
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"hash"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
 // --- ListDirectoryTool ---
 
 // ListDirectoryTool lists the contents of a directory.
-type ListDirectoryTool struct{}
+type ListDirectoryTool struct {
+	pathGuard
+}
+
+// maxListEntries caps the number of entries a recursive listing returns.
+const maxListEntries = 1000
 
 func (t *ListDirectoryTool) Name() string {
 	return "list_directory"
@@ -25,8 +48,12 @@ func (t *ListDirectoryTool) RequiresConfirmation() bool {
 	return false
 }
 
+func (t *ListDirectoryTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
 func (t *ListDirectoryTool) Description() string {
-	return "Lists files and subdirectories within a specified directory path. Usage: {\"path\": \"<directory_path>\"}"
+	return "Lists files and subdirectories within a specified directory path. With recursive, walks subdirectories (up to depth levels) and shows them as an indented tree. Hidden entries are skipped unless show_hidden is true. Usage: {\"path\": \"<directory_path>\", \"recursive\": false, \"depth\": 0, \"show_hidden\": false}"
 }
 
 func (t *ListDirectoryTool) Parameters() any {
@@ -35,15 +62,32 @@ func (t *ListDirectoryTool) Parameters() any {
 		"properties": map[string]any{
 			"path": map[string]any{
 				"type":        "string",
+				"minLength":   1,
 				"description": "The path to the directory to list.",
 			},
+			"recursive": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Whether to list subdirectories too. Defaults to false.",
+			},
+			"depth": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: How many levels below the path to list when recursive. Defaults to 0, meaning unlimited.",
+			},
+			"show_hidden": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Whether to include entries whose names start with a dot. Defaults to false.",
+			},
 		},
 		"required": []string{"path"},
 	}
 }
 
 type ListDirectoryArgs struct {
-	Path string `json:"path"`
+	Path       string `json:"path"`
+	Recursive  bool   `json:"recursive"`
+	Depth      int    `json:"depth"`
+	ShowHidden bool   `json:"show_hidden"`
 }
 
 func (t *ListDirectoryTool) Execute(args string) (string, error) {
@@ -57,6 +101,14 @@ func (t *ListDirectoryTool) Execute(args string) (string, error) {
 		path = "." // Default to current directory
 	}
 
+	if err := t.checkPath(path); err != nil {
+		return "", err
+	}
+
+	if toolArgs.Recursive {
+		return listRecursive(path, toolArgs.Depth, toolArgs.ShowHidden)
+	}
+
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return "", fmt.Errorf("error reading directory '%s': %w", path, err)
@@ -66,6 +118,10 @@ func (t *ListDirectoryTool) Execute(args string) (string, error) {
 	output.WriteString(fmt.Sprintf("Contents of %s:\n", path))
 
 	for _, entry := range entries {
+		if !toolArgs.ShowHidden && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
 		info, err := entry.Info()
 		if err != nil {
 			continue // Skip files we can't get info for
@@ -87,10 +143,231 @@ func (t *ListDirectoryTool) Execute(args string) (string, error) {
 	return output.String(), nil
 }
 
+// listRecursive walks root and lists every entry indented by its depth, stopping after
+// maxListEntries. A depth of 0 or less means no limit.
+func listRecursive(root string, depth int, showHidden bool) (string, error) {
+	if _, err := os.Stat(root); err != nil {
+		return "", fmt.Errorf("error reading directory '%s': %w", root, err)
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Contents of %s:\n", root))
+
+	count := 0
+	truncated := false
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if path == root {
+			return err
+		}
+		if err != nil {
+			return nil // Skip entries we can't read
+		}
+		if !showHidden && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if count >= maxListEntries {
+			truncated = true
+			return filepath.SkipAll
+		}
+		count++
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		level := strings.Count(rel, string(filepath.Separator))
+		indent := strings.Repeat("  ", level)
+
+		if d.IsDir() {
+			output.WriteString(indent + d.Name() + "/\n")
+			if depth > 0 && level+1 >= depth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		line := indent + d.Name()
+		if info, err := d.Info(); err == nil {
+			line += fmt.Sprintf(" (%s)", formatSize(info.Size()))
+		}
+		output.WriteString(line + "\n")
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error walking directory '%s': %w", root, err)
+	}
+
+	if truncated {
+		output.WriteString(fmt.Sprintf("... output truncated at %d entries; use a smaller depth or a subdirectory\n", maxListEntries))
+	}
+	return output.String(), nil
+}
+
+// --- TreeTool ---
+
+// TreeTool renders a directory hierarchy like the `tree` command.
+type TreeTool struct {
+	pathGuard
+}
+
+const (
+	defaultTreeDepth = 3
+	maxTreeLines     = 500
+)
+
+func (t *TreeTool) Name() string {
+	return "tree"
+}
+
+func (t *TreeTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *TreeTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *TreeTool) Description() string {
+	return "Shows the directory structure recursively as a tree with file sizes, like the `tree` command. Hidden entries are skipped unless show_hidden is true. Usage: {\"path\": \"<directory_path>\", \"max_depth\": 3}"
+}
+
+func (t *TreeTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The root directory of the tree.",
+			},
+			"max_depth": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "How many levels below the root to show. Defaults to 3.",
+			},
+			"show_hidden": map[string]any{
+				"type":        "boolean",
+				"description": "Whether to include entries whose names start with a dot. Defaults to false.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type TreeArgs struct {
+	Path       string `json:"path"`
+	MaxDepth   int    `json:"max_depth"`
+	ShowHidden bool   `json:"show_hidden"`
+}
+
+func (t *TreeTool) Execute(args string) (string, error) {
+	var toolArgs TreeArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for tree: %w. Expected JSON: {\"path\": \"...\", \"max_depth\": 3}", err)
+	}
+
+	path := toolArgs.Path
+	if path == "" {
+		path = "."
+	}
+
+	if err := t.checkPath(path); err != nil {
+		return "", err
+	}
+
+	depth := toolArgs.MaxDepth
+	if depth <= 0 {
+		depth = defaultTreeDepth
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading directory '%s': %w", path, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("'%s' is not a directory", path)
+	}
+
+	lines := []string{path}
+	truncated := writeTree(path, "", depth, toolArgs.ShowHidden, &lines)
+
+	output := strings.Join(lines, "\n")
+	if truncated {
+		output += fmt.Sprintf("\n... output truncated at %d lines; use a smaller max_depth or a subdirectory", maxTreeLines)
+	}
+	return output, nil
+}
+
+// writeTree appends the entries below dir to lines, prefixing each with the branches
+// of its ancestors. It reports whether the output was cut off at maxTreeLines.
+func writeTree(dir, prefix string, depth int, showHidden bool, lines *[]string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		*lines = append(*lines, prefix+"└── [error: "+err.Error()+"]")
+		return false
+	}
+
+	if !showHidden {
+		visible := entries[:0]
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), ".") {
+				visible = append(visible, entry)
+			}
+		}
+		entries = visible
+	}
+
+	for i, entry := range entries {
+		if len(*lines) >= maxTreeLines {
+			return true
+		}
+
+		branch, indent := "├── ", "│   "
+		if i == len(entries)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		if entry.IsDir() {
+			*lines = append(*lines, prefix+branch+entry.Name()+"/")
+			if depth > 1 && writeTree(filepath.Join(dir, entry.Name()), prefix+indent, depth-1, showHidden, lines) {
+				return true
+			}
+			continue
+		}
+
+		line := prefix + branch + entry.Name()
+		if info, err := entry.Info(); err == nil {
+			line += fmt.Sprintf(" (%s)", formatSize(info.Size()))
+		}
+		*lines = append(*lines, line)
+	}
+	return false
+}
+
+// formatSize renders a byte count with a binary unit, e.g. 1.5K.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
 // --- ReadFileTool ---
 
 // ReadFileTool reads the content of a file.
-type ReadFileTool struct{}
+type ReadFileTool struct {
+	pathGuard
+}
 
 func (t *ReadFileTool) Name() string {
 	return "read_file"
@@ -100,8 +377,12 @@ func (t *ReadFileTool) RequiresConfirmation() bool {
 	return false
 }
 
+func (t *ReadFileTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
 func (t *ReadFileTool) Description() string {
-	return "Reads the entire content of a specified file. Usage: {\"path\": \"<file_path>\"}"
+	return "Reads the entire content of a specified file. Files larger than size_threshold bytes (default 50000) return a summary instead: size, line count, and top-level declarations for Go files or the first and last lines otherwise. Set start_line and/or end_line (1-based, inclusive) to read only part of a file. Usage: {\"path\": \"<file_path>\", \"start_line\": 120, \"end_line\": 140, \"force\": false}"
 }
 
 func (t *ReadFileTool) Parameters() any {
@@ -110,17 +391,44 @@ func (t *ReadFileTool) Parameters() any {
 		"properties": map[string]any{
 			"path": map[string]any{
 				"type":        "string",
+				"minLength":   1,
 				"description": "The path to the file to read.",
 			},
+			"size_threshold": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: Files larger than this many bytes are summarized instead of returned in full. Defaults to 50000.",
+			},
+			"force": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Return the full content even if the file exceeds size_threshold.",
+			},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: The first line to return (1-based). Defaults to the first line when only end_line is set.",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: The last line to return (inclusive). Defaults to the last line when only start_line is set.",
+			},
 		},
 		"required": []string{"path"},
 	}
 }
 
 type ReadFileArgs struct {
-	Path string `json:"path"`
+	Path          string `json:"path"`
+	SizeThreshold int    `json:"size_threshold,omitempty"`
+	Force         bool   `json:"force,omitempty"`
+	StartLine     int    `json:"start_line,omitempty"`
+	EndLine       int    `json:"end_line,omitempty"`
 }
 
+// defaultReadSizeThreshold is the file size above which read_file returns a summary.
+const defaultReadSizeThreshold = 50000
+
 func (t *ReadFileTool) Execute(args string) (string, error) {
 	var toolArgs ReadFileArgs
 	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
@@ -131,18 +439,142 @@ func (t *ReadFileTool) Execute(args string) (string, error) {
 		return "", fmt.Errorf("path argument is required for read_file")
 	}
 
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	if toolArgs.StartLine != 0 || toolArgs.EndLine != 0 {
+		return readLineRange(toolArgs.Path, toolArgs.StartLine, toolArgs.EndLine)
+	}
+
 	content, err := os.ReadFile(toolArgs.Path)
 	if err != nil {
 		return "", fmt.Errorf("error reading file '%s': %w", toolArgs.Path, err)
 	}
 
+	threshold := toolArgs.SizeThreshold
+	if threshold <= 0 {
+		threshold = defaultReadSizeThreshold
+	}
+	if len(content) > threshold && !toolArgs.Force {
+		return summarizeLargeFile(toolArgs.Path, content, threshold), nil
+	}
+
 	return string(content), nil
 }
 
+// readLineRange returns lines start through end (1-based, inclusive) of a file, prefixed with
+// a comment giving the range. A zero start or end means the first or last line respectively.
+func readLineRange(path string, start, end int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading file '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	var selected []string
+	total := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // Allow long lines, e.g. minified files
+	for scanner.Scan() {
+		total++
+		if total >= start && (end == 0 || total <= end) {
+			selected = append(selected, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("error reading file '%s': %w", path, err)
+	}
+
+	if start == 0 {
+		start = 1
+	}
+	if end == 0 {
+		end = total
+	}
+	switch {
+	case start > end:
+		return "", fmt.Errorf("start_line %d is after end_line %d ('%s' has %d lines)", start, end, path, total)
+	case start < 1 || end > total:
+		return "", fmt.Errorf("line range %d-%d is out of bounds ('%s' has %d lines)", start, end, path, total)
+	}
+
+	return fmt.Sprintf("// %s lines %d-%d\n%s", path, start, end, strings.Join(selected, "\n")), nil
+}
+
+// summarizeLargeFile describes a file that is too large to return in full.
+// Go files are summarized by their top-level declarations, other files by their first and last lines.
+func summarizeLargeFile(path string, content []byte, threshold int) string {
+	lines := strings.Split(string(content), "\n")
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s is %d bytes (%d lines), which exceeds the %d byte threshold. Showing a summary; pass \"start_line\"/\"end_line\" to read part of it or \"force\": true to read the full content.\n\n", path, len(content), len(lines), threshold))
+
+	if strings.HasSuffix(path, ".go") {
+		if decls, err := goDeclarations(path, content); err == nil {
+			b.WriteString("Top-level declarations:\n")
+			b.WriteString(decls)
+			return b.String()
+		}
+	}
+
+	const headLines = 50
+	const tailLines = 20
+	if len(lines) <= headLines+tailLines {
+		b.WriteString(string(content))
+		return b.String()
+	}
+
+	b.WriteString(strings.Join(lines[:headLines], "\n"))
+	b.WriteString(fmt.Sprintf("\n... (%d lines omitted) ...\n", len(lines)-headLines-tailLines))
+	b.WriteString(strings.Join(lines[len(lines)-tailLines:], "\n"))
+	return b.String()
+}
+
+// goDeclarations lists the top-level declarations of a Go source file with their line numbers.
+func goDeclarations(path string, content []byte) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, content, parser.SkipObjectResolution)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, decl := range file.Decls {
+		line := fset.Position(decl.Pos()).Line
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = "(" + types.ExprString(d.Recv.List[0].Type) + ") " + name
+			}
+			b.WriteString(fmt.Sprintf("%6d  func %s\n", line, name))
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			for _, spec := range d.Specs {
+				specLine := fset.Position(spec.Pos()).Line
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					b.WriteString(fmt.Sprintf("%6d  type %s\n", specLine, sp.Name.Name))
+				case *ast.ValueSpec:
+					for _, name := range sp.Names {
+						b.WriteString(fmt.Sprintf("%6d  %s %s\n", specLine, d.Tok, name.Name))
+					}
+				}
+			}
+		}
+	}
+	return b.String(), nil
+}
+
 // --- WriteFileTool ---
 
 // WriteFileTool writes content to a specified file.
-type WriteFileTool struct{}
+type WriteFileTool struct {
+	pathGuard
+}
 
 func (t *WriteFileTool) Name() string {
 	return "write_file"
@@ -152,6 +584,10 @@ func (t *WriteFileTool) RequiresConfirmation() bool {
 	return true
 }
 
+func (t *WriteFileTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
 func (t *WriteFileTool) Description() string {
 	return "Writes content to a specified file, creating the file if it doesn't exist or overwriting it if it does. Usage: {\"path\": \"<file_path>\", \"content\": \"<content_to_write>\"}"
 }
@@ -162,6 +598,7 @@ func (t *WriteFileTool) Parameters() any {
 		"properties": map[string]any{
 			"path": map[string]any{
 				"type":        "string",
+				"minLength":   1,
 				"description": "The path to the file to write to.",
 			},
 			"content": map[string]any{
@@ -188,137 +625,463 @@ func (t *WriteFileTool) Execute(args string) (string, error) {
 		return "", fmt.Errorf("path argument is required for write_file")
 	}
 
-	// The permissions 0644 are standard for text files.
-	err := os.WriteFile(toolArgs.Path, []byte(toolArgs.Content), 0644)
-	if err != nil {
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	if err := writeFileAtomic(toolArgs.Path, []byte(toolArgs.Content)); err != nil {
 		return "", fmt.Errorf("error writing to file '%s': %w", toolArgs.Path, err)
 	}
 
 	return fmt.Sprintf("Successfully wrote %d bytes to %s", len(toolArgs.Content), toolArgs.Path), nil
 }
 
-// --- SearchFileContentTool ---
+// writeFileAtomic writes data to a temporary file next to path and renames it over path,
+// so an interrupted write never leaves a partially written file behind. An existing file
+// keeps its permissions; new files get 0644, which is standard for text files.
+func writeFileAtomic(path string, data []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
 
-// SearchFileContentTool searches for a pattern in files within a directory.
-type SearchFileContentTool struct{}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tachigoma_tmp_"+filepath.Base(path)+"*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // Fails harmlessly once the file has been renamed
 
-func (t *SearchFileContentTool) Name() string {
-	return "search_file_content"
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
 }
 
-func (t *SearchFileContentTool) RequiresConfirmation() bool {
-	return false
+// --- AppendFileTool ---
+
+// AppendFileTool appends content to the end of a file, creating the file if it doesn't exist.
+// Appending cannot lose existing content, so it runs without confirmation unless
+// RequireConfirmation is set.
+type AppendFileTool struct {
+	pathGuard
+
+	RequireConfirmation bool
 }
 
-func (t *SearchFileContentTool) Description() string {
-	return "Recursively searches for a regular expression pattern in files within a directory. Usage: {\"path\": \"<directory_path>\", \"pattern\": \"<regex_pattern>\"}"
+func (t *AppendFileTool) Name() string {
+	return "append_file"
 }
 
-func (t *SearchFileContentTool) Parameters() any {
+func (t *AppendFileTool) RequiresConfirmation() bool {
+	return t.RequireConfirmation
+}
+
+func (t *AppendFileTool) ModifiesFiles() bool {
+	return true
+}
+
+func (t *AppendFileTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *AppendFileTool) Description() string {
+	return "Appends content to the end of a file, creating the file if it doesn't exist. Use this instead of write_file to add lines to an existing file without rewriting it. Usage: {\"path\": \"<file_path>\", \"content\": \"<content_to_append>\"}"
+}
+
+func (t *AppendFileTool) Parameters() any {
 	return map[string]any{
 		"type": "object",
 		"properties": map[string]any{
 			"path": map[string]any{
 				"type":        "string",
-				"description": "The directory path to start searching from.",
+				"minLength":   1,
+				"description": "The path to the file to append to.",
 			},
-			"pattern": map[string]any{
+			"content": map[string]any{
 				"type":        "string",
-				"description": "The regular expression pattern to search for.",
+				"description": "The content to append. Include a trailing newline if the file should end with one.",
 			},
 		},
-		"required": []string{"path", "pattern"},
+		"required": []string{"path", "content"},
 	}
 }
 
-type SearchFileContentArgs struct {
+type AppendFileArgs struct {
 	Path    string `json:"path"`
-	Pattern string `json:"pattern"`
+	Content string `json:"content"`
 }
 
-func (t *SearchFileContentTool) Execute(args string) (string, error) {
-	var toolArgs SearchFileContentArgs
+func (t *AppendFileTool) Execute(args string) (string, error) {
+	var toolArgs AppendFileArgs
 	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
-		return "", fmt.Errorf("invalid arguments for search_file_content: %w", err)
+		return "", fmt.Errorf("invalid arguments for append_file: %w", err)
 	}
 
-	if toolArgs.Path == "" || toolArgs.Pattern == "" {
-		return "", fmt.Errorf("path and pattern arguments are required for search_file_content")
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for append_file")
 	}
 
-	regex, err := regexp.Compile(toolArgs.Pattern)
-	if err != nil {
-		return "", fmt.Errorf("invalid regex pattern: %w", err)
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
 	}
 
-	var results strings.Builder
-	var matchesFound int
-
-	err = filepath.WalkDir(toolArgs.Path, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err // Propagate errors from WalkDir
-		}
-		if !d.IsDir() {
-			file, err := os.Open(path)
-			if err != nil {
-				// Can't open, just log it and continue
-				results.WriteString(fmt.Sprintf("Could not open file %s: %v\n", path, err))
-				return nil
-			}
-			defer file.Close()
-
-			scanner := bufio.NewScanner(file)
-			lineNumber := 1
-			for scanner.Scan() {
-				if regex.MatchString(scanner.Text()) {
-					matchesFound++
-					results.WriteString(fmt.Sprintf("%s:%d: %s\n", path, lineNumber, scanner.Text()))
-				}
-				lineNumber++
-			}
-		}
-		return nil
-	})
-
+	f, err := os.OpenFile(toolArgs.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return "", fmt.Errorf("error walking directory '%s': %w", toolArgs.Path, err)
+		return "", fmt.Errorf("error opening file '%s': %w", toolArgs.Path, err)
+	}
+	n, err := f.WriteString(toolArgs.Content)
+	if err != nil {
+		f.Close()
+		return "", fmt.Errorf("error appending to file '%s': %w", toolArgs.Path, err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("error closing file '%s': %w", toolArgs.Path, err)
 	}
 
-	if matchesFound == 0 {
-		return "No matches found.", nil
+	info, err := os.Stat(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading size of file '%s': %w", toolArgs.Path, err)
 	}
 
-	return results.String(), nil
+	return fmt.Sprintf("Successfully appended %d bytes to %s. The file is now %d bytes.", n, toolArgs.Path, info.Size()), nil
 }
 
-// --- GlobTool ---
+// --- SearchFileContentTool ---
 
-// GlobTool finds files matching a glob pattern.
-type GlobTool struct{}
+// SearchFileContentTool searches for a pattern in files within a directory.
+type SearchFileContentTool struct {
+	pathGuard
+}
 
-func (t *GlobTool) Name() string {
-	return "glob"
+func (t *SearchFileContentTool) Name() string {
+	return "search_file_content"
 }
 
-func (t *GlobTool) RequiresConfirmation() bool {
+func (t *SearchFileContentTool) RequiresConfirmation() bool {
 	return false
 }
 
-func (t *GlobTool) Description() string {
-	return "Finds files and directories matching a specified glob pattern within a given path. Usage: {\"pattern\": \"<glob_pattern>\", \"path\": \"<base_directory>\"}"
+func (t *SearchFileContentTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
 }
 
-func (t *GlobTool) Parameters() any {
+func (t *SearchFileContentTool) Description() string {
+	return "Recursively searches for a regular expression pattern in files within a directory. Set context_lines to also show the lines around each match, like grep -C. Binary files, images and files over 1 MB are skipped; use include_patterns, exclude_patterns and max_file_size_bytes to change which files are searched. Usage: {\"path\": \"<directory_path>\", \"pattern\": \"<regex_pattern>\", \"context_lines\": 2, \"include_patterns\": [\"*.go\"]}"
+}
+
+func (t *SearchFileContentTool) Parameters() any {
 	return map[string]any{
 		"type": "object",
 		"properties": map[string]any{
-			"pattern": map[string]any{
-				"type":        "string",
-				"description": "The glob pattern to match files against (e.g., \"internal/**/*.go\").",
-			},
 			"path": map[string]any{
 				"type":        "string",
-				"description": "Optional: The base directory to start the glob search from. Defaults to the current working directory if not provided.",
+				"minLength":   1,
+				"description": "The directory path to start searching from.",
+			},
+			"pattern": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The regular expression pattern to search for.",
+			},
+			"context_lines": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: The number of lines to show before and after each match. Defaults to 0.",
+			},
+			"case_insensitive": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Whether to ignore case when matching. Defaults to false.",
+			},
+			"include_patterns": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional: Only search files whose name or relative path matches one of these glob patterns, e.g. [\"*.go\", \"docs/**\"].",
+			},
+			"exclude_patterns": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional: Skip files whose name or relative path matches one of these glob patterns. Replaces the default list of image and binary extensions.",
+			},
+			"max_file_size_bytes": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: Skip files larger than this many bytes. Defaults to 1 MB.",
+			},
+		},
+		"required": []string{"path", "pattern"},
+	}
+}
+
+type SearchFileContentArgs struct {
+	Path             string   `json:"path"`
+	Pattern          string   `json:"pattern"`
+	ContextLines     int      `json:"context_lines"`
+	CaseInsensitive  bool     `json:"case_insensitive"`
+	IncludePatterns  []string `json:"include_patterns,omitempty"`
+	ExcludePatterns  []string `json:"exclude_patterns,omitempty"` // Nil uses defaultSearchExcludes
+	MaxFileSizeBytes int64    `json:"max_file_size_bytes,omitempty"`
+}
+
+// defaultSearchExcludes are the file patterns search_file_content skips unless exclude_patterns is given.
+var defaultSearchExcludes = []string{"*.png", "*.jpg", "*.gif", "*.exe", "*.bin", "*.so", "*.dylib"}
+
+// defaultSearchMaxFileSize is the size above which search_file_content skips a file.
+const defaultSearchMaxFileSize = 1024 * 1024
+
+// binarySniffLen is how much of a file is checked for NUL bytes to detect binary content.
+const binarySniffLen = 8000
+
+// searchSkips counts the files search_file_content did not search, by reason. Files left
+// out by include_patterns were not asked for, so they are not counted.
+type searchSkips struct {
+	excluded, tooLarge, binary int
+}
+
+func (s searchSkips) String() string {
+	var reasons []string
+	for _, r := range []struct {
+		n      int
+		reason string
+	}{
+		{s.excluded, "matching exclude_patterns"},
+		{s.tooLarge, "too large"},
+		{s.binary, "binary"},
+	} {
+		if r.n > 0 {
+			reasons = append(reasons, fmt.Sprintf("%d %s", r.n, r.reason))
+		}
+	}
+	if len(reasons) == 0 {
+		return ""
+	}
+	return "Skipped files: " + strings.Join(reasons, ", ") + "."
+}
+
+// matchesAnyPattern reports whether the base name or slash-separated relative path of a
+// file matches one of the glob patterns.
+func matchesAnyPattern(patterns []string, relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	name := filepath.Base(relPath)
+	for _, pattern := range patterns {
+		if ok, _ := doublestar.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := doublestar.Match(pattern, relPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// maxSearchGroups caps the number of match groups returned by search_file_content.
+// Without context lines every match is its own group.
+const maxSearchGroups = 200
+
+func (t *SearchFileContentTool) Execute(args string) (string, error) {
+	var toolArgs SearchFileContentArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for search_file_content: %w", err)
+	}
+
+	if toolArgs.Path == "" || toolArgs.Pattern == "" {
+		return "", fmt.Errorf("path and pattern arguments are required for search_file_content")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	pattern := toolArgs.Pattern
+	if toolArgs.CaseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex pattern: %w", err)
+	}
+	contextLines := max(toolArgs.ContextLines, 0)
+
+	excludes := toolArgs.ExcludePatterns
+	if excludes == nil {
+		excludes = defaultSearchExcludes
+	}
+	maxSize := toolArgs.MaxFileSizeBytes
+	if maxSize <= 0 {
+		maxSize = defaultSearchMaxFileSize
+	}
+
+	var results strings.Builder
+	var groupsFound int
+	var skipped searchSkips
+	truncated := false
+
+	err = filepath.WalkDir(toolArgs.Path, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err // Propagate errors from WalkDir
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(toolArgs.Path, path)
+		if err != nil {
+			rel = path
+		}
+		if len(toolArgs.IncludePatterns) > 0 && !matchesAnyPattern(toolArgs.IncludePatterns, rel) {
+			return nil
+		}
+		if matchesAnyPattern(excludes, rel) {
+			skipped.excluded++
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.Size() > maxSize {
+			skipped.tooLarge++
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			// Can't open, just log it and continue
+			results.WriteString(fmt.Sprintf("Could not open file %s: %v\n", path, err))
+			return nil
+		}
+		defer file.Close()
+
+		reader := bufio.NewReader(file)
+		if head, _ := reader.Peek(binarySniffLen); bytes.IndexByte(head, 0) >= 0 {
+			skipped.binary++
+			return nil
+		}
+
+		var lines []string
+		var matches []int
+		scanner := bufio.NewScanner(reader)
+		scanner.Buffer(make([]byte, 0, 64*1024), int(maxSize)+1) // Allow lines as long as the file
+		for scanner.Scan() {
+			if regex.MatchString(scanner.Text()) {
+				matches = append(matches, len(lines))
+			}
+			lines = append(lines, scanner.Text())
+		}
+
+		for i := 0; i < len(matches); {
+			if groupsFound == maxSearchGroups {
+				truncated = true
+				return filepath.SkipAll
+			}
+
+			// Extend the group while the next match's context touches it.
+			start := max(matches[i]-contextLines, 0)
+			end := min(matches[i]+contextLines, len(lines)-1)
+			i++
+			for i < len(matches) && matches[i]-contextLines <= end+1 {
+				end = min(matches[i]+contextLines, len(lines)-1)
+				i++
+			}
+
+			if contextLines > 0 && groupsFound > 0 {
+				results.WriteString("--\n")
+			}
+			groupsFound++
+
+			for n := start; n <= end; n++ {
+				// Like grep, match lines use ':' and context lines '-'.
+				sep := "-"
+				if regex.MatchString(lines[n]) {
+					sep = ":"
+				}
+				results.WriteString(fmt.Sprintf("%s%s%d%s %s\n", path, sep, n+1, sep, lines[n]))
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return "", fmt.Errorf("error walking directory '%s': %w", toolArgs.Path, err)
+	}
+
+	if groupsFound == 0 {
+		if note := skipped.String(); note != "" {
+			return "No matches found. " + note, nil
+		}
+		return "No matches found.", nil
+	}
+
+	if truncated {
+		results.WriteString(fmt.Sprintf("... results truncated after %d match groups; narrow the path or pattern to see more\n", maxSearchGroups))
+	}
+	if note := skipped.String(); note != "" {
+		results.WriteString(note + "\n")
+	}
+	return results.String(), nil
+}
+
+// --- GlobTool ---
+
+// GlobTool finds files matching a glob pattern.
+type GlobTool struct {
+	pathGuard
+}
+
+func (t *GlobTool) Name() string {
+	return "glob"
+}
+
+func (t *GlobTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *GlobTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *GlobTool) Description() string {
+	return "Finds files matching a specified glob pattern within a given path, optionally filtered by extension and size and sorted by name, size or modification time. Ends with the number of files found and their total size. Usage: {\"pattern\": \"<glob_pattern>\", \"path\": \"<base_directory>\", \"extensions\": [\".go\"], \"max_size_bytes\": 0, \"sort_by\": \"name\", \"sort_desc\": false}"
+}
+
+func (t *GlobTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pattern": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The glob pattern to match files against (e.g., \"internal/**/*.go\").",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional: The base directory to start the glob search from. Defaults to the current working directory if not provided.",
+			},
+			"extensions": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional: Only return files with one of these extensions, e.g. [\".go\", \".md\"].",
+			},
+			"max_size_bytes": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: Skip files larger than this many bytes. 0 means no limit.",
+			},
+			"sort_by": map[string]any{
+				"type":        "string",
+				"enum":        []string{"name", "size", "mtime"},
+				"description": "Optional: Sort the files by path, size or modification time. Defaults to name.",
+			},
+			"sort_desc": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Sort in descending order. Defaults to false.",
 			},
 		},
 		"required": []string{"pattern"},
@@ -326,8 +1089,19 @@ func (t *GlobTool) Parameters() any {
 }
 
 type GlobArgs struct {
-	Pattern string `json:"pattern"`
-	Path    string `json:"path"`
+	Pattern      string   `json:"pattern"`
+	Path         string   `json:"path"`
+	Extensions   []string `json:"extensions,omitempty"`
+	MaxSizeBytes int64    `json:"max_size_bytes,omitempty"`
+	SortBy       string   `json:"sort_by,omitempty"`
+	SortDesc     bool     `json:"sort_desc,omitempty"`
+}
+
+// globMatch is a file found by the glob tool.
+type globMatch struct {
+	path    string
+	size    int64
+	modTime time.Time
 }
 
 func (t *GlobTool) Execute(args string) (string, error) {
@@ -345,7 +1119,19 @@ func (t *GlobTool) Execute(args string) (string, error) {
 		basePath = "."
 	}
 
-	var matches []string
+	if err := t.checkPath(basePath); err != nil {
+		return "", err
+	}
+
+	extensions := make(map[string]bool, len(toolArgs.Extensions))
+	for _, ext := range toolArgs.Extensions {
+		if ext != "" && !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions[strings.ToLower(ext)] = true
+	}
+
+	var matches []globMatch
 	err := filepath.WalkDir(basePath, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -367,10 +1153,21 @@ func (t *GlobTool) Execute(args string) (string, error) {
 			return fmt.Errorf("invalid glob pattern %s: %w", toolArgs.Pattern, err)
 		}
 
-		if matched {
-			matches = append(matches, path)
+		if !matched {
+			return nil
+		}
+		if len(extensions) > 0 && !extensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
 		}
 
+		info, err := d.Info()
+		if err != nil {
+			return nil // The file disappeared during the walk
+		}
+		if toolArgs.MaxSizeBytes > 0 && info.Size() > toolArgs.MaxSizeBytes {
+			return nil
+		}
+		matches = append(matches, globMatch{path: path, size: info.Size(), modTime: info.ModTime()})
 		return nil
 	})
 
@@ -382,13 +1179,43 @@ func (t *GlobTool) Execute(args string) (string, error) {
 		return "No files matched the pattern.", nil
 	}
 
-	return strings.Join(matches, "\n"), nil
+	sortGlobMatches(matches, toolArgs.SortBy, toolArgs.SortDesc)
+
+	var b strings.Builder
+	var total int64
+	for _, match := range matches {
+		b.WriteString(match.path + "\n")
+		total += match.size
+	}
+	fmt.Fprintf(&b, "\nFound %d files, total %d bytes.", len(matches), total)
+	return b.String(), nil
+}
+
+// sortGlobMatches orders matches by "name", "size" or "mtime"; anything else sorts by name.
+// Ties keep the path order.
+func sortGlobMatches(matches []globMatch, sortBy string, desc bool) {
+	less := func(a, b globMatch) bool { return a.path < b.path }
+	switch sortBy {
+	case "size":
+		less = func(a, b globMatch) bool { return a.size < b.size }
+	case "mtime":
+		less = func(a, b globMatch) bool { return a.modTime.Before(b.modTime) }
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if desc {
+			return less(matches[j], matches[i])
+		}
+		return less(matches[i], matches[j])
+	})
 }
 
 // --- ReplaceTool ---
 
 // ReplaceTool replaces the first occurrence of a string in a file.
-type ReplaceTool struct{}
+type ReplaceTool struct {
+	pathGuard
+}
 
 func (t *ReplaceTool) Name() string {
 	return "replace"
@@ -398,8 +1225,12 @@ func (t *ReplaceTool) RequiresConfirmation() bool {
 	return true // Requires user confirmation as it modifies a file
 }
 
+func (t *ReplaceTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
 func (t *ReplaceTool) Description() string {
-	return "Replaces the first occurrence of a specified old string with a new string in a file. Usage: {\"path\": \"<file_path>\", \"old_string\": \"<string_to_find>\", \"new_string\": \"<string_to_replace_with>\"}"
+	return "Replaces the first occurrence of a specified old string with a new string in a file, or every occurrence when replace_all is true. Usage: {\"path\": \"<file_path>\", \"old_string\": \"<string_to_find>\", \"new_string\": \"<string_to_replace_with>\", \"replace_all\": false}"
 }
 
 func (t *ReplaceTool) Parameters() any {
@@ -408,6 +1239,7 @@ func (t *ReplaceTool) Parameters() any {
 		"properties": map[string]any{
 			"path": map[string]any{
 				"type":        "string",
+				"minLength":   1,
 				"description": "The path to the file to modify.",
 			},
 			"old_string": map[string]any{
@@ -418,15 +1250,20 @@ func (t *ReplaceTool) Parameters() any {
 				"type":        "string",
 				"description": "The string to replace the old string with.",
 			},
+			"replace_all": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Replace every occurrence instead of only the first. Defaults to false.",
+			},
 		},
 		"required": []string{"path", "old_string", "new_string"},
 	}
 }
 
 type ReplaceArgs struct {
-	Path      string `json:"path"`
-	OldString string `json:"old_string"`
-	NewString string `json:"new_string"`
+	Path       string `json:"path"`
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
 }
 
 func (t *ReplaceTool) Execute(args string) (string, error) {
@@ -435,10 +1272,18 @@ func (t *ReplaceTool) Execute(args string) (string, error) {
 		return "", fmt.Errorf("invalid arguments for replace: %w", err)
 	}
 
-	if toolArgs.Path == "" || toolArgs.OldString == "" || toolArgs.NewString == "" {
+	if toolArgs.OldString == "" {
+		// An empty old_string matches between every character, so it is never what the model meant.
+		return "", fmt.Errorf("old_string must not be empty for replace")
+	}
+	if toolArgs.Path == "" || toolArgs.NewString == "" {
 		return "", fmt.Errorf("path, old_string, and new_string arguments are required for replace")
 	}
 
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
 	// Read the file content
 	contentBytes, err := os.ReadFile(toolArgs.Path)
 	if err != nil {
@@ -446,19 +1291,1788 @@ func (t *ReplaceTool) Execute(args string) (string, error) {
 	}
 	content := string(contentBytes)
 
-	// Find and replace the first occurrence
-	index := strings.Index(content, toolArgs.OldString)
-	if index == -1 {
+	count := strings.Count(content, toolArgs.OldString)
+	if count == 0 {
 		return "", fmt.Errorf("old_string not found in file '%s'", toolArgs.Path)
 	}
 
-	modifiedContent := content[:index] + toolArgs.NewString + content[index+len(toolArgs.OldString):]
+	var modifiedContent string
+	if toolArgs.ReplaceAll {
+		modifiedContent = strings.ReplaceAll(content, toolArgs.OldString, toolArgs.NewString)
+	} else {
+		// Find and replace the first occurrence
+		modifiedContent = strings.Replace(content, toolArgs.OldString, toolArgs.NewString, 1)
+	}
+
+	if err := writeFileAtomic(toolArgs.Path, []byte(modifiedContent)); err != nil {
+		return "", fmt.Errorf("error writing to file '%s': %w", toolArgs.Path, err)
+	}
+
+	if toolArgs.ReplaceAll {
+		return fmt.Sprintf("Replaced %d occurrences in %s", count, toolArgs.Path), nil
+	}
+	return fmt.Sprintf("Replaced 1 occurrence in %s (%d total in the file; set replace_all to replace every one)", toolArgs.Path, count), nil
+}
+
+// --- InsertLinesTool ---
+
+// InsertLinesTool inserts text before or after a given line of a file.
+type InsertLinesTool struct {
+	pathGuard
+}
+
+func (t *InsertLinesTool) Name() string {
+	return "insert_lines"
+}
+
+func (t *InsertLinesTool) RequiresConfirmation() bool {
+	return true // Requires user confirmation as it modifies a file
+}
+
+func (t *InsertLinesTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *InsertLinesTool) Description() string {
+	return "Inserts content as new lines before the given 1-based line of a file, or after it when after is true. Line 0 inserts at the start of the file and a line past the end appends. Returns the new line count. Prefer this over replace when the surrounding text is ambiguous. Usage: {\"path\": \"<file_path>\", \"line\": 42, \"content\": \"<lines_to_insert>\", \"after\": true}"
+}
+
+func (t *InsertLinesTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the file to modify.",
+			},
+			"line": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "The 1-based line number to insert at. 0 inserts at the start; a number past the last line appends.",
+			},
+			"content": map[string]any{
+				"type":        "string",
+				"description": "The text to insert. A trailing newline is optional.",
+			},
+			"after": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Insert after the line instead of before it. Defaults to false.",
+			},
+		},
+		"required": []string{"path", "line", "content"},
+	}
+}
+
+type InsertLinesArgs struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Content string `json:"content"`
+	After   bool   `json:"after"`
+}
+
+// InsertLines returns content with text inserted as whole lines before the 1-based line,
+// or after it if after is true, and the resulting number of lines. Line 0 inserts at the
+// start and lines past the end append.
+func InsertLines(content string, line int, text string, after bool) (string, int) {
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+	inserted := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+
+	index := line - 1
+	if after {
+		index = line
+	}
+	index = max(0, min(index, len(lines)))
+
+	result := slices.Concat(lines[:index], inserted, lines[index:])
+	// Keep a missing final newline missing, unless the insertion became the last line
+	output := strings.Join(result, "\n")
+	if content == "" || strings.HasSuffix(content, "\n") || index == len(lines) {
+		output += "\n"
+	}
+	return output, len(result)
+}
+
+func (t *InsertLinesTool) Execute(args string) (string, error) {
+	var toolArgs InsertLinesArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for insert_lines: %w. Expected JSON: {\"path\": \"...\", \"line\": 1, \"content\": \"...\"}", err)
+	}
+
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for insert_lines")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
 
-	// Write the modified content back to the file
-	err = os.WriteFile(toolArgs.Path, []byte(modifiedContent), 0644)
+	contentBytes, err := os.ReadFile(toolArgs.Path)
 	if err != nil {
+		return "", fmt.Errorf("error reading file '%s': %w", toolArgs.Path, err)
+	}
+
+	modified, total := InsertLines(string(contentBytes), toolArgs.Line, toolArgs.Content, toolArgs.After)
+	if err := writeFileAtomic(toolArgs.Path, []byte(modified)); err != nil {
 		return "", fmt.Errorf("error writing to file '%s': %w", toolArgs.Path, err)
 	}
 
-	return fmt.Sprintf("Successfully replaced first occurrence of string in %s", toolArgs.Path), nil
+	count := strings.Count(strings.TrimSuffix(toolArgs.Content, "\n"), "\n") + 1
+	return fmt.Sprintf("Inserted %d line(s) into %s, which now has %d lines.", count, toolArgs.Path, total), nil
+}
+
+// --- DeleteLinesTool ---
+
+// DeleteLinesTool removes a range of lines from a file.
+type DeleteLinesTool struct {
+	pathGuard
+}
+
+// maxDeletedLinesShown caps the removed lines echoed in the result of delete_lines.
+const maxDeletedLinesShown = 30
+
+func (t *DeleteLinesTool) Name() string {
+	return "delete_lines"
+}
+
+func (t *DeleteLinesTool) RequiresConfirmation() bool {
+	return true // Requires user confirmation as it modifies a file
+}
+
+func (t *DeleteLinesTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *DeleteLinesTool) Description() string {
+	return "Deletes lines start_line through end_line (1-based, inclusive) from a file and shows the removed lines so you can check they were the right ones. Usage: {\"path\": \"<file_path>\", \"start_line\": 10, \"end_line\": 20}"
+}
+
+func (t *DeleteLinesTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the file to modify.",
+			},
+			"start_line": map[string]any{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "The first line to delete, 1-based.",
+			},
+			"end_line": map[string]any{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "The last line to delete, inclusive.",
+			},
+		},
+		"required": []string{"path", "start_line", "end_line"},
+	}
+}
+
+type DeleteLinesArgs struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+}
+
+// DeleteLines returns content without the 1-based lines start through end, inclusive,
+// along with the removed lines and the number of lines left.
+func DeleteLines(content string, start, end int) (string, []string, int, error) {
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+	if start > end {
+		return "", nil, 0, fmt.Errorf("start_line %d is after end_line %d", start, end)
+	}
+	if start < 1 || end > len(lines) {
+		return "", nil, 0, fmt.Errorf("lines %d-%d are out of range; the file has %d lines", start, end, len(lines))
+	}
+
+	removed := slices.Clone(lines[start-1 : end])
+	remaining := slices.Delete(lines, start-1, end)
+	output := strings.Join(remaining, "\n")
+	if len(remaining) > 0 && strings.HasSuffix(content, "\n") {
+		output += "\n"
+	}
+	return output, removed, len(remaining), nil
+}
+
+func (t *DeleteLinesTool) Execute(args string) (string, error) {
+	var toolArgs DeleteLinesArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for delete_lines: %w. Expected JSON: {\"path\": \"...\", \"start_line\": 1, \"end_line\": 1}", err)
+	}
+
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for delete_lines")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	contentBytes, err := os.ReadFile(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading file '%s': %w", toolArgs.Path, err)
+	}
+
+	modified, removed, total, err := DeleteLines(string(contentBytes), toolArgs.StartLine, toolArgs.EndLine)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(toolArgs.Path, []byte(modified)); err != nil {
+		return "", fmt.Errorf("error writing to file '%s': %w", toolArgs.Path, err)
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Deleted %d line(s) from %s, which now has %d lines. Removed:\n", len(removed), toolArgs.Path, total))
+	for i, line := range removed {
+		if i == maxDeletedLinesShown {
+			output.WriteString(fmt.Sprintf("... and %d more lines\n", len(removed)-maxDeletedLinesShown))
+			break
+		}
+		output.WriteString(fmt.Sprintf("%6d\t%s\n", toolArgs.StartLine+i, line))
+	}
+	return output.String(), nil
+}
+
+// --- DeleteFileTool ---
+
+// DeleteFileTool removes a file or an empty directory.
+type DeleteFileTool struct {
+	pathGuard
+}
+
+func (t *DeleteFileTool) Name() string {
+	return "delete_file"
+}
+
+func (t *DeleteFileTool) RequiresConfirmation() bool {
+	return true // Deleting a file cannot be undone
+}
+
+func (t *DeleteFileTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *DeleteFileTool) Description() string {
+	return "Deletes a file or an empty directory. Usage: {\"path\": \"<file_path>\"}"
+}
+
+func (t *DeleteFileTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the file to delete.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type DeleteFileArgs struct {
+	Path string `json:"path"`
+}
+
+func (t *DeleteFileTool) Execute(args string) (string, error) {
+	var toolArgs DeleteFileArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for delete_file: %w", err)
+	}
+
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for delete_file")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	absPath, err := filepath.Abs(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path '%s': %w", toolArgs.Path, err)
+	}
+
+	if err := os.Remove(absPath); err != nil {
+		return "", fmt.Errorf("error deleting '%s': %w", absPath, err)
+	}
+
+	return fmt.Sprintf("Successfully deleted %s", absPath), nil
+}
+
+// --- MoveFileTool ---
+
+// MoveFileTool renames or moves a file or directory, creating the destination's parent directories.
+type MoveFileTool struct {
+	pathGuard
+}
+
+func (t *MoveFileTool) Name() string {
+	return "move_file"
+}
+
+func (t *MoveFileTool) RequiresConfirmation() bool {
+	return true // May overwrite the destination
+}
+
+func (t *MoveFileTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *MoveFileTool) Description() string {
+	return "Moves or renames a file or directory. Missing parent directories of the destination are created. Usage: {\"path\": \"<source_path>\", \"destination\": \"<destination_path>\"}"
+}
+
+func (t *MoveFileTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the file or directory to move.",
+			},
+			"destination": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The new path.",
+			},
+		},
+		"required": []string{"path", "destination"},
+	}
+}
+
+type MoveFileArgs struct {
+	Path        string `json:"path"`
+	Destination string `json:"destination"`
+}
+
+func (t *MoveFileTool) Execute(args string) (string, error) {
+	var toolArgs MoveFileArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for move_file: %w", err)
+	}
+
+	if toolArgs.Path == "" || toolArgs.Destination == "" {
+		return "", fmt.Errorf("path and destination arguments are required for move_file")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+	if err := t.checkPath(toolArgs.Destination); err != nil {
+		return "", err
+	}
+
+	source, err := filepath.Abs(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path '%s': %w", toolArgs.Path, err)
+	}
+	destination, err := filepath.Abs(toolArgs.Destination)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path '%s': %w", toolArgs.Destination, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return "", fmt.Errorf("error creating directory '%s': %w", filepath.Dir(destination), err)
+	}
+
+	if err := os.Rename(source, destination); err != nil {
+		return "", fmt.Errorf("error moving '%s' to '%s': %w", source, destination, err)
+	}
+
+	return fmt.Sprintf("Successfully moved %s to %s", source, destination), nil
+}
+
+// --- CopyFileTool ---
+
+// CopyFileTool copies a file, symlink or directory tree, preserving file permissions.
+type CopyFileTool struct {
+	pathGuard
+}
+
+func (t *CopyFileTool) Name() string {
+	return "copy_file"
+}
+
+func (t *CopyFileTool) RequiresConfirmation() bool {
+	return true // May overwrite files at the destination
+}
+
+func (t *CopyFileTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *CopyFileTool) Description() string {
+	return "Copies a file to a new path, or a directory tree when recursive is true. File permissions are preserved, symlinks are copied as symlinks, and missing parent directories of the destination are created. Existing files at the destination are overwritten. Usage: {\"source\": \"<source_path>\", \"destination\": \"<destination_path>\", \"recursive\": false}"
+}
+
+func (t *CopyFileTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"source": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the file or directory to copy.",
+			},
+			"destination": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path of the copy.",
+			},
+			"recursive": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Copy a directory and everything in it. Required when source is a directory. Defaults to false.",
+			},
+		},
+		"required": []string{"source", "destination"},
+	}
+}
+
+type CopyFileArgs struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Recursive   bool   `json:"recursive,omitempty"`
+}
+
+func (t *CopyFileTool) Execute(args string) (string, error) {
+	var toolArgs CopyFileArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for copy_file: %w", err)
+	}
+
+	if toolArgs.Source == "" || toolArgs.Destination == "" {
+		return "", fmt.Errorf("source and destination arguments are required for copy_file")
+	}
+
+	if err := t.checkPath(toolArgs.Source); err != nil {
+		return "", err
+	}
+	if err := t.checkPath(toolArgs.Destination); err != nil {
+		return "", err
+	}
+
+	source, err := filepath.Abs(toolArgs.Source)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path '%s': %w", toolArgs.Source, err)
+	}
+	destination, err := filepath.Abs(toolArgs.Destination)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path '%s': %w", toolArgs.Destination, err)
+	}
+	if source == destination {
+		return "", fmt.Errorf("source and destination are the same path '%s'", source)
+	}
+
+	info, err := os.Lstat(source)
+	if err != nil {
+		return "", fmt.Errorf("error reading file info for '%s': %w", source, err)
+	}
+	if info.IsDir() {
+		if !toolArgs.Recursive {
+			return "", fmt.Errorf("'%s' is a directory; set recursive to true to copy it", source)
+		}
+		if strings.HasPrefix(destination, source+string(filepath.Separator)) {
+			return "", fmt.Errorf("cannot copy '%s' into itself", source)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return "", fmt.Errorf("error creating directory '%s': %w", filepath.Dir(destination), err)
+	}
+
+	files, bytes := 0, int64(0)
+	err = filepath.WalkDir(source, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destination, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("error creating directory '%s': %w", target, err)
+			}
+		case info.Mode()&os.ModeSymlink != 0:
+			if err := copySymlink(path, target); err != nil {
+				return err
+			}
+			files++
+		case info.Mode().IsRegular():
+			n, err := copyRegularFile(path, target, info.Mode().Perm())
+			if err != nil {
+				return err
+			}
+			files++
+			bytes += n
+		}
+		// Devices, sockets and pipes are skipped
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error copying '%s' to '%s': %w", source, destination, err)
+	}
+
+	return fmt.Sprintf("Successfully copied %s to %s: %d file(s), %s", source, destination, files, formatSize(bytes)), nil
+}
+
+// copyRegularFile copies the contents of src to dst, giving dst the permissions perm.
+func copyRegularFile(src, dst string, perm os.FileMode) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, fmt.Errorf("error opening file '%s': %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return 0, fmt.Errorf("error creating file '%s': %w", dst, err)
+	}
+	n, err := io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return n, fmt.Errorf("error writing file '%s': %w", dst, err)
+	}
+	if err := out.Close(); err != nil {
+		return n, fmt.Errorf("error writing file '%s': %w", dst, err)
+	}
+	// OpenFile applies the umask and leaves the mode of an existing file unchanged
+	if err := os.Chmod(dst, perm); err != nil {
+		return n, fmt.Errorf("error setting permissions of '%s': %w", dst, err)
+	}
+	return n, nil
+}
+
+// copySymlink recreates the symlink src at dst, pointing at the same target.
+func copySymlink(src, dst string) error {
+	link, err := os.Readlink(src)
+	if err != nil {
+		return fmt.Errorf("error reading symlink '%s': %w", src, err)
+	}
+	if _, err := os.Lstat(dst); err == nil {
+		if err := os.Remove(dst); err != nil {
+			return fmt.Errorf("error replacing '%s': %w", dst, err)
+		}
+	}
+	if err := os.Symlink(link, dst); err != nil {
+		return fmt.Errorf("error creating symlink '%s': %w", dst, err)
+	}
+	return nil
+}
+
+// --- CreateDirectoryTool ---
+
+// CreateDirectoryTool creates a directory, optionally including its missing parents.
+type CreateDirectoryTool struct {
+	pathGuard
+}
+
+func (t *CreateDirectoryTool) Name() string {
+	return "create_directory"
+}
+
+func (t *CreateDirectoryTool) RequiresConfirmation() bool {
+	return false // Creating a directory does not modify existing content
+}
+
+func (t *CreateDirectoryTool) ModifiesFiles() bool {
+	return true
+}
+
+func (t *CreateDirectoryTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *CreateDirectoryTool) Description() string {
+	return "Creates a directory. With parents set to true, missing parent directories are created too and an existing directory is not an error. Usage: {\"path\": \"<directory_path>\", \"parents\": true}"
+}
+
+func (t *CreateDirectoryTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path of the directory to create.",
+			},
+			"parents": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Create missing parent directories as needed, like mkdir -p. Defaults to false.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type CreateDirectoryArgs struct {
+	Path    string `json:"path"`
+	Parents bool   `json:"parents"`
+}
+
+func (t *CreateDirectoryTool) Execute(args string) (string, error) {
+	var toolArgs CreateDirectoryArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for create_directory: %w", err)
+	}
+
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for create_directory")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	if toolArgs.Parents {
+		if err := os.MkdirAll(toolArgs.Path, 0755); err != nil {
+			return "", fmt.Errorf("error creating directory '%s': %w", toolArgs.Path, err)
+		}
+	} else if err := os.Mkdir(toolArgs.Path, 0755); err != nil {
+		if os.IsExist(err) {
+			return "", fmt.Errorf("'%s' already exists; set parents to true to accept an existing directory", toolArgs.Path)
+		}
+		return "", fmt.Errorf("error creating directory '%s': %w", toolArgs.Path, err)
+	}
+
+	info, err := os.Stat(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading file info for '%s': %w", toolArgs.Path, err)
+	}
+	return fmt.Sprintf("Successfully created directory %s (permissions %s)", toolArgs.Path, info.Mode().Perm()), nil
+}
+
+// --- FileStatTool ---
+
+// FileStatTool reports the size, mode, modification time and type of a file or directory.
+type FileStatTool struct {
+	pathGuard
+}
+
+func (t *FileStatTool) Name() string {
+	return "file_stat"
+}
+
+func (t *FileStatTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *FileStatTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *FileStatTool) Description() string {
+	return "Shows metadata of a file or directory without reading it: absolute path, size, permissions, modification time, and whether it is a directory or a symlink (with the link target). Useful to check a file before changing it. Usage: {\"path\": \"<path>\"}"
+}
+
+func (t *FileStatTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the file or directory.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type FileStatArgs struct {
+	Path string `json:"path"`
+}
+
+func (t *FileStatTool) Execute(args string) (string, error) {
+	var toolArgs FileStatArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for file_stat: %w", err)
+	}
+
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for file_stat")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	absPath, err := filepath.Abs(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error resolving path '%s': %w", toolArgs.Path, err)
+	}
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return "", fmt.Errorf("error reading file info for '%s': %w", toolArgs.Path, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Path: %s\n", absPath)
+	fmt.Fprintf(&b, "Size: %d bytes (%s)\n", info.Size(), formatSize(info.Size()))
+	fmt.Fprintf(&b, "Mode: %s\n", info.Mode().String())
+	fmt.Fprintf(&b, "Octal: %04o\n", info.Mode().Perm())
+	fmt.Fprintf(&b, "Modified: %s\n", info.ModTime().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Directory: %t\n", info.IsDir())
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(absPath)
+		if err != nil {
+			target = fmt.Sprintf("unknown (%v)", err)
+		}
+		fmt.Fprintf(&b, "Symlink: true\nTarget: %s", target)
+	} else {
+		b.WriteString("Symlink: false")
+	}
+	return b.String(), nil
+}
+
+// --- SymlinkTool ---
+
+// SymlinkTool creates a symbolic link.
+type SymlinkTool struct {
+	pathGuard
+}
+
+func (t *SymlinkTool) Name() string {
+	return "symlink"
+}
+
+func (t *SymlinkTool) RequiresConfirmation() bool {
+	return true // Requires user confirmation as it creates a file
+}
+
+func (t *SymlinkTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *SymlinkTool) Description() string {
+	return "Creates a symbolic link at link_name pointing to target, like ln -s. A relative target is resolved from the link's directory. Usage: {\"target\": \"../bin/tool\", \"link_name\": \"bin/tool\"}"
+}
+
+func (t *SymlinkTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"target": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path the link points to. It is stored as given; a relative target is relative to the link's directory.",
+			},
+			"link_name": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path of the link to create. It must not exist yet.",
+			},
+		},
+		"required": []string{"target", "link_name"},
+	}
+}
+
+type SymlinkArgs struct {
+	Target   string `json:"target"`
+	LinkName string `json:"link_name"`
+}
+
+// resolveLinkTarget returns the absolute path a symlink at link pointing to target refers to.
+func resolveLinkTarget(link, target string) string {
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(link), target)
+	}
+	if abs, err := filepath.Abs(target); err == nil {
+		return abs
+	}
+	return target
+}
+
+func (t *SymlinkTool) Execute(args string) (string, error) {
+	var toolArgs SymlinkArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for symlink: %w. Expected JSON: {\"target\": \"...\", \"link_name\": \"...\"}", err)
+	}
+
+	if toolArgs.Target == "" || toolArgs.LinkName == "" {
+		return "", fmt.Errorf("target and link_name arguments are required for symlink")
+	}
+
+	resolved := resolveLinkTarget(toolArgs.LinkName, toolArgs.Target)
+	if err := t.checkPath(toolArgs.LinkName); err != nil {
+		return "", err
+	}
+	if err := t.checkPath(resolved); err != nil {
+		return "", err
+	}
+
+	if err := os.Symlink(toolArgs.Target, toolArgs.LinkName); err != nil {
+		return "", fmt.Errorf("error creating symlink '%s': %w", toolArgs.LinkName, err)
+	}
+
+	linkAbs, err := filepath.Abs(toolArgs.LinkName)
+	if err != nil {
+		linkAbs = toolArgs.LinkName
+	}
+	result := fmt.Sprintf("Created symlink %s -> %s (resolves to %s)", linkAbs, toolArgs.Target, resolved)
+	if _, err := os.Stat(resolved); err != nil {
+		result += "; note that the target does not exist"
+	}
+	return result, nil
+}
+
+// --- ReadlinkTool ---
+
+// ReadlinkTool shows where a symbolic link points.
+type ReadlinkTool struct {
+	pathGuard
+}
+
+func (t *ReadlinkTool) Name() string {
+	return "readlink"
+}
+
+func (t *ReadlinkTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *ReadlinkTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *ReadlinkTool) Description() string {
+	return "Returns the target of a symbolic link, as stored and as a resolved absolute path. Fails if the path is not a symlink. Usage: {\"path\": \"<link_path>\"}"
+}
+
+func (t *ReadlinkTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path of the symbolic link.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type ReadlinkArgs struct {
+	Path string `json:"path"`
+}
+
+func (t *ReadlinkTool) Execute(args string) (string, error) {
+	var toolArgs ReadlinkArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for readlink: %w. Expected JSON: {\"path\": \"...\"}", err)
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading '%s': %w", toolArgs.Path, err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return "", fmt.Errorf("'%s' is not a symlink", toolArgs.Path)
+	}
+
+	target, err := os.Readlink(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading symlink '%s': %w", toolArgs.Path, err)
+	}
+
+	linkAbs, err := filepath.Abs(toolArgs.Path)
+	if err != nil {
+		linkAbs = toolArgs.Path
+	}
+	resolved := resolveLinkTarget(toolArgs.Path, target)
+	result := fmt.Sprintf("%s -> %s (resolves to %s)", linkAbs, target, resolved)
+	if _, err := os.Stat(resolved); err != nil {
+		result += "; the target does not exist"
+	}
+	return result, nil
+}
+
+// --- WatchFileTool ---
+
+// WatchFileTool collects the lines appended to a file over a period of time, like tail -f.
+type WatchFileTool struct {
+	pathGuard
+}
+
+const (
+	// maxWatchLines caps the new lines watch_file returns; the most recent are kept.
+	maxWatchLines = 1000
+	// maxWatchBytes caps the new content watch_file keeps in memory while watching.
+	maxWatchBytes = 1024 * 1024
+)
+
+func (t *WatchFileTool) Name() string {
+	return "watch_file"
+}
+
+func (t *WatchFileTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *WatchFileTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *WatchFileTool) Description() string {
+	return fmt.Sprintf("Watches a file for duration_seconds and returns the lines appended to it meanwhile (at most the last %d), like tail -f. Also reports when the file is truncated or removed. Useful for following log files. Usage: {\"path\": \"app.log\", \"duration_seconds\": 10, \"poll_interval_ms\": 500}", maxWatchLines)
+}
+
+func (t *WatchFileTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path of the file to watch.",
+			},
+			"duration_seconds": map[string]any{
+				"type":        "integer",
+				"minimum":     1,
+				"maximum":     120,
+				"description": "Optional: How long to watch the file. Defaults to 10.",
+			},
+			"poll_interval_ms": map[string]any{
+				"type":        "integer",
+				"minimum":     100,
+				"maximum":     10000,
+				"description": "Optional: How often to check the file for changes. Defaults to 500.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type WatchFileArgs struct {
+	Path            string `json:"path"`
+	DurationSeconds int    `json:"duration_seconds"`
+	PollIntervalMs  int    `json:"poll_interval_ms"`
+}
+
+func (t *WatchFileTool) Execute(args string) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext watches the file until the duration has passed or ctx is cancelled,
+// and returns what was appended until then.
+func (t *WatchFileTool) ExecuteContext(ctx context.Context, args string) (string, error) {
+	var toolArgs WatchFileArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for watch_file: %w. Expected JSON: {\"path\": \"...\", \"duration_seconds\": 10}", err)
+	}
+
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for watch_file")
+	}
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	duration := 10 * time.Second
+	if toolArgs.DurationSeconds > 0 {
+		duration = time.Duration(toolArgs.DurationSeconds) * time.Second
+	}
+	interval := 500 * time.Millisecond
+	if toolArgs.PollIntervalMs > 0 {
+		interval = time.Duration(toolArgs.PollIntervalMs) * time.Millisecond
+	}
+
+	info, err := os.Stat(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading file info for '%s': %w", toolArgs.Path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("'%s' is not a regular file", toolArgs.Path)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	result := watchFile(ctx, toolArgs.Path, info.Size(), interval)
+	return result.format(toolArgs.Path, duration), nil
+}
+
+// watchResult is what watchFile saw happen to a file.
+type watchResult struct {
+	content []byte   // Appended content, at most maxWatchBytes of the most recent
+	dropped bool     // Whether older appended content was discarded to stay under maxWatchBytes
+	events  []string // Truncations and removals, in order
+}
+
+// watchFile polls path every interval until ctx is done, collecting what is appended after
+// offset. When the file shrinks it is assumed to have been truncated or rotated, and is
+// read again from the start.
+func watchFile(ctx context.Context, path string, offset int64, interval time.Duration) watchResult {
+	var result watchResult
+	missing := false
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return result
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			if !missing {
+				result.events = append(result.events, "file was removed")
+				missing = true
+			}
+			offset = 0
+			continue
+		}
+		if missing {
+			result.events = append(result.events, "file was recreated")
+			missing = false
+		}
+		if info.Size() < offset {
+			result.events = append(result.events, "file was truncated")
+			offset = 0
+		}
+		if info.Size() == offset {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err == nil {
+			data, _ := io.ReadAll(io.LimitReader(f, maxWatchBytes))
+			offset += int64(len(data))
+			result.content = append(result.content, data...)
+			if excess := len(result.content) - maxWatchBytes; excess > 0 {
+				result.content = result.content[excess:]
+				result.dropped = true
+			}
+		}
+		f.Close()
+	}
+}
+
+// format renders the result as the tool output.
+func (r watchResult) format(path string, duration time.Duration) string {
+	var b strings.Builder
+	for _, event := range r.events {
+		fmt.Fprintf(&b, "[%s]\n", event)
+	}
+
+	if len(r.content) == 0 {
+		fmt.Fprintf(&b, "No new content in %s after %s.", path, duration)
+		return b.String()
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(r.content), "\n"), "\n")
+	if r.dropped {
+		lines = lines[1:] // The first line is most likely cut off
+	}
+	skipped := 0
+	if len(lines) > maxWatchLines {
+		skipped = len(lines) - maxWatchLines
+		lines = lines[skipped:]
+	}
+
+	fmt.Fprintf(&b, "%d new lines in %s after %s:\n", len(lines), path, duration)
+	if skipped > 0 || r.dropped {
+		fmt.Fprintf(&b, "... (earlier lines omitted; showing the last %d)\n", len(lines))
+	}
+	b.WriteString(strings.Join(lines, "\n"))
+	return b.String()
+}
+
+// --- ChecksumTool ---
+
+// ChecksumTool computes the hash of a file and optionally compares it with a known value.
+type ChecksumTool struct {
+	pathGuard
+}
+
+// checksumAlgorithms maps the supported algorithm names to their hash constructors.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func (t *ChecksumTool) Name() string {
+	return "checksum"
+}
+
+func (t *ChecksumTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *ChecksumTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *ChecksumTool) Description() string {
+	return "Computes the md5, sha1, sha256 or sha512 checksum of a file, e.g. to verify a download or check that two files are identical. If compare is given, reports MATCH or MISMATCH. Usage: {\"path\": \"<file_path>\", \"algorithm\": \"sha256\", \"compare\": \"<optional_known_hash>\"}"
+}
+
+func (t *ChecksumTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path of the file to hash.",
+			},
+			"algorithm": map[string]any{
+				"type":        "string",
+				"enum":        []string{"md5", "sha1", "sha256", "sha512"},
+				"description": "Optional: The hash algorithm. Defaults to sha256.",
+			},
+			"compare": map[string]any{
+				"type":        "string",
+				"description": "Optional: A known hex digest to compare the file's checksum with.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type ChecksumArgs struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+	Compare   string `json:"compare"`
+}
+
+func (t *ChecksumTool) Execute(args string) (string, error) {
+	var toolArgs ChecksumArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for checksum: %w. Expected JSON: {\"path\": \"...\", \"algorithm\": \"sha256\"}", err)
+	}
+
+	algorithm := strings.ToLower(toolArgs.Algorithm)
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	newHash, ok := checksumAlgorithms[algorithm]
+	if !ok {
+		return "", fmt.Errorf("unsupported algorithm '%s': use md5, sha1, sha256 or sha512", toolArgs.Algorithm)
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error opening file '%s': %w", toolArgs.Path, err)
+	}
+	defer file.Close()
+
+	h := newHash()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("error reading file '%s': %w", toolArgs.Path, err)
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+
+	if toolArgs.Compare == "" {
+		return fmt.Sprintf("%s:%s  %s", algorithm, digest, toolArgs.Path), nil
+	}
+
+	// Accept the expected value with or without an "algorithm:" prefix.
+	expected := strings.TrimSpace(toolArgs.Compare)
+	expected = strings.TrimPrefix(strings.ToLower(expected), algorithm+":")
+	if expected == digest {
+		return fmt.Sprintf("MATCH\nexpected: %s\nactual:   %s", expected, digest), nil
+	}
+	return fmt.Sprintf("MISMATCH\nexpected: %s\nactual:   %s", expected, digest), nil
+}
+
+// --- FindDuplicateFilesTool ---
+
+// FindDuplicateFilesTool finds files with identical content below a directory.
+type FindDuplicateFilesTool struct {
+	pathGuard
+}
+
+// maxDuplicateScanFiles caps the number of files find_duplicate_files looks at.
+const maxDuplicateScanFiles = 1000
+
+func (t *FindDuplicateFilesTool) Name() string {
+	return "find_duplicate_files"
+}
+
+func (t *FindDuplicateFilesTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *FindDuplicateFilesTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *FindDuplicateFilesTool) Description() string {
+	return "Finds files with identical content (by SHA-256) in a directory tree and lists each group of duplicates, separated by blank lines. Looks at up to 1000 files. Usage: {\"path\": \"<directory_path>\", \"min_size_bytes\": 1}"
+}
+
+func (t *FindDuplicateFilesTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The directory to search for duplicates.",
+			},
+			"min_size_bytes": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: Ignore files smaller than this many bytes. Defaults to 1, which ignores empty files.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type FindDuplicateFilesArgs struct {
+	Path         string `json:"path"`
+	MinSizeBytes *int64 `json:"min_size_bytes"`
+}
+
+func (t *FindDuplicateFilesTool) Execute(args string) (string, error) {
+	var toolArgs FindDuplicateFilesArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for find_duplicate_files: %w. Expected JSON: {\"path\": \"...\", \"min_size_bytes\": 1}", err)
+	}
+
+	path := toolArgs.Path
+	if path == "" {
+		path = "."
+	}
+
+	if err := t.checkPath(path); err != nil {
+		return "", err
+	}
+
+	minSize := int64(1)
+	if toolArgs.MinSizeBytes != nil {
+		minSize = *toolArgs.MinSizeBytes
+	}
+
+	// Only files of the same size can be duplicates, so group by size before hashing.
+	bySize := make(map[int64][]string)
+	scanned := 0
+	truncated := false
+	err := filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			if p == path {
+				return err
+			}
+			return nil // Skip entries we can't read
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if scanned == maxDuplicateScanFiles {
+			truncated = true
+			return filepath.SkipAll
+		}
+		scanned++
+
+		info, err := d.Info()
+		if err != nil || info.Size() < minSize {
+			return nil
+		}
+		bySize[info.Size()] = append(bySize[info.Size()], p)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error walking directory '%s': %w", path, err)
+	}
+
+	type duplicateGroup struct {
+		size  int64
+		paths []string
+	}
+	var groups []duplicateGroup
+	for size, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+		byHash := make(map[string][]string)
+		for _, p := range paths {
+			digest, err := sha256File(p)
+			if err != nil {
+				continue
+			}
+			byHash[digest] = append(byHash[digest], p)
+		}
+		for _, same := range byHash {
+			if len(same) > 1 {
+				sort.Strings(same)
+				groups = append(groups, duplicateGroup{size: size, paths: same})
+			}
+		}
+	}
+
+	// Show the groups wasting the most space first.
+	sort.Slice(groups, func(i, j int) bool {
+		wasteI := groups[i].size * int64(len(groups[i].paths)-1)
+		wasteJ := groups[j].size * int64(len(groups[j].paths)-1)
+		if wasteI != wasteJ {
+			return wasteI > wasteJ
+		}
+		return groups[i].paths[0] < groups[j].paths[0]
+	})
+
+	var output strings.Builder
+	if len(groups) == 0 {
+		output.WriteString(fmt.Sprintf("No duplicate files found among %d files.\n", scanned))
+	}
+	for i, group := range groups {
+		if i > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString(fmt.Sprintf("%d identical files of %s:\n", len(group.paths), formatSize(group.size)))
+		for _, p := range group.paths {
+			output.WriteString(p + "\n")
+		}
+	}
+	if truncated {
+		output.WriteString(fmt.Sprintf("\n... stopped after %d files; search a subdirectory to cover the rest\n", maxDuplicateScanFiles))
+	}
+	return output.String(), nil
+}
+
+// sha256File returns the hex-encoded SHA-256 digest of the file at path.
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// --- CreateDiffTool ---
+
+// CreateDiffTool produces a unified diff between two files.
+type CreateDiffTool struct {
+	pathGuard
+}
+
+func (t *CreateDiffTool) Name() string {
+	return "create_diff"
+}
+
+func (t *CreateDiffTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *CreateDiffTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *CreateDiffTool) Description() string {
+	return "Produces a unified diff (like diff -u) that turns from_path into to_path. A missing file is treated as empty, so the diff creates or deletes it. Usage: {\"from_path\": \"<original_file>\", \"to_path\": \"<modified_file>\"}"
+}
+
+func (t *CreateDiffTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"from_path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The original file.",
+			},
+			"to_path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The modified file.",
+			},
+		},
+		"required": []string{"from_path", "to_path"},
+	}
+}
+
+type CreateDiffArgs struct {
+	FromPath string `json:"from_path"`
+	ToPath   string `json:"to_path"`
+}
+
+func (t *CreateDiffTool) Execute(args string) (string, error) {
+	var toolArgs CreateDiffArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for create_diff: %w", err)
+	}
+
+	if toolArgs.FromPath == "" || toolArgs.ToPath == "" {
+		return "", fmt.Errorf("from_path and to_path arguments are required for create_diff")
+	}
+
+	if err := t.checkPath(toolArgs.FromPath); err != nil {
+		return "", err
+	}
+	if err := t.checkPath(toolArgs.ToPath); err != nil {
+		return "", err
+	}
+
+	from, fromErr := os.ReadFile(toolArgs.FromPath)
+	if fromErr != nil && !os.IsNotExist(fromErr) {
+		return "", fmt.Errorf("error reading file '%s': %w", toolArgs.FromPath, fromErr)
+	}
+	to, toErr := os.ReadFile(toolArgs.ToPath)
+	if toErr != nil && !os.IsNotExist(toErr) {
+		return "", fmt.Errorf("error reading file '%s': %w", toolArgs.ToPath, toErr)
+	}
+	if fromErr != nil && toErr != nil {
+		return "", fmt.Errorf("neither '%s' nor '%s' exists", toolArgs.FromPath, toolArgs.ToPath)
+	}
+
+	fromName, toName := toolArgs.FromPath, toolArgs.ToPath
+	if fromErr != nil {
+		fromName = "/dev/null"
+	}
+	if toErr != nil {
+		toName = "/dev/null"
+	}
+
+	diff, added, removed := unifiedDiff(fromName, toName, string(from), string(to))
+	if diff == "" {
+		return "The files are identical.", nil
+	}
+	return fmt.Sprintf("%d lines added, %d lines removed.\n\n%s", added, removed, diff), nil
+}
+
+// --- ApplyDiffTool ---
+
+// ApplyDiffTool applies a unified diff to a file.
+type ApplyDiffTool struct {
+	pathGuard
+}
+
+func (t *ApplyDiffTool) Name() string {
+	return "apply_diff"
+}
+
+func (t *ApplyDiffTool) RequiresConfirmation() bool {
+	return true // Modifies the file
+}
+
+func (t *ApplyDiffTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *ApplyDiffTool) Description() string {
+	return "Applies a unified diff (as produced by create_diff or diff -u) to a single file. Every hunk must match the file's current content; hunks may be found a few lines away from their stated position. A missing file is treated as empty. Usage: {\"path\": \"<file_path>\", \"diff\": \"<unified_diff>\"}"
+}
+
+func (t *ApplyDiffTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The file to patch.",
+			},
+			"diff": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The unified diff. File header lines (---/+++) are optional.",
+			},
+		},
+		"required": []string{"path", "diff"},
+	}
+}
+
+type ApplyDiffArgs struct {
+	Path string `json:"path"`
+	Diff string `json:"diff"`
+}
+
+func (t *ApplyDiffTool) Execute(args string) (string, error) {
+	var toolArgs ApplyDiffArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for apply_diff: %w", err)
+	}
+
+	if toolArgs.Path == "" || toolArgs.Diff == "" {
+		return "", fmt.Errorf("path and diff arguments are required for apply_diff")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	content, err := os.ReadFile(toolArgs.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("error reading file '%s': %w", toolArgs.Path, err)
+	}
+
+	patched, added, removed, err := applyUnifiedDiff(string(content), toolArgs.Diff)
+	if err != nil {
+		return "", fmt.Errorf("error applying diff to '%s': %w", toolArgs.Path, err)
+	}
+
+	if err := writeFileAtomic(toolArgs.Path, []byte(patched)); err != nil {
+		return "", fmt.Errorf("error writing to file '%s': %w", toolArgs.Path, err)
+	}
+	return fmt.Sprintf("Successfully patched %s: %d lines added, %d lines removed", toolArgs.Path, added, removed), nil
+}
+
+// diffContext is the number of unchanged lines around each change in a unified diff.
+const diffContext = 3
+
+type diffOp struct {
+	kind byte // ' ', '+' or '-'
+	text string
+}
+
+// splitLines splits s into lines without their terminators. A trailing newline does not start a new line.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// UnifiedDiff returns a unified diff from before to after, or an empty string when they are equal.
+func UnifiedDiff(fromName, toName, before, after string) string {
+	diff, _, _ := unifiedDiff(fromName, toName, before, after)
+	return diff
+}
+
+// unifiedDiff returns a unified diff from before to after and the number of added and removed lines.
+// It returns an empty diff when the contents are equal.
+func unifiedDiff(fromName, toName, before, after string) (string, int, int) {
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(before, after)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	var ops []diffOp
+	added, removed := 0, 0
+	for _, d := range diffs {
+		kind := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			kind = '+'
+		case diffmatchpatch.DiffDelete:
+			kind = '-'
+		}
+		for _, line := range splitLines(d.Text) {
+			ops = append(ops, diffOp{kind, line})
+			switch kind {
+			case '+':
+				added++
+			case '-':
+				removed++
+			}
+		}
+	}
+	if added == 0 && removed == 0 {
+		return "", 0, 0
+	}
+
+	var out strings.Builder
+	out.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+
+	// oldLine and newLine hold the 1-based line numbers of ops[i] in each file.
+	oldLine, newLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	oldLine[0], newLine[0] = 1, 1
+	for i, op := range ops {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if op.kind != '+' {
+			oldLine[i+1]++
+		}
+		if op.kind != '-' {
+			newLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the next change is close enough to share context.
+		start := max(0, i-diffContext)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*diffContext {
+				break
+			}
+		}
+		end = min(len(ops), end+diffContext+1)
+
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		oldStart, newStart := oldLine[start], newLine[start]
+		if oldCount == 0 {
+			oldStart-- // An empty range names the line before it
+		}
+		if newCount == 0 {
+			newStart--
+		}
+
+		out.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount))
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String(), added, removed
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// applyUnifiedDiff applies the hunks of diff to content and returns the result with the
+// number of added and removed lines. Each hunk is looked for at its stated line first and
+// then at the nearest position where its context and removed lines match.
+func applyUnifiedDiff(content, diff string) (string, int, int, error) {
+	lines := splitLines(content)
+	trailingNewline := content == "" || strings.HasSuffix(content, "\n")
+
+	type hunk struct {
+		oldStart int
+		ops      []diffOp
+	}
+	var hunks []hunk
+	diffLines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	for i, line := range diffLines {
+		if match := hunkHeaderRegex.FindStringSubmatch(line); match != nil {
+			start, _ := strconv.Atoi(match[1])
+			hunks = append(hunks, hunk{oldStart: start})
+			continue
+		}
+		if len(hunks) == 0 || strings.HasPrefix(line, `\`) {
+			continue // File headers, or "\ No newline at end of file"
+		}
+		// A removed line may itself start with "-- ", but followed by "+++ " it is the next file's header
+		if strings.HasPrefix(line, "--- ") && i+1 < len(diffLines) && strings.HasPrefix(diffLines[i+1], "+++ ") {
+			return "", 0, 0, fmt.Errorf("diff patches more than one file; pass a separate diff for each file")
+		}
+		current := &hunks[len(hunks)-1]
+		switch {
+		case line == "":
+			current.ops = append(current.ops, diffOp{' ', ""}) // Some tools strip the space of empty context lines
+		case line[0] == ' ' || line[0] == '+' || line[0] == '-':
+			current.ops = append(current.ops, diffOp{line[0], line[1:]})
+		default:
+			return "", 0, 0, fmt.Errorf("unexpected line in hunk %d: %q", len(hunks), line)
+		}
+	}
+	if len(hunks) == 0 {
+		return "", 0, 0, fmt.Errorf("no hunks (@@ ... @@) found in diff")
+	}
+
+	var result []string
+	added, removed := 0, 0
+	pos := 0 // Index in lines of the first line not yet copied to result
+	for n, h := range hunks {
+		// Drop trailing blank context lines that were only the diff's final newline.
+		for len(h.ops) > 0 && h.ops[len(h.ops)-1] == (diffOp{' ', ""}) {
+			h.ops = h.ops[:len(h.ops)-1]
+		}
+
+		var old []string
+		for _, op := range h.ops {
+			if op.kind != '+' {
+				old = append(old, op.text)
+			}
+		}
+
+		at := findHunk(lines, old, pos, max(pos, h.oldStart-1))
+		if len(old) == 0 {
+			at = max(pos, min(h.oldStart, len(lines))) // Pure insertion after line oldStart
+		}
+		if at < 0 {
+			return "", 0, 0, fmt.Errorf("hunk %d (@@ -%d) does not match the file content", n+1, h.oldStart)
+		}
+
+		result = append(result, lines[pos:at]...)
+		for _, op := range h.ops {
+			switch op.kind {
+			case ' ':
+				result = append(result, op.text)
+			case '+':
+				result = append(result, op.text)
+				added++
+			case '-':
+				removed++
+			}
+		}
+		pos = at + len(old)
+	}
+	result = append(result, lines[pos:]...)
+
+	patched := strings.Join(result, "\n")
+	if trailingNewline && len(result) > 0 {
+		patched += "\n"
+	}
+	return patched, added, removed, nil
+}
+
+// findHunk returns the index of the match for old in lines at or after minPos that is closest to want, or -1.
+func findHunk(lines, old []string, minPos, want int) int {
+	matches := func(at int) bool {
+		if at < minPos || at+len(old) > len(lines) {
+			return false
+		}
+		for i, line := range old {
+			if lines[at+i] != line {
+				return false
+			}
+		}
+		return true
+	}
+
+	for offset := 0; offset <= len(lines); offset++ {
+		if matches(want - offset) {
+			return want - offset
+		}
+		if matches(want + offset) {
+			return want + offset
+		}
+	}
+	return -1
 }
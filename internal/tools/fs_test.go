@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyDiffKeepsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows only supports the read-only bit")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho old\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	args, _ := json.Marshal(ApplyDiffArgs{Path: path, Diff: "@@ -1,2 +1,2 @@\n #!/bin/sh\n-echo old\n+echo new\n"})
+	if _, err := (&ApplyDiffTool{}).Execute(string(args)); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "#!/bin/sh\necho new\n"; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0755 {
+		t.Errorf("mode = %04o, want 0755", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only run.sh (no leftover temporary file)", len(entries))
+	}
+}
+
+func TestReplaceKeepsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows only supports the read-only bit")
+	}
+
+	path := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho old\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	args, _ := json.Marshal(ReplaceArgs{Path: path, OldString: "old", NewString: "new"})
+	if _, err := (&ReplaceTool{}).Execute(string(args)); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "#!/bin/sh\necho new\n"; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0755 {
+		t.Errorf("mode = %04o, want 0755", got)
+	}
+}
+
+func TestWatchFileStopsWhenCancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		os.WriteFile(path, []byte("old\nnew\n"), 0644)
+		time.Sleep(300 * time.Millisecond)
+		cancel()
+	}()
+
+	args, _ := json.Marshal(WatchFileArgs{Path: path, DurationSeconds: 60, PollIntervalMs: 100})
+	start := time.Now()
+	result, err := (&WatchFileTool{}).ExecuteContext(ctx, string(args))
+	if err != nil {
+		t.Fatalf("ExecuteContext() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("watch_file ran for %v after its context was cancelled", elapsed)
+	}
+	if !strings.Contains(result, "new") || strings.Contains(result, "old") {
+		t.Errorf("result = %q, want only the appended line", result)
+	}
+}
+
+func TestApplyUnifiedDiffMultipleFiles(t *testing.T) {
+	content := "a\n-- b\nc\n"
+
+	// A removed line that starts with "-- " is still part of the hunk
+	patched, added, removed, err := applyUnifiedDiff(content, "--- x.sql\n+++ x.sql\n@@ -1,3 +1,2 @@\n a\n--- b\n c\n")
+	if err != nil || patched != "a\nc\n" || added != 0 || removed != 1 {
+		t.Errorf("applyUnifiedDiff() = %q, %d, %d, %v, want \"a\\nc\\n\", 0, 1, nil", patched, added, removed, err)
+	}
+
+	diff := "--- x.sql\n+++ x.sql\n@@ -1,3 +1,3 @@\n a\n-c\n+d\n--- y.sql\n+++ y.sql\n@@ -1 +1 @@\n-y\n+z\n"
+	if _, _, _, err := applyUnifiedDiff(content, diff); err == nil || !strings.Contains(err.Error(), "more than one file") {
+		t.Errorf("applyUnifiedDiff() with two files: error = %v, want a multi-file error", err)
+	}
+}
@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// runGit runs git with args in the current working directory and returns its combined output.
+// It reports a clear error when the directory is not inside a git repository.
+func runGit(args ...string) (string, error) {
+	if err := exec.Command("git", "rev-parse", "--is-inside-work-tree").Run(); err != nil {
+		if _, lookErr := exec.LookPath("git"); lookErr != nil {
+			return "", fmt.Errorf("git is not installed or not in PATH")
+		}
+		return "", fmt.Errorf("the current directory is not a git repository")
+	}
+
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s failed: %w\n%s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return string(output), nil
+}
+
+// --- GitStatusTool ---
+
+// GitStatusTool shows the working tree status in short format.
+type GitStatusTool struct{}
+
+func (t *GitStatusTool) Name() string {
+	return "git_status"
+}
+
+func (t *GitStatusTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *GitStatusTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *GitStatusTool) Description() string {
+	return "Shows the status of the git repository in the current directory (git status --short). Usage: {}"
+}
+
+func (t *GitStatusTool) Parameters() any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+func (t *GitStatusTool) Execute(args string) (string, error) {
+	output, err := runGit("status", "--short")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(output) == "" {
+		return "Working tree clean.", nil
+	}
+	return output, nil
+}
+
+// --- GitDiffTool ---
+
+// GitDiffTool shows unstaged or staged changes, optionally limited to a path.
+type GitDiffTool struct {
+	pathGuard
+}
+
+func (t *GitDiffTool) Name() string {
+	return "git_diff"
+}
+
+func (t *GitDiffTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *GitDiffTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *GitDiffTool) Description() string {
+	return "Shows the changes in the git repository in the current directory. By default shows unstaged changes; set staged to true to show what will be committed. Usage: {\"staged\": false, \"path\": \"<optional_path>\"}"
+}
+
+func (t *GitDiffTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"staged": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Show staged changes (git diff --staged) instead of unstaged ones.",
+			},
+			"path": map[string]any{
+				"type":        "string",
+				"description": "Optional: Limit the diff to this file or directory.",
+			},
+		},
+	}
+}
+
+type GitDiffArgs struct {
+	Staged bool   `json:"staged"`
+	Path   string `json:"path"`
+}
+
+func (t *GitDiffTool) Execute(args string) (string, error) {
+	var toolArgs GitDiffArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for git_diff: %w", err)
+	}
+
+	gitArgs := []string{"diff"}
+	if toolArgs.Staged {
+		gitArgs = append(gitArgs, "--staged")
+	}
+	if toolArgs.Path != "" {
+		if err := t.checkPath(toolArgs.Path); err != nil {
+			return "", err
+		}
+		gitArgs = append(gitArgs, "--", toolArgs.Path)
+	}
+
+	output, err := runGit(gitArgs...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(output) == "" {
+		return "No changes.", nil
+	}
+	return output, nil
+}
+
+// --- GitLogTool ---
+
+// GitLogTool shows recent commits.
+type GitLogTool struct{}
+
+func (t *GitLogTool) Name() string {
+	return "git_log"
+}
+
+func (t *GitLogTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *GitLogTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *GitLogTool) Description() string {
+	return "Shows the most recent commits of the git repository in the current directory. Usage: {\"n\": 10, \"oneline\": true}"
+}
+
+func (t *GitLogTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"n": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: The number of commits to show. Defaults to 10.",
+			},
+			"oneline": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Show one line per commit. Defaults to true.",
+			},
+		},
+	}
+}
+
+type GitLogArgs struct {
+	N       int   `json:"n"`
+	Oneline *bool `json:"oneline"` // Pointer so that an omitted value defaults to true
+}
+
+func (t *GitLogTool) Execute(args string) (string, error) {
+	var toolArgs GitLogArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for git_log: %w", err)
+	}
+
+	n := toolArgs.N
+	if n <= 0 {
+		n = 10
+	}
+	gitArgs := []string{"log", "-n", strconv.Itoa(n)}
+	if toolArgs.Oneline == nil || *toolArgs.Oneline {
+		gitArgs = append(gitArgs, "--oneline")
+	}
+
+	return runGit(gitArgs...)
+}
+
+// --- GitCommitTool ---
+
+// GitCommitTool commits the staged changes.
+type GitCommitTool struct{}
+
+func (t *GitCommitTool) Name() string {
+	return "git_commit"
+}
+
+func (t *GitCommitTool) RequiresConfirmation() bool {
+	return true // Records history in the user's repository
+}
+
+func (t *GitCommitTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *GitCommitTool) Description() string {
+	return "Commits the currently staged changes of the git repository in the current directory with the given message. Stage files first, e.g. with run_shell_command and git add. Usage: {\"message\": \"<commit_message>\"}"
+}
+
+func (t *GitCommitTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"message": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The commit message.",
+			},
+		},
+		"required": []string{"message"},
+	}
+}
+
+type GitCommitArgs struct {
+	Message string `json:"message"`
+}
+
+func (t *GitCommitTool) Execute(args string) (string, error) {
+	var toolArgs GitCommitArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for git_commit: %w", err)
+	}
+
+	if strings.TrimSpace(toolArgs.Message) == "" {
+		return "", fmt.Errorf("message argument is required for git_commit")
+	}
+
+	return runGit("commit", "-m", toolArgs.Message)
+}
@@ -0,0 +1,1129 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"go/version"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+	"unicode"
+
+	"golang.org/x/mod/semver"
+)
+
+// --- ParseGoErrorsTool ---
+
+// ParseGoErrorsTool turns raw `go build` / `go test` / `go vet` output into structured diagnostics.
+type ParseGoErrorsTool struct{}
+
+func (t *ParseGoErrorsTool) Name() string {
+	return "parse_go_errors"
+}
+
+func (t *ParseGoErrorsTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *ParseGoErrorsTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *ParseGoErrorsTool) Description() string {
+	return "Parses the output of `go build`, `go vet` or `go test` into a JSON array of diagnostics ({file, line, col, message, severity}), including panics and data races. Usage: {\"error_output\": \"<raw_output>\"}"
+}
+
+func (t *ParseGoErrorsTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"error_output": map[string]any{
+				"type":        "string",
+				"description": "The raw output of a failed go command.",
+			},
+		},
+		"required": []string{"error_output"},
+	}
+}
+
+type ParseGoErrorsArgs struct {
+	ErrorOutput string `json:"error_output"`
+}
+
+// GoDiagnostic is a single structured problem reported by the go toolchain.
+type GoDiagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Col      int      `json:"col,omitempty"`
+	Message  string   `json:"message"`
+	Severity string   `json:"severity"` // "error", "vet", "panic" or "race"
+	Stack    []string `json:"stack,omitempty"`
+}
+
+var (
+	// <file>.go:<line>:<col>: <message>, where the column is optional.
+	goDiagnosticRegex = regexp.MustCompile(`^(?:vet: )?(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+	// A stack frame location such as "\t/path/to/file.go:42 +0x1d".
+	goStackFileRegex = regexp.MustCompile(`^\s+(\S+\.go):(\d+)`)
+)
+
+func (t *ParseGoErrorsTool) Execute(args string) (string, error) {
+	var toolArgs ParseGoErrorsArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for parse_go_errors: %w", err)
+	}
+
+	if strings.TrimSpace(toolArgs.ErrorOutput) == "" {
+		return "", fmt.Errorf("error_output argument is required for parse_go_errors")
+	}
+
+	diagnostics := parseGoErrors(toolArgs.ErrorOutput)
+	if len(diagnostics) == 0 {
+		return "No Go diagnostics found in the output.", nil
+	}
+
+	out, err := json.MarshalIndent(diagnostics, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding diagnostics: %w", err)
+	}
+	return string(out), nil
+}
+
+// parseGoErrors extracts compiler/vet diagnostics, panics and data races, grouped by file.
+func parseGoErrors(output string) []GoDiagnostic {
+	var diagnostics []GoDiagnostic
+	lines := strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "panic: "):
+			diag, next := parseStackBlock(lines, i, "panic", strings.TrimPrefix(trimmed, "panic: "), "")
+			diagnostics = append(diagnostics, diag)
+			i = next - 1
+
+		case trimmed == "WARNING: DATA RACE":
+			diag, next := parseStackBlock(lines, i, "race", "data race detected", "==================")
+			diagnostics = append(diagnostics, diag)
+			i = next - 1
+
+		default:
+			match := goDiagnosticRegex.FindStringSubmatch(trimmed)
+			if match == nil {
+				continue
+			}
+			lineNum, _ := strconv.Atoi(match[2])
+			col, _ := strconv.Atoi(match[3]) // Empty when the tool reports no column
+			severity := "error"
+			if strings.HasPrefix(trimmed, "vet: ") {
+				severity = "vet"
+			}
+			// Type errors continue on indented lines, e.g. the have/want lines of a missing method
+			message := match[4]
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], "\t") && !goDiagnosticRegex.MatchString(strings.TrimSpace(lines[i+1])) {
+				i++
+				message += "\n" + strings.TrimSpace(lines[i])
+			}
+			diagnostics = append(diagnostics, GoDiagnostic{
+				File:     match[1],
+				Line:     lineNum,
+				Col:      col,
+				Message:  message,
+				Severity: severity,
+			})
+		}
+	}
+
+	sort.SliceStable(diagnostics, func(a, b int) bool {
+		if diagnostics[a].File != diagnostics[b].File {
+			return diagnostics[a].File < diagnostics[b].File
+		}
+		return diagnostics[a].Line < diagnostics[b].Line
+	})
+	return diagnostics
+}
+
+// parseStackBlock collects the stack trace that follows a panic or race header starting at lines[start].
+// The block ends at terminator (if non-empty) or at the first blank line after a stack frame.
+// The reported location is the first frame outside the Go runtime.
+func parseStackBlock(lines []string, start int, severity, message, terminator string) (GoDiagnostic, int) {
+	diag := GoDiagnostic{Message: message, Severity: severity}
+
+	i := start + 1
+	sawFrame := false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if terminator != "" && trimmed == terminator {
+			i++
+			break
+		}
+		if terminator == "" && sawFrame && (trimmed == "" || strings.HasPrefix(trimmed, "exit status") || strings.HasPrefix(trimmed, "FAIL")) {
+			break
+		}
+		if trimmed == "" {
+			continue
+		}
+
+		diag.Stack = append(diag.Stack, trimmed)
+		if match := goStackFileRegex.FindStringSubmatch(line); match != nil {
+			sawFrame = true
+			if diag.File == "" && !strings.Contains(match[1], "/src/runtime/") {
+				diag.File = match[1]
+				diag.Line, _ = strconv.Atoi(match[2])
+			}
+		}
+	}
+
+	return diag, i
+}
+
+// --- GoComplexityTool ---
+
+// DefaultComplexityThreshold is the cyclomatic complexity above which a function is flagged.
+const DefaultComplexityThreshold = 10
+
+// GoComplexityTool reports the cyclomatic complexity of the functions in a Go file or package.
+type GoComplexityTool struct {
+	pathGuard
+}
+
+func (t *GoComplexityTool) Name() string {
+	return "go_complexity"
+}
+
+func (t *GoComplexityTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *GoComplexityTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *GoComplexityTool) Description() string {
+	return "Reports the cyclomatic complexity of every function in a Go file or package directory, most complex first. Functions above the threshold are marked with ⚠. Usage: {\"path\": \"<file_or_directory>\", \"threshold\": 10}"
+}
+
+func (t *GoComplexityTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "A .go file or a package directory (not searched recursively).",
+			},
+			"threshold": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: Functions with a higher complexity are flagged. Defaults to 10.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type GoComplexityArgs struct {
+	Path      string `json:"path"`
+	Threshold int    `json:"threshold"`
+}
+
+// FunctionComplexity is the cyclomatic complexity of a single function or method.
+type FunctionComplexity struct {
+	Func       string
+	File       string
+	Line       int
+	Complexity int
+}
+
+func (t *GoComplexityTool) Execute(args string) (string, error) {
+	var toolArgs GoComplexityArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for go_complexity: %w", err)
+	}
+
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for go_complexity")
+	}
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+	if toolArgs.Threshold <= 0 {
+		toolArgs.Threshold = DefaultComplexityThreshold
+	}
+
+	results, err := AnalyzeComplexity(toolArgs.Path)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return fmt.Sprintf("No functions found in %s.", toolArgs.Path), nil
+	}
+	return FormatComplexity(results, toolArgs.Threshold), nil
+}
+
+// AnalyzeComplexity parses a Go file, or every .go file in a directory, and returns the
+// complexity of each function sorted from most to least complex.
+func AnalyzeComplexity(path string) ([]FunctionComplexity, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading '%s': %w", path, err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.go"))
+		if err != nil {
+			return nil, fmt.Errorf("error listing Go files in '%s': %w", path, err)
+		}
+	}
+
+	var results []FunctionComplexity
+	fset := token.NewFileSet()
+	for _, file := range files {
+		node, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing '%s': %w", file, err)
+		}
+
+		for _, decl := range node.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			results = append(results, FunctionComplexity{
+				Func:       funcDisplayName(fn),
+				File:       file,
+				Line:       fset.Position(fn.Pos()).Line,
+				Complexity: cyclomaticComplexity(fn.Body),
+			})
+		}
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].Complexity > results[b].Complexity
+	})
+	return results, nil
+}
+
+// FormatComplexity renders results as an aligned table, marking functions above threshold.
+func FormatComplexity(results []FunctionComplexity, threshold int) string {
+	var output strings.Builder
+	w := tabwriter.NewWriter(&output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FUNC\tFILE\tLINE\tCOMPLEXITY\t")
+	for _, r := range results {
+		marker := ""
+		if r.Complexity > threshold {
+			marker = "⚠"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", r.Func, r.File, r.Line, r.Complexity, marker)
+	}
+	w.Flush()
+	return strings.TrimRight(output.String(), "\n")
+}
+
+// cyclomaticComplexity counts the decision points in body, plus one for the function itself.
+// Function literals are counted as part of the enclosing function.
+func cyclomaticComplexity(body *ast.BlockStmt) int {
+	complexity := 1
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if node.List != nil { // default does not add a path
+				complexity++
+			}
+		case *ast.CommClause:
+			if node.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if node.Op == token.LAND || node.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+// funcDisplayName returns "Name" for functions and "Type.Name" for methods.
+func funcDisplayName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	// Strip type parameters from generic receivers such as List[T].
+	switch r := recv.(type) {
+	case *ast.IndexExpr:
+		recv = r.X
+	case *ast.IndexListExpr:
+		recv = r.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
+
+// --- GenerateGoTestsTool ---
+
+// GenerateGoTestsTool writes table-driven test skeletons for functions in a Go file.
+type GenerateGoTestsTool struct {
+	pathGuard
+}
+
+func (t *GenerateGoTestsTool) Name() string {
+	return "generate_go_tests"
+}
+
+func (t *GenerateGoTestsTool) RequiresConfirmation() bool {
+	return true // Creates or modifies a _test.go file
+}
+
+func (t *GenerateGoTestsTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *GenerateGoTestsTool) Description() string {
+	return "Generates table-driven test skeletons for the given functions of a Go file and writes them to the matching _test.go file. Methods are named as Type.Method. If the test file exists, only tests that are missing are appended. Usage: {\"file\": \"<path/to/file.go>\", \"functions\": [\"Add\", \"Parser.Parse\"]}"
+}
+
+func (t *GenerateGoTestsTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"file": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The Go source file containing the functions.",
+			},
+			"functions": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "The functions to generate tests for. Use Type.Method for methods.",
+			},
+		},
+		"required": []string{"file", "functions"},
+	}
+}
+
+type GenerateGoTestsArgs struct {
+	File      string   `json:"file"`
+	Functions []string `json:"functions"`
+}
+
+func (t *GenerateGoTestsTool) Execute(args string) (string, error) {
+	var toolArgs GenerateGoTestsArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for generate_go_tests: %w", err)
+	}
+
+	if toolArgs.File == "" || len(toolArgs.Functions) == 0 {
+		return "", fmt.Errorf("file and functions arguments are required for generate_go_tests")
+	}
+	if !strings.HasSuffix(toolArgs.File, ".go") || strings.HasSuffix(toolArgs.File, "_test.go") {
+		return "", fmt.Errorf("file must be a non-test .go file, got '%s'", toolArgs.File)
+	}
+	if err := t.checkPath(toolArgs.File); err != nil {
+		return "", err
+	}
+
+	fset := token.NewFileSet()
+	src, err := parser.ParseFile(fset, toolArgs.File, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return "", fmt.Errorf("error parsing '%s': %w", toolArgs.File, err)
+	}
+
+	decls := make(map[string]*ast.FuncDecl)
+	for _, decl := range src.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			decls[funcDisplayName(fn)] = fn
+		}
+	}
+
+	testPath := strings.TrimSuffix(toolArgs.File, ".go") + "_test.go"
+	if err := t.checkPath(testPath); err != nil {
+		return "", err
+	}
+	existingTests := make(map[string]bool)
+	var existing *ast.File
+	existingSrc, err := os.ReadFile(testPath)
+	if err == nil {
+		existing, err = parser.ParseFile(token.NewFileSet(), testPath, existingSrc, parser.SkipObjectResolution)
+		if err != nil {
+			return "", fmt.Errorf("error parsing existing test file '%s': %w", testPath, err)
+		}
+		for _, decl := range existing.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+				existingTests[fn.Name.Name] = true
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("error reading file '%s': %w", testPath, err)
+	}
+
+	imports := map[string]string{"testing": ""} // Import path to the name it is imported as, if not the default
+	var body strings.Builder
+	var generated, skipped []string
+	for _, name := range toolArgs.Functions {
+		fn, ok := decls[name]
+		if !ok {
+			return "", fmt.Errorf("function '%s' not found in '%s'", name, toolArgs.File)
+		}
+		if fn.Type.TypeParams != nil && len(fn.Type.TypeParams.List) > 0 {
+			return "", fmt.Errorf("function '%s' is generic; generic functions are not supported", name)
+		}
+		if hasGenericReceiver(fn) {
+			return "", fmt.Errorf("method '%s' belongs to a generic type; methods of generic types are not supported", name)
+		}
+
+		testName := testFuncName(name)
+		if existingTests[testName] {
+			skipped = append(skipped, testName)
+			continue
+		}
+
+		body.WriteString("\n")
+		body.WriteString(testSkeleton(name, testName, fn))
+		for path, name := range typeImports(fn, src) {
+			imports[path] = name
+		}
+		if fn.Type.Results != nil && resultsNeedReflect(fn) {
+			imports["reflect"] = ""
+		}
+		existingTests[testName] = true
+		generated = append(generated, testName)
+	}
+
+	if len(generated) == 0 {
+		return fmt.Sprintf("All requested tests already exist in %s: %s", testPath, strings.Join(skipped, ", ")), nil
+	}
+
+	var out []byte
+	if existing == nil {
+		out = []byte(fmt.Sprintf("package %s\n\n%s%s", src.Name.Name, importBlock(imports, nil), body.String()))
+	} else {
+		// Add any imports the new tests need after the existing import declarations.
+		insertAt := int(existing.Name.End()) - 1
+		for _, decl := range existing.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				insertAt = int(gen.End()) - 1
+			}
+		}
+		block := importBlock(imports, existing.Imports)
+		out = []byte(string(existingSrc[:insertAt]) + "\n\n" + block + string(existingSrc[insertAt:]) + body.String())
+	}
+
+	formatted, err := format.Source(out)
+	if err != nil {
+		return "", fmt.Errorf("generated test code is not valid Go: %w", err)
+	}
+	if err := os.WriteFile(testPath, formatted, 0644); err != nil {
+		return "", fmt.Errorf("error writing to file '%s': %w", testPath, err)
+	}
+
+	result := fmt.Sprintf("Successfully wrote %s to %s", strings.Join(generated, ", "), testPath)
+	if len(skipped) > 0 {
+		result += fmt.Sprintf(" (already present: %s)", strings.Join(skipped, ", "))
+	}
+	return result, nil
+}
+
+// testFuncName follows the gotests convention: TestFoo, Test_foo and TestType_Method.
+func testFuncName(name string) string {
+	name = strings.ReplaceAll(name, ".", "_")
+	if ast.IsExported(name) {
+		return "Test" + name
+	}
+	return "Test_" + name
+}
+
+// testSkeleton renders a table-driven test for fn. Parameters become fields of the input
+// struct, results become expected fields, and a trailing error result becomes wantErr.
+func testSkeleton(name, testName string, fn *ast.FuncDecl) string {
+	var params []string   // "name type" for the input struct
+	var callArgs []string // arguments in the call expression
+	if fn.Type.Params != nil {
+		for i, field := range fn.Type.Params.List {
+			typ := field.Type
+			variadic := false
+			if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+				typ = &ast.ArrayType{Elt: ellipsis.Elt}
+				variadic = true
+			}
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{{Name: "_"}}
+			}
+			for j, ident := range names {
+				paramName := ident.Name
+				if paramName == "_" {
+					paramName = fmt.Sprintf("arg%d", i+j)
+				}
+				params = append(params, paramName+" "+types.ExprString(typ))
+				arg := "tt.input." + paramName
+				if variadic {
+					arg += "..."
+				}
+				callArgs = append(callArgs, arg)
+			}
+		}
+	}
+
+	var results []string // expected field types, excluding a trailing error
+	returnsErr := false
+	if fn.Type.Results != nil {
+		for _, field := range fn.Type.Results.List {
+			count := len(field.Names)
+			if count == 0 {
+				count = 1
+			}
+			for i := 0; i < count; i++ {
+				results = append(results, types.ExprString(field.Type))
+			}
+		}
+		if len(results) > 0 && results[len(results)-1] == "error" {
+			results = results[:len(results)-1]
+			returnsErr = true
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "func %s(t *testing.T) {\n", testName)
+	if len(params) > 0 {
+		b.WriteString("\ttype input struct {\n")
+		for _, p := range params {
+			fmt.Fprintf(&b, "\t\t%s\n", p)
+		}
+		b.WriteString("\t}\n")
+	}
+
+	b.WriteString("\ttests := []struct {\n\t\tname string\n")
+	if len(params) > 0 {
+		b.WriteString("\t\tinput input\n")
+	}
+	var got []string
+	for i, r := range results {
+		fmt.Fprintf(&b, "\t\t%s %s\n", expectedField(i), r)
+		got = append(got, gotVar(i))
+	}
+	if returnsErr {
+		b.WriteString("\t\twantErr bool\n")
+		got = append(got, "err")
+	}
+	b.WriteString("\t}{\n\t\t// TODO: Add test cases.\n\t}\n")
+
+	b.WriteString("\tfor _, tt := range tests {\n\t\tt.Run(tt.name, func(t *testing.T) {\n")
+	callee := fn.Name.Name
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		recvType := fn.Recv.List[0].Type
+		if star, ok := recvType.(*ast.StarExpr); ok {
+			recvType = star.X
+		}
+		fmt.Fprintf(&b, "\t\t\tvar receiver %s // TODO: Initialize the receiver.\n", types.ExprString(recvType))
+		callee = "receiver." + callee
+	}
+	call := fmt.Sprintf("%s(%s)", callee, strings.Join(callArgs, ", "))
+	if len(got) > 0 {
+		fmt.Fprintf(&b, "\t\t\t%s := %s\n", strings.Join(got, ", "), call)
+	} else {
+		fmt.Fprintf(&b, "\t\t\t%s\n", call)
+	}
+	if returnsErr {
+		fmt.Fprintf(&b, "\t\t\tif (err != nil) != tt.wantErr {\n\t\t\t\tt.Errorf(\"%s() error = %%v, wantErr %%v\", err, tt.wantErr)\n\t\t\t\treturn\n\t\t\t}\n", name)
+	}
+	for i, r := range results {
+		if isComparable(r) {
+			fmt.Fprintf(&b, "\t\t\tif %s != tt.%s {\n", gotVar(i), expectedField(i))
+		} else {
+			fmt.Fprintf(&b, "\t\t\tif !reflect.DeepEqual(%s, tt.%s) {\n", gotVar(i), expectedField(i))
+		}
+		fmt.Fprintf(&b, "\t\t\t\tt.Errorf(\"%s() %s = %%v, want %%v\", %s, tt.%s)\n\t\t\t}\n", name, gotVar(i), gotVar(i), expectedField(i))
+	}
+	b.WriteString("\t\t})\n\t}\n}\n")
+	return b.String()
+}
+
+func gotVar(i int) string {
+	if i == 0 {
+		return "got"
+	}
+	return fmt.Sprintf("got%d", i)
+}
+
+func expectedField(i int) string {
+	if i == 0 {
+		return "expected"
+	}
+	return fmt.Sprintf("expected%d", i)
+}
+
+// isComparable reports whether a result type can be checked with != instead of reflect.DeepEqual.
+func isComparable(typ string) bool {
+	switch typ {
+	case "bool", "string", "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+		"float32", "float64", "byte", "rune":
+		return true
+	}
+	return false
+}
+
+// resultsNeedReflect reports whether any non-error result needs reflect.DeepEqual.
+func resultsNeedReflect(fn *ast.FuncDecl) bool {
+	fields := fn.Type.Results.List
+	for i, field := range fields {
+		typ := types.ExprString(field.Type)
+		if i == len(fields)-1 && typ == "error" && len(field.Names) <= 1 {
+			continue
+		}
+		if !isComparable(typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasGenericReceiver reports whether fn is a method of a generic type, such as Stack[T].Push.
+func hasGenericReceiver(fn *ast.FuncDecl) bool {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return false
+	}
+	recvType := fn.Recv.List[0].Type
+	if star, ok := recvType.(*ast.StarExpr); ok {
+		recvType = star.X
+	}
+	switch recvType.(type) {
+	case *ast.IndexExpr, *ast.IndexListExpr:
+		return true
+	}
+	return false
+}
+
+// typeImports returns the packages referenced in fn's signature and receiver, mapping each
+// import path to the name file imports it as, or "" if that is the package's assumed name.
+func typeImports(fn *ast.FuncDecl, file *ast.File) map[string]string {
+	byName := make(map[string]string)
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := assumedPackageName(path)
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		byName[name] = path
+	}
+
+	imports := make(map[string]string)
+	collect := func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if pkg, ok := sel.X.(*ast.Ident); ok {
+				if path, ok := byName[pkg.Name]; ok {
+					imports[path] = ""
+					if pkg.Name != assumedPackageName(path) {
+						imports[path] = pkg.Name
+					}
+				}
+			}
+		}
+		return true
+	}
+	ast.Inspect(fn.Type, collect)
+	if fn.Recv != nil {
+		ast.Inspect(fn.Recv, collect)
+	}
+	return imports
+}
+
+// majorVersion matches the major version element of a module path, such as v2.
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// assumedPackageName guesses the name of the package at importPath the way goimports does:
+// the last path element without a major version suffix (".../v2" or "gopkg.in/yaml.v3"),
+// a "go-" prefix, or anything from the first character that cannot be in an identifier.
+func assumedPackageName(importPath string) string {
+	base := path.Base(importPath)
+	if majorVersion.MatchString(base) && path.Dir(importPath) != "." {
+		base = path.Base(path.Dir(importPath))
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// importBlock renders an import declaration for every path in imports that is not already
+// imported, with its name if it has one.
+func importBlock(imports map[string]string, existing []*ast.ImportSpec) string {
+	have := make(map[string]bool)
+	for _, imp := range existing {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		have[path] = true
+	}
+
+	var paths []string
+	for path := range imports {
+		if !have[path] {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return ""
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, path := range paths {
+		if name := imports[path]; name != "" {
+			fmt.Fprintf(&b, "\t%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// --- CheckLatestVersionTool ---
+
+// CheckLatestVersionTool compares a module's version in go.mod, or the running Go toolchain,
+// with the latest published release.
+type CheckLatestVersionTool struct {
+	ProxyURL     string // Module proxy to query; empty uses GOPROXY or proxy.golang.org
+	DownloadsURL string // Go release list in the go.dev/dl JSON format; empty uses go.dev
+}
+
+func (t *CheckLatestVersionTool) Name() string {
+	return "check_latest_version"
+}
+
+func (t *CheckLatestVersionTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *CheckLatestVersionTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *CheckLatestVersionTool) Description() string {
+	return "Looks up the latest released version of a Go module on the module proxy and compares it with the version required in go.mod. Pass \"go\" as the module to compare the Go toolchain with the latest stable release. Returns JSON: {module, current, latest, needs_update}. Usage: {\"module\": \"github.com/spf13/cobra\", \"go_mod\": \"<optional/path/to/go.mod>\"}"
+}
+
+func (t *CheckLatestVersionTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"module": map[string]any{
+				"type":        "string",
+				"description": "The module path, or \"go\" for the Go toolchain.",
+			},
+			"go_mod": map[string]any{
+				"type":        "string",
+				"description": "Optional: The go.mod file to read the current version from. Defaults to ./go.mod.",
+			},
+		},
+		"required": []string{"module"},
+	}
+}
+
+type CheckLatestVersionArgs struct {
+	Module string `json:"module"`
+	GoMod  string `json:"go_mod"`
+}
+
+// VersionCheck is the result of check_latest_version.
+type VersionCheck struct {
+	Module      string `json:"module"`
+	Current     string `json:"current"`
+	Latest      string `json:"latest"`
+	NeedsUpdate bool   `json:"needs_update"`
+}
+
+const (
+	defaultGoProxy   = "https://proxy.golang.org"
+	goDownloadsURL   = "https://go.dev/dl/?mode=json"
+	versionCacheTTL  = 5 * time.Minute
+	versionUserAgent = "tachigoma"
+)
+
+type cachedVersion struct {
+	version string
+	fetched time.Time
+}
+
+var (
+	versionCacheMu sync.Mutex
+	versionCache   = make(map[string]cachedVersion) // Keyed by request URL
+	versionClient  = &http.Client{Timeout: 15 * time.Second}
+)
+
+func (t *CheckLatestVersionTool) Execute(args string) (string, error) {
+	var toolArgs CheckLatestVersionArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for check_latest_version: %w", err)
+	}
+
+	if toolArgs.Module == "" {
+		return "", fmt.Errorf("module argument is required for check_latest_version")
+	}
+
+	var check VersionCheck
+	var err error
+	if toolArgs.Module == "go" {
+		downloadsURL := t.DownloadsURL
+		if downloadsURL == "" {
+			downloadsURL = goDownloadsURL
+		}
+		check, err = checkGoToolchain(downloadsURL)
+	} else {
+		goMod := toolArgs.GoMod
+		if goMod == "" {
+			goMod = "go.mod"
+		}
+		check, err = checkModule(toolArgs.Module, goMod, t.ProxyURL)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	out, err := json.MarshalIndent(check, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error encoding version check: %w", err)
+	}
+	return string(out), nil
+}
+
+// checkModule compares the version of module required by goMod with the latest one on proxy,
+// or on the proxy chosen by moduleProxy if proxy is empty.
+func checkModule(module, goMod, proxy string) (VersionCheck, error) {
+	current, err := requiredVersion(goMod, module)
+	if err != nil {
+		return VersionCheck{}, err
+	}
+
+	if proxy == "" {
+		if proxy, err = moduleProxy(module); err != nil {
+			return VersionCheck{}, err
+		}
+	}
+	proxy = strings.TrimSuffix(proxy, "/")
+
+	latest, err := cachedFetch(proxy+"/"+escapeModulePath(module)+"/@latest", func(body []byte) (string, error) {
+		var info struct {
+			Version string `json:"Version"`
+		}
+		if err := json.Unmarshal(body, &info); err != nil {
+			return "", fmt.Errorf("error decoding module proxy response: %w", err)
+		}
+		return info.Version, nil
+	})
+	if err != nil {
+		return VersionCheck{}, err
+	}
+
+	return VersionCheck{
+		Module:      module,
+		Current:     current,
+		Latest:      latest,
+		NeedsUpdate: current != "" && semver.Compare(current, latest) < 0,
+	}, nil
+}
+
+// checkGoToolchain compares the running Go version with the latest stable release listed at downloadsURL.
+func checkGoToolchain(downloadsURL string) (VersionCheck, error) {
+	latest, err := cachedFetch(downloadsURL, func(body []byte) (string, error) {
+		var releases []struct {
+			Version string `json:"version"`
+			Stable  bool   `json:"stable"`
+		}
+		if err := json.Unmarshal(body, &releases); err != nil {
+			return "", fmt.Errorf("error decoding Go releases: %w", err)
+		}
+		for _, r := range releases {
+			if r.Stable {
+				return r.Version, nil
+			}
+		}
+		return "", fmt.Errorf("no stable Go release found")
+	})
+	if err != nil {
+		return VersionCheck{}, err
+	}
+
+	current := runtime.Version()
+	return VersionCheck{
+		Module:  "go",
+		Current: current,
+		Latest:  latest,
+		// Development builds report something like "devel go1.24-abcdef" and are never flagged.
+		NeedsUpdate: strings.HasPrefix(current, "go") && version.Compare(current, latest) < 0,
+	}, nil
+}
+
+// requiredVersion returns the version of module required by goMod, or "" if it is not required.
+func requiredVersion(goMod, module string) (string, error) {
+	content, err := os.ReadFile(goMod)
+	if err != nil {
+		return "", fmt.Errorf("error reading '%s': %w", goMod, err)
+	}
+
+	inBlock := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimSpace(strings.TrimPrefix(line, "require "))
+		case !inBlock:
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == module {
+			return fields[1], nil
+		}
+	}
+	return "", nil
+}
+
+// moduleProxy returns the proxy to query for module, honouring GOPROXY and the
+// GOPRIVATE, GONOSUMDB and GONOSUMCHECK patterns for modules that must not be looked up publicly.
+// GOFLAGS=-mod=vendor also disables lookups, since dependencies are then managed by hand.
+func moduleProxy(module string) (string, error) {
+	for _, env := range []string{"GOPRIVATE", "GONOSUMDB", "GONOSUMCHECK"} {
+		if matchesModulePattern(os.Getenv(env), module) {
+			return "", fmt.Errorf("module '%s' matches %s; not querying the public module proxy", module, env)
+		}
+	}
+	for _, flag := range strings.Fields(os.Getenv("GOFLAGS")) {
+		if flag == "-mod=vendor" {
+			return "", fmt.Errorf("GOFLAGS contains -mod=vendor; not querying the module proxy")
+		}
+	}
+
+	goproxy := os.Getenv("GOPROXY")
+	if goproxy == "" {
+		return defaultGoProxy, nil
+	}
+	for _, entry := range strings.FieldsFunc(goproxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		switch entry {
+		case "off":
+			return "", fmt.Errorf("GOPROXY=off; module lookups are disabled")
+		case "direct":
+			continue
+		default:
+			return strings.TrimSuffix(entry, "/"), nil
+		}
+	}
+	return "", fmt.Errorf("GOPROXY=%s has no proxy to query", goproxy)
+}
+
+// matchesModulePattern reports whether module or one of its path prefixes matches
+// a comma-separated list of glob patterns, as used by GOPRIVATE.
+func matchesModulePattern(patterns, module string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		n := strings.Count(pattern, "/") + 1
+		parts := strings.SplitN(module, "/", n+1)
+		if len(parts) < n {
+			continue
+		}
+		prefix := strings.Join(parts[:n], "/")
+		if ok, _ := path.Match(pattern, prefix); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeModulePath applies the module proxy case encoding: each upper-case letter becomes "!" plus its lower-case form.
+func escapeModulePath(module string) string {
+	var b strings.Builder
+	for _, r := range module {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cachedFetch GETs url and extracts a version with parse, reusing results younger than versionCacheTTL.
+func cachedFetch(url string, parse func([]byte) (string, error)) (string, error) {
+	versionCacheMu.Lock()
+	entry, ok := versionCache[url]
+	versionCacheMu.Unlock()
+	if ok && time.Since(entry.fetched) < versionCacheTTL {
+		return entry.version, nil
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", versionUserAgent)
+
+	resp, err := versionClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error querying '%s': %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response from '%s': %w", url, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("'%s' returned status %d: %s", url, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	version, err := parse(body)
+	if err != nil {
+		return "", err
+	}
+
+	versionCacheMu.Lock()
+	versionCache[url] = cachedVersion{version: version, fetched: time.Now()}
+	versionCacheMu.Unlock()
+	return version, nil
+}
@@ -0,0 +1,323 @@
+package tools
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGoErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []GoDiagnostic
+	}{
+		{
+			name: "go build",
+			output: `# example.com/app
+./main.go:5:2: "os" imported and not used
+./main.go:16:6: cannot use T{} (value of struct type T) as I value in argument to use: T does not implement I (missing method M)
+		have m()
+		want M()
+./main.go:17:14: cannot use "s" (untyped string constant) as int value in variable declaration
+./main.go:18:14: undefined: undefinedName
+`,
+			want: []GoDiagnostic{
+				{File: "./main.go", Line: 5, Col: 2, Message: `"os" imported and not used`, Severity: "error"},
+				{File: "./main.go", Line: 16, Col: 6, Message: "cannot use T{} (value of struct type T) as I value in argument to use: T does not implement I (missing method M)\nhave m()\nwant M()", Severity: "error"},
+				{File: "./main.go", Line: 17, Col: 14, Message: `cannot use "s" (untyped string constant) as int value in variable declaration`, Severity: "error"},
+				{File: "./main.go", Line: 18, Col: 14, Message: "undefined: undefinedName", Severity: "error"},
+			},
+		},
+		{
+			name: "go vet",
+			output: `# example.com/app
+# [example.com/app]
+./main.go:7:14: fmt.Printf format %d has arg s of wrong type string
+./util.go:12:3: unreachable code
+`,
+			want: []GoDiagnostic{
+				{File: "./main.go", Line: 7, Col: 14, Message: "fmt.Printf format %d has arg s of wrong type string", Severity: "error"},
+				{File: "./util.go", Line: 12, Col: 3, Message: "unreachable code", Severity: "error"},
+			},
+		},
+		{
+			name: "go vet syntax error",
+			output: `# example.com/app
+# [example.com/app]
+vet: ./main.go:10:17: missing ',' before newline in argument list (and 1 more errors)
+`,
+			want: []GoDiagnostic{
+				{File: "./main.go", Line: 10, Col: 17, Message: "missing ',' before newline in argument list (and 1 more errors)", Severity: "vet"},
+			},
+		},
+		{
+			name: "grouped by file and line",
+			output: `./b.go:3:1: second
+./a.go:9:1: third
+./a.go:2:1: first
+`,
+			want: []GoDiagnostic{
+				{File: "./a.go", Line: 2, Col: 1, Message: "first", Severity: "error"},
+				{File: "./a.go", Line: 9, Col: 1, Message: "third", Severity: "error"},
+				{File: "./b.go", Line: 3, Col: 1, Message: "second", Severity: "error"},
+			},
+		},
+		{
+			name: "panic",
+			output: `panic: runtime error: index out of range [3] with length 3
+
+goroutine 1 [running]:
+main.main()
+	/home/user/app/main.go:8 +0x1d
+exit status 2
+`,
+			want: []GoDiagnostic{
+				{
+					File:     "/home/user/app/main.go",
+					Line:     8,
+					Message:  "runtime error: index out of range [3] with length 3",
+					Severity: "panic",
+					Stack:    []string{"goroutine 1 [running]:", "main.main()", "/home/user/app/main.go:8 +0x1d"},
+				},
+			},
+		},
+		{
+			name:   "no diagnostics",
+			output: "ok  \texample.com/app\t0.002s\n",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseGoErrors(tt.output)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGoErrors() =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+// generateTestsSource is a small package with functions, methods and types that need imports.
+const generateTestsSource = `package calc
+
+import (
+	"context"
+	"io"
+	str "strings"
+	"time"
+
+	"example.com/calc/lib/store/v2"
+	"example.com/calc/lib/yaml.v3"
+)
+
+func Add(a, b int) int { return a + b }
+
+func Divide(a, b float64) (float64, error) { return a / b, nil }
+
+func Wait(ctx context.Context, d time.Duration) error { return nil }
+
+func Copy(w io.Writer, data []byte, opts ...string) (int, error) { return 0, nil }
+
+type Stack[T any] struct{ items []T }
+
+func (s *Stack[T]) Push(v T) { s.items = append(s.items, v) }
+
+type Parser struct{}
+
+func Build(b *str.Builder, n *yaml.Node, s store.Store) error { return nil }
+
+func (p Parser) Parse(input string) (map[string]int, error) { return nil, nil }
+`
+
+func TestGenerateGoTestsCompiles(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/calc\n\ngo 1.23\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "calc.go")
+	packages := map[string]string{
+		file: generateTestsSource,
+		filepath.Join(dir, "lib", "yaml.v3", "yaml.go"):      "package yaml\n\ntype Node struct{}\n",
+		filepath.Join(dir, "lib", "store", "v2", "store.go"): "package store\n\ntype Store interface{}\n",
+	}
+	for path, src := range packages {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tool := &GenerateGoTestsTool{}
+	generate := func(functions ...string) {
+		t.Helper()
+		args, _ := json.Marshal(GenerateGoTestsArgs{File: file, Functions: functions})
+		if _, err := tool.Execute(string(args)); err != nil {
+			t.Fatalf("Execute(%v) error = %v", functions, err)
+		}
+	}
+	// The second call appends to the existing test file
+	generate("Add", "Divide", "Wait")
+	generate("Add", "Copy", "Parser.Parse", "Build")
+
+	args, _ := json.Marshal(GenerateGoTestsArgs{File: file, Functions: []string{"Stack.Push"}})
+	if _, err := tool.Execute(string(args)); err == nil || !strings.Contains(err.Error(), "generic type") {
+		t.Errorf("Execute(Stack.Push) error = %v, want an error about generic types", err)
+	}
+
+	testSrc, err := os.ReadFile(filepath.Join(dir, "calc_test.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(testSrc), "func TestAdd("); n != 1 {
+		t.Errorf("calc_test.go has %d TestAdd functions, want 1", n)
+	}
+
+	cmd := exec.Command("go", "vet", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated tests do not compile: %v\n%s\n--- calc_test.go ---\n%s", err, output, testSrc)
+	}
+}
+
+func TestCheckLatestVersion(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/github.com/!burnt!sushi/toml/@latest":
+			io.WriteString(w, `{"Version": "v1.4.0", "Time": "2024-06-12T19:05:11Z"}`)
+		case "/github.com/spf13/cobra/@latest":
+			io.WriteString(w, `{"Version": "v1.8.1", "Time": "2024-06-01T00:00:00Z"}`)
+		case "/example.com/pre/@latest":
+			io.WriteString(w, `{"Version": "v1.0.0-rc.10"}`)
+		case "/example.com/broken/@latest":
+			io.WriteString(w, `not json`)
+		default:
+			http.Error(w, "not found: "+r.URL.Path, http.StatusNotFound)
+		}
+	}))
+	defer proxy.Close()
+
+	goMod := filepath.Join(t.TempDir(), "go.mod")
+	if err := os.WriteFile(goMod, []byte(`module example.com/app
+
+go 1.23
+
+require github.com/spf13/cobra v1.8.1
+
+require (
+	github.com/BurntSushi/toml v1.3.2 // indirect
+	example.com/broken v0.1.0
+	example.com/pre v1.0.0-rc.9
+)
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		module  string
+		want    VersionCheck
+		wantErr string
+	}{
+		{
+			name:   "outdated, with case-encoded path",
+			module: "github.com/BurntSushi/toml",
+			want:   VersionCheck{Module: "github.com/BurntSushi/toml", Current: "v1.3.2", Latest: "v1.4.0", NeedsUpdate: true},
+		},
+		{
+			name:   "up to date",
+			module: "github.com/spf13/cobra",
+			want:   VersionCheck{Module: "github.com/spf13/cobra", Current: "v1.8.1", Latest: "v1.8.1"},
+		},
+		{
+			name:   "pre-release numbers compare numerically",
+			module: "example.com/pre",
+			want:   VersionCheck{Module: "example.com/pre", Current: "v1.0.0-rc.9", Latest: "v1.0.0-rc.10", NeedsUpdate: true},
+		},
+		{name: "unknown module", module: "example.com/missing", wantErr: "status 404"},
+		{name: "invalid response", module: "example.com/broken", wantErr: "error decoding module proxy response"},
+	}
+
+	tool := &CheckLatestVersionTool{ProxyURL: proxy.URL}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(CheckLatestVersionArgs{Module: tt.module, GoMod: goMod})
+			out, err := tool.Execute(string(args))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Execute() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			var got VersionCheck
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("Execute() returned invalid JSON: %v\n%s", err, out)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLatestVersionGoToolchain(t *testing.T) {
+	downloads := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[
+			{"version": "go2.0rc1", "stable": false},
+			{"version": "go1.999.0", "stable": true},
+			{"version": "go1.998.5", "stable": true}
+		]`)
+	}))
+	defer downloads.Close()
+
+	tool := &CheckLatestVersionTool{DownloadsURL: downloads.URL}
+	out, err := tool.Execute(`{"module": "go"}`)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	var got VersionCheck
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("Execute() returned invalid JSON: %v\n%s", err, out)
+	}
+	if got.Latest != "go1.999.0" {
+		t.Errorf("Latest = %q, want the first stable release go1.999.0", got.Latest)
+	}
+	if strings.HasPrefix(got.Current, "go") && !got.NeedsUpdate {
+		t.Errorf("NeedsUpdate = false for %s older than %s", got.Current, got.Latest)
+	}
+}
+
+func TestAssumedPackageName(t *testing.T) {
+	tests := map[string]string{
+		"fmt":                         "fmt",
+		"net/http":                    "http",
+		"gopkg.in/yaml.v3":            "yaml",
+		"github.com/go-chi/chi/v5":    "chi",
+		"github.com/mattn/go-sqlite3": "sqlite3",
+		"github.com/santhosh-tekuri/jsonschema/v6": "jsonschema",
+		"v2": "v2",
+	}
+	for importPath, want := range tests {
+		if got := assumedPackageName(importPath); got != want {
+			t.Errorf("assumedPackageName(%q) = %q, want %q", importPath, got, want)
+		}
+	}
+}
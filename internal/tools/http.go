@@ -0,0 +1,348 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// --- HTTPRequestTool ---
+
+// HTTPRequestTool sends an HTTP request and returns the status, key headers and (truncated) body.
+type HTTPRequestTool struct {
+	// Timeout bounds the whole request. Zero means DefaultHTTPTimeout.
+	Timeout time.Duration
+}
+
+// DefaultHTTPTimeout is used when HTTPRequestTool.Timeout is not set.
+const DefaultHTTPTimeout = 30 * time.Second
+
+// defaultHTTPMaxBytes is the number of body bytes returned when max_bytes is not given.
+const defaultHTTPMaxBytes = 8 * 1024
+
+func (t *HTTPRequestTool) Name() string {
+	return "http_request"
+}
+
+func (t *HTTPRequestTool) RequiresConfirmation() bool {
+	return true // Sends data to, and fetches content from, arbitrary hosts
+}
+
+func (t *HTTPRequestTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *HTTPRequestTool) Description() string {
+	return "Sends an HTTP request, e.g. to read documentation or call a JSON API, and returns the status code, Content-Type, Content-Length and the response body truncated to max_bytes (default 8192). Usage: {\"url\": \"<url>\", \"method\": \"GET\", \"headers\": {\"Accept\": \"application/json\"}, \"body\": \"<optional_body>\"}"
+}
+
+func (t *HTTPRequestTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The URL to request, including the scheme.",
+			},
+			"method": map[string]any{
+				"type":        "string",
+				"description": "Optional: The HTTP method. Defaults to GET.",
+			},
+			"headers": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+				"description":          "Optional: Request headers.",
+			},
+			"body": map[string]any{
+				"type":        "string",
+				"description": "Optional: The request body.",
+			},
+			"max_bytes": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: The maximum number of body bytes to return. Defaults to 8192.",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+type HTTPRequestArgs struct {
+	URL      string            `json:"url"`
+	Method   string            `json:"method"`
+	Headers  map[string]string `json:"headers"`
+	Body     string            `json:"body"`
+	MaxBytes int               `json:"max_bytes"`
+}
+
+func (t *HTTPRequestTool) Execute(args string) (string, error) {
+	var toolArgs HTTPRequestArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for http_request: %w", err)
+	}
+
+	if toolArgs.URL == "" {
+		return "", fmt.Errorf("url argument is required for http_request")
+	}
+	method := strings.ToUpper(toolArgs.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	maxBytes := toolArgs.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultHTTPMaxBytes
+	}
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+
+	var body io.Reader
+	if toolArgs.Body != "" {
+		body = strings.NewReader(toolArgs.Body)
+	}
+	req, err := http.NewRequest(method, toolArgs.URL, body)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	for key, value := range toolArgs.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read one byte more than allowed to find out whether the body was truncated.
+	content, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %w", err)
+	}
+	truncated := len(content) > maxBytes
+	if truncated {
+		content = content[:maxBytes]
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Status: %s\n", resp.Status))
+	output.WriteString(fmt.Sprintf("Content-Type: %s\n", resp.Header.Get("Content-Type")))
+	if length := resp.Header.Get("Content-Length"); length != "" {
+		output.WriteString(fmt.Sprintf("Content-Length: %s\n", length))
+	}
+	output.WriteString("\n")
+	output.Write(content)
+	if truncated {
+		output.WriteString(fmt.Sprintf("\n... (body truncated to %d bytes)", maxBytes))
+	}
+	return output.String(), nil
+}
+
+// --- WebFetchTool ---
+
+// WebFetchTool downloads a URL and returns its content as readable text.
+type WebFetchTool struct{}
+
+const (
+	webFetchTimeout = 15 * time.Second
+	// webFetchMaxRead bounds how much of a page is downloaded before its text is extracted.
+	webFetchMaxRead = 2 * 1024 * 1024
+)
+
+func (t *WebFetchTool) Name() string {
+	return "web_fetch"
+}
+
+func (t *WebFetchTool) RequiresConfirmation() bool {
+	return true // Fetches content from arbitrary hosts
+}
+
+func (t *WebFetchTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *WebFetchTool) Description() string {
+	return "Downloads a web page or document and returns it as text, e.g. to read documentation. HTML is reduced to its readable text; JSON and plain text are returned as is; binary files are only described. Usage: {\"url\": \"<url>\", \"max_bytes\": 8192}"
+}
+
+func (t *WebFetchTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The URL to fetch, including the scheme.",
+			},
+			"max_bytes": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: The maximum number of bytes of text to return. Defaults to 8192.",
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+type WebFetchArgs struct {
+	URL      string `json:"url"`
+	MaxBytes int    `json:"max_bytes"`
+}
+
+func (t *WebFetchTool) Execute(args string) (string, error) {
+	var toolArgs WebFetchArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for web_fetch: %w", err)
+	}
+
+	if toolArgs.URL == "" {
+		return "", fmt.Errorf("url argument is required for web_fetch")
+	}
+	maxBytes := toolArgs.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultHTTPMaxBytes
+	}
+
+	client := &http.Client{
+		Timeout:   webFetchTimeout,
+		Transport: &http.Transport{Proxy: http.ProxyFromEnvironment},
+	}
+	resp, err := client.Get(toolArgs.URL)
+	if err != nil {
+		return "", fmt.Errorf("error fetching '%s': %w", toolArgs.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("fetching '%s' failed with status %s: %s", toolArgs.URL, resp.Status, string(snippet))
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, webFetchMaxRead))
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %w", err)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var text string
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		text = htmlToText(string(content))
+	case isTextMediaType(mediaType):
+		text = string(content)
+	default:
+		size := "unknown size"
+		if resp.ContentLength >= 0 {
+			size = fmt.Sprintf("%d bytes", resp.ContentLength)
+		}
+		return fmt.Sprintf("Binary content (%s, %s) at %s; the body is not shown.", mediaType, size, toolArgs.URL), nil
+	}
+
+	if len(text) > maxBytes {
+		// Cut before the rune that straddles the limit rather than through it
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + fmt.Sprintf("\n... (truncated to %d bytes)", maxBytes)
+	}
+	return text, nil
+}
+
+// isTextMediaType reports whether content of the media type can be returned as is.
+func isTextMediaType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript", "application/x-yaml", "application/yaml", "application/toml":
+		return true
+	}
+	return false
+}
+
+// htmlToText extracts the readable text of an HTML document. Scripts, styles and other
+// non-content elements are dropped, and block elements start new lines.
+func htmlToText(document string) string {
+	skipped := map[string]bool{"script": true, "style": true, "noscript": true, "svg": true, "template": true, "head": true}
+	blocks := map[string]bool{
+		"p": true, "div": true, "br": true, "li": true, "tr": true, "pre": true, "section": true, "article": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "blockquote": true, "table": true,
+	}
+
+	var b strings.Builder
+	var title string
+	inTitle := false
+	skipDepth := 0
+
+	tokenizer := html.NewTokenizer(strings.NewReader(document))
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			return formatExtractedText(title, b.String())
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if tag == "title" {
+				inTitle = true
+			}
+			if skipped[tag] && tokenType == html.StartTagToken {
+				skipDepth++
+			}
+			if blocks[tag] {
+				b.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if tag == "title" {
+				inTitle = false
+			}
+			if skipped[tag] && skipDepth > 0 {
+				skipDepth--
+			}
+			if blocks[tag] {
+				b.WriteString("\n")
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			} else if skipDepth == 0 {
+				b.Write(tokenizer.Text())
+			}
+		}
+	}
+}
+
+// formatExtractedText collapses the whitespace of extracted text and drops empty lines.
+func formatExtractedText(title, text string) string {
+	var lines []string
+	if title = strings.Join(strings.Fields(title), " "); title != "" {
+		lines = append(lines, "# "+title, "")
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWebFetchTruncatesOnRuneBoundary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, "ab日本語")
+	}))
+	defer server.Close()
+
+	// The limit falls in the middle of 日, which takes three bytes
+	args, _ := json.Marshal(WebFetchArgs{URL: server.URL, MaxBytes: 4})
+	result, err := (&WebFetchTool{}).Execute(string(args))
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !utf8.ValidString(result) {
+		t.Errorf("result %q is not valid UTF-8", result)
+	}
+	if text, _, _ := strings.Cut(result, "\n"); text != "ab" {
+		t.Errorf("truncated text = %q, want %q", text, "ab")
+	}
+}
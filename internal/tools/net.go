@@ -0,0 +1,324 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// dnsTimeout bounds every DNS lookup.
+const dnsTimeout = 5 * time.Second
+
+// maxCheckedPorts caps the ports port_check probes in one call.
+const maxCheckedPorts = 20
+
+// defaultPortTimeout is how long port_check waits for each connection by default.
+const defaultPortTimeout = 5 * time.Second
+
+// dnsRecordTypes are the record types dns_lookup can query.
+var dnsRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "TXT", "NS"}
+
+// --- DNSLookupTool ---
+
+// DNSLookupTool resolves hostnames and queries DNS records with the system resolver.
+type DNSLookupTool struct{}
+
+func (t *DNSLookupTool) Name() string {
+	return "dns_lookup"
+}
+
+func (t *DNSLookupTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *DNSLookupTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *DNSLookupTool) Description() string {
+	return "Queries DNS records of a host and returns them as a table. Supports A, AAAA, CNAME, MX, TXT and NS records. Usage: {\"host\": \"example.com\", \"record_type\": \"MX\"}"
+}
+
+func (t *DNSLookupTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"host": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The hostname or domain to look up.",
+			},
+			"record_type": map[string]any{
+				"type":        "string",
+				"enum":        dnsRecordTypes,
+				"description": "Optional: The record type to query. Defaults to A.",
+			},
+		},
+		"required": []string{"host"},
+	}
+}
+
+type DNSLookupArgs struct {
+	Host       string `json:"host"`
+	RecordType string `json:"record_type"`
+}
+
+// dnsRecord is one row of the lookup result.
+type dnsRecord struct {
+	Type  string
+	Value string
+}
+
+func (t *DNSLookupTool) Execute(args string) (string, error) {
+	var toolArgs DNSLookupArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for dns_lookup: %w. Expected JSON: {\"host\": \"...\", \"record_type\": \"A\"}", err)
+	}
+
+	host := strings.TrimSuffix(strings.TrimSpace(toolArgs.Host), ".")
+	if host == "" {
+		return "", fmt.Errorf("host argument is required for dns_lookup")
+	}
+	recordType := strings.ToUpper(toolArgs.RecordType)
+	if recordType == "" {
+		recordType = "A"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+	defer cancel()
+
+	records, err := lookupRecords(ctx, host, recordType)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+			if ctx.Err() != nil {
+				return "", fmt.Errorf("DNS lookup of %s timed out after %s", host, dnsTimeout)
+			}
+			return "", fmt.Errorf("error looking up %s records of %s: %w", recordType, host, err)
+		}
+		// The resolver reports missing records like a missing host, so check whether
+		// the host resolves at all to tell the two apart
+		if _, hostErr := net.DefaultResolver.LookupHost(ctx, host); hostErr != nil {
+			return "", fmt.Errorf("host %s does not exist", host)
+		}
+		records = nil
+	}
+
+	if len(records) == 0 {
+		return "", fmt.Errorf("host %s exists but has no %s records", host, recordType)
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tTYPE\tVALUE")
+	for _, record := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", host, record.Type, record.Value)
+	}
+	w.Flush()
+	return b.String(), nil
+}
+
+// lookupRecords queries the records of recordType for host.
+func lookupRecords(ctx context.Context, host, recordType string) ([]dnsRecord, error) {
+	resolver := net.DefaultResolver
+	var records []dnsRecord
+
+	switch recordType {
+	case "A", "AAAA":
+		addrs, err := resolver.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				continue
+			}
+			if isV4 := ip.To4() != nil; isV4 == (recordType == "A") {
+				records = append(records, dnsRecord{recordType, addr})
+			}
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		// A host without an alias is its own canonical name
+		if cname = strings.TrimSuffix(cname, "."); !strings.EqualFold(cname, host) {
+			records = append(records, dnsRecord{"CNAME", cname})
+		}
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, dnsRecord{"MX", fmt.Sprintf("%d %s", mx.Pref, strings.TrimSuffix(mx.Host, "."))})
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, txt := range txts {
+			records = append(records, dnsRecord{"TXT", fmt.Sprintf("%q", txt)})
+		}
+	case "NS":
+		nss, err := resolver.LookupNS(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range nss {
+			records = append(records, dnsRecord{"NS", strings.TrimSuffix(ns.Host, ".")})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported record type '%s' (expected one of %s)", recordType, strings.Join(dnsRecordTypes, ", "))
+	}
+	return records, nil
+}
+
+// --- PortCheckTool ---
+
+// PortCheckTool tests whether TCP ports of a host accept connections.
+type PortCheckTool struct{}
+
+func (t *PortCheckTool) Name() string {
+	return "port_check"
+}
+
+func (t *PortCheckTool) RequiresConfirmation() bool {
+	return true // Port scans can trigger intrusion detection alerts
+}
+
+func (t *PortCheckTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *PortCheckTool) Description() string {
+	return fmt.Sprintf("Tries a TCP connection to each port of a host and returns a table of port, status (open, closed or filtered) and latency. At most %d ports per call. Requires user confirmation. Usage: {\"host\": \"example.com\", \"ports\": [22, 443], \"timeout_seconds\": 5}", maxCheckedPorts)
+}
+
+func (t *PortCheckTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"host": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The hostname, IPv4 or IPv6 address to connect to.",
+			},
+			"ports": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "integer", "minimum": 1, "maximum": 65535},
+				"minItems":    1,
+				"maxItems":    maxCheckedPorts,
+				"description": fmt.Sprintf("The TCP ports to check, at most %d.", maxCheckedPorts),
+			},
+			"timeout_seconds": map[string]any{
+				"type":        "integer",
+				"minimum":     1,
+				"maximum":     30,
+				"description": "Optional: How long to wait for each connection. Defaults to 5.",
+			},
+		},
+		"required": []string{"host", "ports"},
+	}
+}
+
+type PortCheckArgs struct {
+	Host           string `json:"host"`
+	Ports          []int  `json:"ports"`
+	TimeoutSeconds int    `json:"timeout_seconds"`
+}
+
+// portResult is the outcome of connecting to one port.
+type portResult struct {
+	Status  string
+	Latency time.Duration
+}
+
+func (t *PortCheckTool) Execute(args string) (string, error) {
+	var toolArgs PortCheckArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for port_check: %w. Expected JSON: {\"host\": \"...\", \"ports\": [22, 443]}", err)
+	}
+
+	// Accept IPv6 addresses written in URL form, like [::1]
+	host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(toolArgs.Host), "["), "]")
+	if host == "" {
+		return "", fmt.Errorf("host argument is required for port_check")
+	}
+	if len(toolArgs.Ports) == 0 {
+		return "", fmt.Errorf("ports argument is required for port_check")
+	}
+	if len(toolArgs.Ports) > maxCheckedPorts {
+		return "", fmt.Errorf("too many ports: %d (at most %d per call)", len(toolArgs.Ports), maxCheckedPorts)
+	}
+	for _, port := range toolArgs.Ports {
+		if port < 1 || port > 65535 {
+			return "", fmt.Errorf("invalid port %d (expected 1-65535)", port)
+		}
+	}
+
+	timeout := defaultPortTimeout
+	if toolArgs.TimeoutSeconds > 0 {
+		timeout = time.Duration(toolArgs.TimeoutSeconds) * time.Second
+	}
+
+	// Resolve once up front, so an unknown host fails clearly instead of once per port
+	if net.ParseIP(host) == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+		_, err := net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+		if err != nil {
+			return "", fmt.Errorf("error resolving host %s: %w", host, err)
+		}
+	}
+
+	results := make([]portResult, len(toolArgs.Ports))
+	var wg sync.WaitGroup
+	for i, port := range toolArgs.Ports {
+		wg.Add(1)
+		go func(i, port int) {
+			defer wg.Done()
+			results[i] = checkPort(host, port, timeout)
+		}(i, port)
+	}
+	wg.Wait()
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PORT\tSTATUS\tLATENCY")
+	for i, port := range toolArgs.Ports {
+		latency := "-"
+		if results[i].Status != "filtered" {
+			latency = fmt.Sprintf("%d ms", results[i].Latency.Milliseconds())
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", port, results[i].Status, latency)
+	}
+	w.Flush()
+	return b.String(), nil
+}
+
+// checkPort connects to host:port. A refused connection means the port is closed;
+// no answer within timeout usually means a firewall drops the packets.
+func checkPort(host string, port int, timeout time.Duration) portResult {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, fmt.Sprint(port)), timeout)
+	latency := time.Since(start)
+	if err == nil {
+		conn.Close()
+		return portResult{Status: "open", Latency: latency}
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return portResult{Status: "closed", Latency: latency}
+	}
+	return portResult{Status: "filtered", Latency: latency}
+}
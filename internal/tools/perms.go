@@ -0,0 +1,345 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// --- GetPermissionsTool ---
+
+// GetPermissionsTool reports the mode bits and ownership of a file or directory.
+type GetPermissionsTool struct {
+	pathGuard
+}
+
+func (t *GetPermissionsTool) Name() string {
+	return "get_permissions"
+}
+
+func (t *GetPermissionsTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *GetPermissionsTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *GetPermissionsTool) Description() string {
+	return "Shows the permissions of a file or directory in symbolic (e.g. -rwxr-xr-x) and octal (e.g. 0755) form, along with its owner and group. Usage: {\"path\": \"<path>\"}"
+}
+
+func (t *GetPermissionsTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the file or directory.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type GetPermissionsArgs struct {
+	Path string `json:"path"`
+}
+
+func (t *GetPermissionsTool) Execute(args string) (string, error) {
+	var toolArgs GetPermissionsArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for get_permissions: %w", err)
+	}
+
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for get_permissions")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading file info for '%s': %w", toolArgs.Path, err)
+	}
+
+	owner, group := fileOwner(info)
+	return fmt.Sprintf("Path: %s\nMode: %s\nOctal: %04o\nOwner: %s\nGroup: %s",
+		toolArgs.Path, info.Mode().String(), info.Mode().Perm(), owner, group), nil
+}
+
+// --- SetPermissionsTool ---
+
+// SetPermissionsTool changes the mode bits of a file or directory, optionally recursively.
+type SetPermissionsTool struct {
+	pathGuard
+}
+
+func (t *SetPermissionsTool) Name() string {
+	return "set_permissions"
+}
+
+func (t *SetPermissionsTool) RequiresConfirmation() bool {
+	return true
+}
+
+func (t *SetPermissionsTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *SetPermissionsTool) Description() string {
+	return "Changes the permissions of a file or directory. The mode is either octal (e.g. \"755\") or symbolic like chmod (e.g. \"u+x\", \"go-w\", \"a=r,u+w\"). Reports the previous and new mode in symbolic and octal form. Usage: {\"path\": \"<path>\", \"mode\": \"<mode>\", \"recursive\": false}"
+}
+
+func (t *SetPermissionsTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the file or directory.",
+			},
+			"mode": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The new mode, in octal (\"644\") or chmod symbolic notation (\"u+x\").",
+			},
+			"recursive": map[string]any{
+				"type":        "boolean",
+				"description": "Optional: Apply the mode to everything under a directory as well. Defaults to false.",
+			},
+		},
+		"required": []string{"path", "mode"},
+	}
+}
+
+type SetPermissionsArgs struct {
+	Path      string `json:"path"`
+	Mode      string `json:"mode"`
+	Recursive bool   `json:"recursive"`
+}
+
+func (t *SetPermissionsTool) Execute(args string) (string, error) {
+	var toolArgs SetPermissionsArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for set_permissions: %w", err)
+	}
+
+	if toolArgs.Path == "" || toolArgs.Mode == "" {
+		return "", fmt.Errorf("path and mode arguments are required for set_permissions")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	// Parse once up front so a bad mode fails before anything is changed.
+	if _, err := applyMode(toolArgs.Mode, 0); err != nil {
+		return "", err
+	}
+
+	chmod := func(path string, mode fs.FileMode) error {
+		newMode, err := applyMode(toolArgs.Mode, mode.Perm())
+		if err != nil {
+			return err
+		}
+		if err := os.Chmod(path, newMode); err != nil {
+			return fmt.Errorf("error changing mode of '%s': %w", path, err)
+		}
+		return nil
+	}
+
+	if !toolArgs.Recursive {
+		info, err := os.Stat(toolArgs.Path)
+		if err != nil {
+			return "", fmt.Errorf("error reading file info for '%s': %w", toolArgs.Path, err)
+		}
+		previous := info.Mode()
+		if err := chmod(toolArgs.Path, previous); err != nil {
+			return "", err
+		}
+		info, _ = os.Stat(toolArgs.Path)
+		return fmt.Sprintf("Successfully changed mode of %s from %s (%04o) to %s (%04o)", toolArgs.Path, previous.String(), previous.Perm(), info.Mode().String(), info.Mode().Perm()), nil
+	}
+
+	count := 0
+	err := filepath.WalkDir(toolArgs.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil // Chmod would follow the link and change its target
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := chmod(path, info.Mode()); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error changing mode under '%s' (%d entries changed): %w", toolArgs.Path, count, err)
+	}
+	return fmt.Sprintf("Successfully changed mode of %d entries under %s", count, toolArgs.Path), nil
+}
+
+// applyMode returns current modified by spec, which is either an octal mode or a
+// comma-separated list of chmod-style symbolic clauses such as "u+x" or "go=r".
+func applyMode(spec string, current fs.FileMode) (fs.FileMode, error) {
+	spec = strings.TrimSpace(spec)
+	if octal, err := strconv.ParseUint(spec, 8, 32); err == nil {
+		if octal > 0777 {
+			return 0, fmt.Errorf("invalid mode '%s': only permission bits (up to 777) are supported", spec)
+		}
+		return fs.FileMode(octal), nil
+	}
+
+	mode := current.Perm()
+	for _, clause := range strings.Split(spec, ",") {
+		opIndex := strings.IndexAny(clause, "+-=")
+		if opIndex < 0 {
+			return 0, fmt.Errorf("invalid mode '%s': expected octal digits or a clause like u+x", spec)
+		}
+
+		var who fs.FileMode
+		for _, c := range clause[:opIndex] {
+			switch c {
+			case 'u':
+				who |= 0700
+			case 'g':
+				who |= 0070
+			case 'o':
+				who |= 0007
+			case 'a':
+				who |= 0777
+			default:
+				return 0, fmt.Errorf("invalid mode '%s': unknown class '%c'", spec, c)
+			}
+		}
+		if who == 0 {
+			who = 0777 // "+x" means "a+x"
+		}
+
+		var perm fs.FileMode
+		for _, c := range clause[opIndex+1:] {
+			switch c {
+			case 'r':
+				perm |= 0444
+			case 'w':
+				perm |= 0222
+			case 'x':
+				perm |= 0111
+			default:
+				return 0, fmt.Errorf("invalid mode '%s': unknown permission '%c'", spec, c)
+			}
+		}
+
+		switch clause[opIndex] {
+		case '+':
+			mode |= who & perm
+		case '-':
+			mode &^= who & perm
+		case '=':
+			mode = mode&^who | who&perm
+		}
+	}
+	return mode, nil
+}
+
+// --- SetOwnerTool ---
+
+// SetOwnerTool changes the owner and/or group of a file or directory. It is not supported on Windows.
+type SetOwnerTool struct {
+	pathGuard
+}
+
+func (t *SetOwnerTool) Name() string {
+	return "set_owner"
+}
+
+func (t *SetOwnerTool) RequiresConfirmation() bool {
+	return true
+}
+
+func (t *SetOwnerTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *SetOwnerTool) Description() string {
+	return "Changes the owner and/or group of a file or directory (Unix only). Users and groups may be given by name or numeric ID; omit one to leave it unchanged. Symlinks themselves are changed, not their targets. Reports the previous and new owner. Usage: {\"path\": \"<path>\", \"user\": \"<user>\", \"group\": \"<group>\"}"
+}
+
+func (t *SetOwnerTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The path to the file or directory.",
+			},
+			"user": map[string]any{
+				"type":        "string",
+				"description": "Optional: The new owner, by name or UID.",
+			},
+			"group": map[string]any{
+				"type":        "string",
+				"description": "Optional: The new group, by name or GID.",
+			},
+		},
+		"required": []string{"path"},
+	}
+}
+
+type SetOwnerArgs struct {
+	Path  string `json:"path"`
+	User  string `json:"user"`
+	Group string `json:"group"`
+}
+
+func (t *SetOwnerTool) Execute(args string) (string, error) {
+	var toolArgs SetOwnerArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for set_owner: %w", err)
+	}
+
+	if toolArgs.Path == "" {
+		return "", fmt.Errorf("path argument is required for set_owner")
+	}
+
+	if err := t.checkPath(toolArgs.Path); err != nil {
+		return "", err
+	}
+
+	if toolArgs.User == "" && toolArgs.Group == "" {
+		return "", fmt.Errorf("at least one of user or group is required for set_owner")
+	}
+
+	before, err := os.Lstat(toolArgs.Path)
+	if err != nil {
+		return "", fmt.Errorf("error reading file info for '%s': %w", toolArgs.Path, err)
+	}
+	previousOwner, previousGroup := fileOwner(before)
+
+	if err := setOwner(toolArgs.Path, toolArgs.User, toolArgs.Group); err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(toolArgs.Path)
+	if err != nil {
+		return fmt.Sprintf("Successfully changed ownership of %s", toolArgs.Path), nil
+	}
+	owner, group := fileOwner(info)
+	return fmt.Sprintf("Successfully changed ownership of %s from %s:%s to %s:%s", toolArgs.Path, previousOwner, previousGroup, owner, group), nil
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSetPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows only supports the read-only bit")
+	}
+
+	tests := []struct {
+		name     string
+		initial  fs.FileMode
+		mode     string
+		wantMode fs.FileMode
+		wantErr  string
+	}{
+		{name: "octal", initial: 0644, mode: "0600", wantMode: 0600},
+		{name: "octal without leading zero", initial: 0600, mode: "755", wantMode: 0755},
+		{name: "symbolic add", initial: 0644, mode: "u+x", wantMode: 0744},
+		{name: "symbolic remove", initial: 0755, mode: "go-rx", wantMode: 0700},
+		{name: "symbolic set", initial: 0777, mode: "u=rw,go=r", wantMode: 0644},
+		{name: "implicit all", initial: 0600, mode: "+x", wantMode: 0711},
+		{name: "invalid class", initial: 0644, mode: "z+x", wantMode: 0644, wantErr: "unknown class"},
+		{name: "invalid permission", initial: 0644, mode: "u+q", wantMode: 0644, wantErr: "unknown permission"},
+		{name: "too large", initial: 0644, mode: "7777", wantMode: 0644, wantErr: "only permission bits"},
+		{name: "not a mode", initial: 0644, mode: "rwx", wantMode: 0644, wantErr: "expected octal digits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file.txt")
+			if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, tt.initial); err != nil {
+				t.Fatal(err)
+			}
+
+			args, _ := json.Marshal(SetPermissionsArgs{Path: path, Mode: tt.mode})
+			_, err := (&SetPermissionsTool{}).Execute(string(args))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Execute() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := info.Mode().Perm(); got != tt.wantMode {
+				t.Errorf("mode = %04o, want %04o", got, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestSetPermissionsRecursive(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows only supports the read-only bit")
+	}
+
+	root := t.TempDir()
+	nested := filepath.Join(root, "sub", "file.sh")
+	if err := os.MkdirAll(filepath.Dir(nested), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(nested, []byte("#!/bin/sh\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args, _ := json.Marshal(SetPermissionsArgs{Path: root, Mode: "o-rx", Recursive: true})
+	if _, err := (&SetPermissionsTool{}).Execute(string(args)); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	for path, want := range map[string]fs.FileMode{filepath.Dir(nested): 0750, nested: 0640} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("mode of %s = %04o, want %04o", path, got, want)
+		}
+	}
+}
+
+func TestSetPermissionsOutsideBasePath(t *testing.T) {
+	base := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(outside, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := &SetPermissionsTool{}
+	tool.SetBasePath(base)
+	args, _ := json.Marshal(SetPermissionsArgs{Path: outside, Mode: "0600"})
+	_, err := tool.Execute(string(args))
+	if err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Fatalf("Execute() error = %v, want access denied", err)
+	}
+
+	info, err := os.Stat(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0644 {
+		t.Errorf("mode of file outside the base path changed to %04o", got)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build !windows
+
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// fileOwner returns the owner and group names of a file, falling back to numeric IDs.
+func fileOwner(info fs.FileInfo) (string, string) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "unknown", "unknown"
+	}
+
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	gid := strconv.FormatUint(uint64(stat.Gid), 10)
+	owner, group := uid, gid
+	if u, err := user.LookupId(uid); err == nil {
+		owner = u.Username
+	}
+	if g, err := user.LookupGroupId(gid); err == nil {
+		group = g.Name
+	}
+	return owner, group
+}
+
+// setOwner changes the owner and group of path. An empty user or group is left unchanged.
+func setOwner(path, userName, groupName string) error {
+	uid, gid := -1, -1
+
+	if userName != "" {
+		id, err := strconv.Atoi(userName)
+		if err != nil {
+			u, lookupErr := user.Lookup(userName)
+			if lookupErr != nil {
+				return fmt.Errorf("unknown user '%s': %w", userName, lookupErr)
+			}
+			id, _ = strconv.Atoi(u.Uid)
+		}
+		uid = id
+	}
+
+	if groupName != "" {
+		id, err := strconv.Atoi(groupName)
+		if err != nil {
+			g, lookupErr := user.LookupGroup(groupName)
+			if lookupErr != nil {
+				return fmt.Errorf("unknown group '%s': %w", groupName, lookupErr)
+			}
+			id, _ = strconv.Atoi(g.Gid)
+		}
+		gid = id
+	}
+
+	if err := os.Lchown(path, uid, gid); err != nil {
+		return fmt.Errorf("error changing ownership of '%s': %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// fileOwner is not available on Windows, where ownership is expressed through ACLs.
+func fileOwner(info fs.FileInfo) (string, string) {
+	return "not supported on Windows", "not supported on Windows"
+}
+
+func setOwner(path, userName, groupName string) error {
+	return fmt.Errorf("set_owner is not supported on Windows")
+}
@@ -0,0 +1,82 @@
+//go:build (linux || darwin || freebsd) && cgo
+
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+)
+
+// LoadPlugins opens every *.so file in dir as a Go plugin and returns the tools they provide.
+//
+// A plugin is a main package built with `go build -buildmode=plugin` against the same
+// version of this module. It must export:
+//
+//	var ToolAPIVersion = tools.ToolAPIVersion
+//	func ToolFactory() tools.Tool
+//
+// Plugins that cannot be loaded are reported in the returned errors and skipped.
+func LoadPlugins(dir string) ([]Tool, []error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, []error{fmt.Errorf("error reading plugin directory '%s': %w", dir, err)}
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return nil, []error{fmt.Errorf("error listing plugins in '%s': %w", dir, err)}
+	}
+	sort.Strings(paths)
+
+	var loaded []Tool
+	var errs []error
+	for _, path := range paths {
+		tool, err := loadPlugin(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error loading plugin '%s': %w", path, err))
+			continue
+		}
+		loaded = append(loaded, tool)
+	}
+	return loaded, errs
+}
+
+func loadPlugin(path string) (Tool, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, err := p.Lookup("ToolAPIVersion")
+	if err != nil {
+		return nil, err
+	}
+	version, ok := sym.(*int)
+	if !ok {
+		return nil, fmt.Errorf("ToolAPIVersion is a %T, expected an int variable", sym)
+	}
+	if *version != ToolAPIVersion {
+		return nil, fmt.Errorf("plugin was built for tool API version %d, this build uses version %d", *version, ToolAPIVersion)
+	}
+
+	sym, err = p.Lookup("ToolFactory")
+	if err != nil {
+		return nil, err
+	}
+	var factory func() Tool
+	switch f := sym.(type) {
+	case func() Tool:
+		factory = f
+	case *func() Tool: // Declared as a variable
+		factory = *f
+	default:
+		return nil, fmt.Errorf("ToolFactory is a %T, expected func() tools.Tool", sym)
+	}
+
+	tool := factory()
+	if tool == nil {
+		return nil, fmt.Errorf("ToolFactory returned nil")
+	}
+	return tool, nil
+}
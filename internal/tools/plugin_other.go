@@ -0,0 +1,11 @@
+//go:build !((linux || darwin || freebsd) && cgo)
+
+package tools
+
+import "fmt"
+
+// LoadPlugins is not available on this platform: Go plugins require Linux, macOS or
+// FreeBSD and a build with cgo enabled.
+func LoadPlugins(dir string) ([]Tool, []error) {
+	return nil, []error{fmt.Errorf("tool plugins are not supported on this platform, ignoring '%s'", dir)}
+}
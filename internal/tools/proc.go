@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// processInfo describes a running process. CPU and Mem are preformatted as the
+// platform reports them, e.g. "1.5" percent on Unix or "12,345 K" on Windows.
+type processInfo struct {
+	PID     int
+	CPU     string
+	Mem     string
+	Command string
+	cpu     float64 // Used for sorting
+}
+
+// maxProcesses caps the number of processes listed, busiest first.
+const maxProcesses = 200
+
+// --- ListProcessesTool ---
+
+// ListProcessesTool lists running processes with their CPU and memory usage.
+type ListProcessesTool struct{}
+
+func (t *ListProcessesTool) Name() string {
+	return "list_processes"
+}
+
+func (t *ListProcessesTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *ListProcessesTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *ListProcessesTool) Description() string {
+	return "Lists running processes as a table of PID, CPU%, MEM% and command, busiest first. Usage: {}"
+}
+
+func (t *ListProcessesTool) Parameters() any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+func (t *ListProcessesTool) Execute(args string) (string, error) {
+	processes, err := listProcesses()
+	if err != nil {
+		return "", err
+	}
+
+	sort.SliceStable(processes, func(i, j int) bool {
+		return processes[i].cpu > processes[j].cpu
+	})
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PID\t%s\t%s\tCOMMAND\n", cpuHeader, memHeader)
+	for i, p := range processes {
+		if i == maxProcesses {
+			break
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", p.PID, p.CPU, p.Mem, p.Command)
+	}
+	w.Flush()
+
+	if len(processes) > maxProcesses {
+		fmt.Fprintf(&b, "... %d more processes not shown\n", len(processes)-maxProcesses)
+	}
+	return b.String(), nil
+}
+
+// --- KillProcessTool ---
+
+// KillProcessTool sends a signal to a process.
+type KillProcessTool struct{}
+
+func (t *KillProcessTool) Name() string {
+	return "kill_process"
+}
+
+func (t *KillProcessTool) RequiresConfirmation() bool {
+	return true
+}
+
+func (t *KillProcessTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *KillProcessTool) Description() string {
+	return "Sends a signal to a process, by default TERM to ask it to exit. Use KILL only if TERM does not work. On Windows TERM ends the process and KILL forces it. Usage: {\"pid\": 1234, \"signal\": \"TERM\"}"
+}
+
+func (t *KillProcessTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pid": map[string]any{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "The ID of the process.",
+			},
+			"signal": map[string]any{
+				"type":        "string",
+				"enum":        []string{"TERM", "KILL", "INT", "HUP", "QUIT", "USR1", "USR2"},
+				"description": "Optional: The signal to send. Defaults to TERM.",
+			},
+		},
+		"required": []string{"pid"},
+	}
+}
+
+type KillProcessArgs struct {
+	PID    int    `json:"pid"`
+	Signal string `json:"signal"`
+}
+
+func (t *KillProcessTool) Execute(args string) (string, error) {
+	var toolArgs KillProcessArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for kill_process: %w", err)
+	}
+
+	if toolArgs.PID <= 0 {
+		return "", fmt.Errorf("pid must be a positive process ID")
+	}
+	signal := strings.TrimPrefix(strings.ToUpper(toolArgs.Signal), "SIG")
+	if signal == "" {
+		signal = "TERM"
+	}
+
+	if err := killProcess(toolArgs.PID, signal); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Sent %s to process %d", signal, toolArgs.PID), nil
+}
@@ -0,0 +1,67 @@
+//go:build !windows
+
+package tools
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	cpuHeader = "CPU%"
+	memHeader = "MEM%"
+)
+
+// signals maps the signal names accepted by kill_process to their values.
+var signals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+	"INT":  syscall.SIGINT,
+	"HUP":  syscall.SIGHUP,
+	"QUIT": syscall.SIGQUIT,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// listProcesses runs ps, which works the same way on Linux and macOS.
+func listProcesses() ([]processInfo, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,pcpu=,pmem=,comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running ps: %w", err)
+	}
+
+	var processes []processInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(fields[1], 64)
+		processes = append(processes, processInfo{
+			PID:     pid,
+			CPU:     fields[1],
+			Mem:     fields[2],
+			Command: strings.Join(fields[3:], " "), // Command names may contain spaces
+			cpu:     cpu,
+		})
+	}
+	return processes, nil
+}
+
+func killProcess(pid int, signal string) error {
+	sig, ok := signals[signal]
+	if !ok {
+		return fmt.Errorf("unsupported signal '%s'", signal)
+	}
+	if err := syscall.Kill(pid, sig); err != nil {
+		return fmt.Errorf("error sending SIG%s to process %d: %w", signal, pid, err)
+	}
+	return nil
+}
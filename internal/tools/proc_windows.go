@@ -0,0 +1,56 @@
+//go:build windows
+
+package tools
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// tasklist does not report CPU usage, only the memory in use.
+const (
+	cpuHeader = "CPU%"
+	memHeader = "MEM"
+)
+
+// listProcesses runs tasklist and parses its CSV output.
+func listProcesses() ([]processInfo, error) {
+	out, err := exec.Command("tasklist", "/FO", "CSV", "/NH").Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running tasklist: %w", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(string(out))).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing tasklist output: %w", err)
+	}
+
+	var processes []processInfo
+	for _, record := range records {
+		// "Image Name","PID","Session Name","Session#","Mem Usage"
+		if len(record) < 5 {
+			continue
+		}
+		pid, err := strconv.Atoi(record[1])
+		if err != nil {
+			continue
+		}
+		processes = append(processes, processInfo{PID: pid, CPU: "-", Mem: record[4], Command: record[0]})
+	}
+	return processes, nil
+}
+
+// killProcess uses taskkill. Windows has no signals: KILL forces termination, anything else asks politely.
+func killProcess(pid int, signal string) error {
+	args := []string{"/PID", strconv.Itoa(pid)}
+	if signal == "KILL" {
+		args = append(args, "/F")
+	}
+	if out, err := exec.Command("taskkill", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("error ending process %d: %w\n%s", pid, err, string(out))
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"go.yaml.in/yaml/v3"
+)
+
+//go:embed scaffold/*.yaml
+var scaffoldTemplates embed.FS
+
+// --- InitGoProjectTool ---
+
+// InitGoProjectTool scaffolds a new Go project from one of the embedded templates.
+type InitGoProjectTool struct {
+	pathGuard
+}
+
+func (t *InitGoProjectTool) Name() string {
+	return "init_go_project"
+}
+
+func (t *InitGoProjectTool) RequiresConfirmation() bool {
+	return true // Creates a directory tree and runs `go mod init`
+}
+
+func (t *InitGoProjectTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+func (t *InitGoProjectTool) Description() string {
+	return "Scaffolds a new Go project in a new directory and runs `go mod init`. Templates: cli, http-server, library, grpc-service. Usage: {\"name\": \"<project_dir>\", \"module_path\": \"<module_path>\", \"template\": \"<template>\"}"
+}
+
+func (t *InitGoProjectTool) Parameters() any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The name of the project. A directory with this name is created in the current working directory.",
+			},
+			"module_path": map[string]any{
+				"type":        "string",
+				"minLength":   1,
+				"description": "The Go module path, e.g. \"github.com/user/project\".",
+			},
+			"template": map[string]any{
+				"type":        "string",
+				"description": "The project template to use.",
+				"enum":        []string{"cli", "http-server", "library", "grpc-service"},
+			},
+		},
+		"required": []string{"name", "module_path", "template"},
+	}
+}
+
+type InitGoProjectArgs struct {
+	Name       string `json:"name"`
+	ModulePath string `json:"module_path"`
+	Template   string `json:"template"`
+}
+
+// scaffoldTemplate is the YAML layout of an embedded project template.
+type scaffoldTemplate struct {
+	Description string `yaml:"description"`
+	Files       []struct {
+		Path    string `yaml:"path"`
+		Content string `yaml:"content"`
+	} `yaml:"files"`
+}
+
+// scaffoldData is the data passed to every file path and content template.
+type scaffoldData struct {
+	Name        string // The target directory as given, e.g. ./tmp/my-cli
+	BaseName    string // Its last element, e.g. my-cli, for command names and titles
+	ModulePath  string
+	PackageName string
+}
+
+// scaffoldFile is a rendered file ready to be written to disk.
+type scaffoldFile struct {
+	Path    string
+	Content string
+}
+
+func (t *InitGoProjectTool) Execute(args string) (string, error) {
+	var toolArgs InitGoProjectArgs
+	if err := json.Unmarshal([]byte(args), &toolArgs); err != nil {
+		return "", fmt.Errorf("invalid arguments for init_go_project: %w", err)
+	}
+
+	if toolArgs.Name == "" || toolArgs.ModulePath == "" || toolArgs.Template == "" {
+		return "", fmt.Errorf("name, module_path, and template arguments are required for init_go_project")
+	}
+	if err := t.checkPath(toolArgs.Name); err != nil {
+		return "", err
+	}
+
+	baseName := filepath.Base(filepath.Clean(toolArgs.Name))
+	data := scaffoldData{
+		Name:        toolArgs.Name,
+		BaseName:    baseName,
+		ModulePath:  toolArgs.ModulePath,
+		PackageName: packageName(baseName),
+	}
+
+	files, err := renderScaffold(scaffoldTemplates, toolArgs.Template, data)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(toolArgs.Name); err == nil {
+		return "", fmt.Errorf("directory '%s' already exists", toolArgs.Name)
+	}
+
+	if err := os.MkdirAll(toolArgs.Name, 0755); err != nil {
+		return "", fmt.Errorf("error creating directory '%s': %w", toolArgs.Name, err)
+	}
+	// Remove the partly created project on failure, so the call can simply be retried
+	created := false
+	defer func() {
+		if !created {
+			os.RemoveAll(toolArgs.Name)
+		}
+	}()
+
+	var written []string
+	for _, file := range files {
+		target := filepath.Join(toolArgs.Name, filepath.FromSlash(file.Path))
+		if err := t.checkPath(target); err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return "", fmt.Errorf("error creating directory for '%s': %w", target, err)
+		}
+		if err := os.WriteFile(target, []byte(file.Content), 0644); err != nil {
+			return "", fmt.Errorf("error writing file '%s': %w", target, err)
+		}
+		written = append(written, file.Path)
+	}
+
+	cmd := exec.Command("go", "mod", "init", toolArgs.ModulePath)
+	cmd.Dir = toolArgs.Name
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("go mod init failed: %v\nOutput:\n%s", err, string(output))
+	}
+	written = append(written, "go.mod")
+	created = true
+
+	return fmt.Sprintf("Created %s project '%s' (module %s):\n%s", toolArgs.Template, toolArgs.Name, toolArgs.ModulePath, fileTree(toolArgs.Name, written)), nil
+}
+
+// renderScaffold loads the named template from fsys and renders every file path and content with data.
+func renderScaffold(fsys fs.FS, name string, data scaffoldData) ([]scaffoldFile, error) {
+	raw, err := fs.ReadFile(fsys, path.Join("scaffold", name+".yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown template '%s': expected one of cli, http-server, library, grpc-service", name)
+	}
+
+	var tmpl scaffoldTemplate
+	if err := yaml.Unmarshal(raw, &tmpl); err != nil {
+		return nil, fmt.Errorf("error parsing template '%s': %w", name, err)
+	}
+
+	var files []scaffoldFile
+	for _, file := range tmpl.Files {
+		filePath, err := renderText(file.Path, data)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering path '%s': %w", file.Path, err)
+		}
+		content, err := renderText(file.Content, data)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering '%s': %w", file.Path, err)
+		}
+		files = append(files, scaffoldFile{Path: filePath, Content: content})
+	}
+
+	return files, nil
+}
+
+func renderText(text string, data scaffoldData) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// packageName turns a project name into a valid Go package name, e.g. "my-lib" becomes "mylib".
+func packageName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 || unicode.IsDigit(rune(b.String()[0])) {
+		return "pkg" + b.String()
+	}
+	return b.String()
+}
+
+// fileTree renders a list of slash-separated relative paths as an indented tree under root.
+func fileTree(root string, paths []string) string {
+	sort.Strings(paths)
+
+	var b strings.Builder
+	b.WriteString(root + "/\n")
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		parts := strings.Split(p, "/")
+		for i := range parts {
+			key := strings.Join(parts[:i+1], "/")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			name := parts[i]
+			if i < len(parts)-1 {
+				name += "/"
+			}
+			b.WriteString(strings.Repeat("  ", i+1) + name + "\n")
+		}
+	}
+	return b.String()
+}
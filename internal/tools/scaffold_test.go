@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRenderScaffold(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scaffold/basic.yaml": {Data: []byte(`description: A test template.
+files:
+  - path: main.go
+    content: |
+      package main
+
+      import "{{.ModulePath}}/internal/{{.PackageName}}"
+  - path: "internal/{{.PackageName}}/{{.PackageName}}.go"
+    content: |
+      // Package {{.PackageName}} belongs to {{.BaseName}} in {{.Name}}.
+      package {{.PackageName}}
+`)},
+		"scaffold/missing-key.yaml": {Data: []byte(`files:
+  - path: main.go
+    content: "{{.Author}}"
+`)},
+		"scaffold/broken.yaml": {Data: []byte("files: [\n")},
+	}
+	data := scaffoldData{Name: "./tmp/my-app", BaseName: "my-app", ModulePath: "example.com/my-app", PackageName: "myapp"}
+
+	tests := []struct {
+		name      string
+		template  string
+		wantFiles map[string]string
+		wantErr   string
+	}{
+		{
+			name:     "renders paths and contents",
+			template: "basic",
+			wantFiles: map[string]string{
+				"main.go":                 "package main\n\nimport \"example.com/my-app/internal/myapp\"\n",
+				"internal/myapp/myapp.go": "// Package myapp belongs to my-app in ./tmp/my-app.\npackage myapp\n",
+			},
+		},
+		{name: "unknown template", template: "nope", wantErr: "unknown template 'nope'"},
+		{name: "missing key", template: "missing-key", wantErr: "error rendering 'main.go'"},
+		{name: "invalid yaml", template: "broken", wantErr: "error parsing template 'broken'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := renderScaffold(fsys, tt.template, data)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("renderScaffold() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderScaffold() error = %v", err)
+			}
+			if len(files) != len(tt.wantFiles) {
+				t.Fatalf("renderScaffold() returned %d files, want %d", len(files), len(tt.wantFiles))
+			}
+			for _, file := range files {
+				want, ok := tt.wantFiles[file.Path]
+				if !ok {
+					t.Errorf("unexpected file %q", file.Path)
+					continue
+				}
+				if file.Content != want {
+					t.Errorf("content of %q = %q, want %q", file.Path, file.Content, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderScaffoldEmbeddedTemplates(t *testing.T) {
+	data := scaffoldData{Name: "./tmp/demo", BaseName: "demo", ModulePath: "example.com/demo", PackageName: "demo"}
+	for _, name := range []string{"cli", "http-server", "library", "grpc-service"} {
+		t.Run(name, func(t *testing.T) {
+			files, err := renderScaffold(scaffoldTemplates, name, data)
+			if err != nil {
+				t.Fatalf("renderScaffold(%q) error = %v", name, err)
+			}
+			if len(files) == 0 {
+				t.Fatalf("renderScaffold(%q) returned no files", name)
+			}
+			for _, file := range files {
+				if strings.Contains(file.Path+file.Content, "./tmp") {
+					t.Errorf("%s uses the target directory instead of the project name", file.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestInitGoProjectRemovesDirectoryOnFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "demo")
+	// An empty PATH makes `go mod init` fail after the files were written
+	t.Setenv("PATH", "")
+
+	tool := &InitGoProjectTool{}
+	args, _ := json.Marshal(InitGoProjectArgs{Name: dir, ModulePath: "example.com/demo", Template: "library"})
+	if _, err := tool.Execute(string(args)); err == nil {
+		t.Fatal("Execute() succeeded without a go binary")
+	}
+	if _, statErr := os.Stat(dir); !os.IsNotExist(statErr) {
+		t.Errorf("project directory was left behind: stat error = %v", statErr)
+	}
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// pathGuard restricts a file-system tool to one directory tree. Tools embed it to
+// implement PathRestricted; the zero value allows every path.
+type pathGuard struct {
+	basePath string
+}
+
+// SetBasePath restricts the tool to paths inside dir. An empty dir lifts the restriction.
+func (g *pathGuard) SetBasePath(dir string) {
+	g.basePath = dir
+}
+
+// checkPath returns an error if path is outside the tool's base path.
+func (g *pathGuard) checkPath(path string) error {
+	if g.basePath == "" {
+		return nil
+	}
+	return validatePath(path, g.basePath)
+}
+
+// validatePath returns an error if path, once made absolute and with symbolic links resolved,
+// is not basePath or inside it. Relative paths are resolved against the working directory,
+// as the file tools do.
+func validatePath(path, basePath string) error {
+	absBase, err := resolvePath(basePath)
+	if err != nil {
+		return fmt.Errorf("error resolving allowed base path '%s': %w", basePath, err)
+	}
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return fmt.Errorf("error resolving path '%s': %w", path, err)
+	}
+
+	// Compare against the base with a trailing separator so /srv/app does not allow /srv/application
+	prefix := absBase
+	if !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+	if absPath != absBase && !strings.HasPrefix(absPath, prefix) {
+		return fmt.Errorf("access denied: '%s' is outside the allowed directory '%s'", path, absBase)
+	}
+	return nil
+}
+
+// resolvePath makes path absolute and resolves the symbolic links in its deepest existing
+// ancestor, so a link inside the base path cannot point a tool outside it. The components
+// that do not exist yet are appended unchanged. A broken link is an error, since writing
+// through it would create its target.
+func resolvePath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if _, err := os.Lstat(path); err == nil {
+			return "", fmt.Errorf("'%s' is a broken symbolic link", path)
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return filepath.Join(append([]string{path}, missing...)...), nil
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
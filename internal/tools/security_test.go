@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidatePath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links needs extra privileges on Windows")
+	}
+
+	base := filepath.Join(t.TempDir(), "app")
+	outside := t.TempDir()
+	for _, dir := range []string{filepath.Join(base, "src"), filepath.Join(base + "lication")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"escape":      outside,                         // Directory outside the base
+		"escape-file": filepath.Join(outside, "x.txt"), // Missing file outside: a broken link
+		"inner":       filepath.Join(base, "src"),      // Stays inside the base
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(base, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	linkedBase := filepath.Join(t.TempDir(), "linked")
+	if err := os.Symlink(base, linkedBase); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		base    string
+		wantErr string
+	}{
+		{name: "base itself", path: base, base: base},
+		{name: "existing file", path: filepath.Join(base, "src"), base: base},
+		{name: "new file", path: filepath.Join(base, "src", "new", "file.go"), base: base},
+		{name: "dot dot", path: filepath.Join(base, "src", "..", "..", "etc"), base: base, wantErr: "access denied"},
+		{name: "sibling with the same prefix", path: base + "lication", base: base, wantErr: "access denied"},
+		{name: "link to a directory outside", path: filepath.Join(base, "escape", "x.txt"), base: base, wantErr: "access denied"},
+		{name: "broken link to a file outside", path: filepath.Join(base, "escape-file"), base: base, wantErr: "broken symbolic link"},
+		{name: "link inside the base", path: filepath.Join(base, "inner", "main.go"), base: base},
+		{name: "base given through a link", path: filepath.Join(base, "src"), base: linkedBase},
+		{name: "path given through the linked base", path: filepath.Join(linkedBase, "escape"), base: base, wantErr: "access denied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePath(tt.path, tt.base)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validatePath() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validatePath() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteFileThroughSymlinkOutsideBase(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links needs extra privileges on Windows")
+	}
+
+	base, outside := t.TempDir(), t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(base, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	tool := &WriteFileTool{}
+	tool.SetBasePath(base)
+	args, _ := json.Marshal(WriteFileArgs{Path: filepath.Join(base, "escape", "pwned.txt"), Content: "x"})
+	if _, err := tool.Execute(string(args)); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Fatalf("Execute() error = %v, want access denied", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "pwned.txt")); !os.IsNotExist(err) {
+		t.Errorf("file was written outside the base path: stat error = %v", err)
+	}
+}
@@ -1,20 +1,50 @@
 package tools
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"regexp"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // RunShellCommandTool defines the tool for executing shell commands.
-type RunShellCommandTool struct{}
+type RunShellCommandTool struct {
+	// Timeout is used when the call does not set timeout_seconds. Zero means DefaultShellTimeout.
+	Timeout time.Duration
+	// ShellCommandDenylist holds patterns of commands that are never run. A pattern matches
+	// if the command contains it, or, for patterns starting with "re:", if the rest of the
+	// pattern is a regular expression that matches the command. Nil means DefaultShellDenylist.
+	ShellCommandDenylist []string
+}
+
+// DefaultShellTimeout is used when neither the call nor RunShellCommandTool.Timeout sets a timeout.
+const DefaultShellTimeout = 30 * time.Second
+
+// DefaultShellDenylist blocks commands that are obviously destructive, such as deleting the
+// root or home directory, overwriting disks and fork bombs.
+var DefaultShellDenylist = []string{
+	`re:\brm\s+(-\S+\s+)*(/|/\*|~|~/|\$HOME)(\s|;|$)`,
+	"dd if=",
+	`re:\bmkfs(\.\w+)?\b`,
+	":(){ :|:& };:",
+	`re:>\s*/dev/(sd|hd|nvme|disk)`,
+	`re:\bchmod\s+(-\S+\s+)*777\s+/(\s|;|$)`,
+}
 
 // RunShellCommandArgs defines the arguments for the RunShellCommandTool.
 type RunShellCommandArgs struct {
-	Command   string `json:"command"`
-	Directory string `json:"directory,omitempty"` // Optional directory to run the command in
+	Command        string   `json:"command"`
+	Directory      string   `json:"directory,omitempty"`       // Optional directory to run the command in
+	TimeoutSeconds int      `json:"timeout_seconds,omitempty"` // Optional limit on the run time
+	Stdin          string   `json:"stdin,omitempty"`           // Optional data written to the command's stdin
+	Env            []string `json:"env,omitempty"`             // Optional KEY=VALUE pairs added to the environment
 }
 
 func (t *RunShellCommandTool) Name() string {
@@ -24,7 +54,9 @@ func (t *RunShellCommandTool) Name() string {
 func (t *RunShellCommandTool) Description() string {
 	return `Executes a shell command on the user's operating system and returns the combined output from stdout and stderr. 
 This tool is powerful and can modify system state. 
-Usage: {"command": "<command_to_run>", "directory": "<optional_path>"}`
+Commands are killed after timeout_seconds (default 30); raise it for long builds or tests.
+Data can be piped to the command with stdin, and extra environment variables set with env.
+Usage: {"command": "<command_to_run>", "directory": "<optional_path>", "timeout_seconds": 30, "stdin": "<optional_input>", "env": ["KEY=value"]}`
 }
 
 func (t *RunShellCommandTool) Parameters() any {
@@ -33,12 +65,27 @@ func (t *RunShellCommandTool) Parameters() any {
 		"properties": map[string]any{
 			"command": map[string]any{
 				"type":        "string",
+				"minLength":   1,
 				"description": "The shell command to execute.",
 			},
 			"directory": map[string]any{
 				"type":        "string",
 				"description": "Optional: The working directory where the command should be executed. If not provided, it uses the current directory of the application.",
 			},
+			"timeout_seconds": map[string]any{
+				"type":        "integer",
+				"minimum":     0,
+				"description": "Optional: How long the command may run before it is killed. Defaults to 30 seconds.",
+			},
+			"stdin": map[string]any{
+				"type":        "string",
+				"description": "Optional: Data to pass to the command on standard input.",
+			},
+			"env": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Optional: Environment variables as KEY=VALUE strings, added to the current environment.",
+			},
 		},
 		"required": []string{"command"},
 	}
@@ -49,6 +96,35 @@ func (t *RunShellCommandTool) RequiresConfirmation() bool {
 	return true
 }
 
+func (t *RunShellCommandTool) ValidateArgs(args string) error {
+	return validateAgainstSchema(t, args)
+}
+
+// checkDenylist returns an error quoting the part of command matched by the first denylist pattern.
+func (t *RunShellCommandTool) checkDenylist(command string) error {
+	denylist := t.ShellCommandDenylist
+	if denylist == nil {
+		denylist = DefaultShellDenylist
+	}
+
+	// Collapse runs of whitespace so "rm  -rf /" cannot slip past "rm -rf /"
+	normalized := strings.Join(strings.Fields(command), " ")
+	for _, pattern := range denylist {
+		if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("invalid shell denylist pattern '%s': %w", pattern, err)
+			}
+			if match := re.FindString(normalized); match != "" {
+				return fmt.Errorf("Command blocked by policy: %s", strings.TrimSpace(match))
+			}
+		} else if pattern = strings.Join(strings.Fields(pattern), " "); pattern != "" && strings.Contains(normalized, pattern) {
+			return fmt.Errorf("Command blocked by policy: %s", pattern)
+		}
+	}
+	return nil
+}
+
 // Execute runs the shell command.
 func (t *RunShellCommandTool) Execute(args string) (string, error) {
 	var toolArgs RunShellCommandArgs
@@ -60,13 +136,27 @@ func (t *RunShellCommandTool) Execute(args string) (string, error) {
 		return "", fmt.Errorf("command argument cannot be empty")
 	}
 
+	if err := t.checkDenylist(toolArgs.Command); err != nil {
+		return "", err
+	}
+
+	timeout := time.Duration(toolArgs.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = t.Timeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultShellTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
 	var cmd *exec.Cmd
 	if runtime.GOOS == "windows" {
 		// Windows 系统
-		cmd = exec.Command("cmd", "/C", toolArgs.Command)
+		cmd = exec.CommandContext(ctx, "cmd", "/C", toolArgs.Command)
 	} else {
 		// Linux, macOS, and other Unix-like systems
-		cmd = exec.Command("sh", "-c", toolArgs.Command)
+		cmd = exec.CommandContext(ctx, "sh", "-c", toolArgs.Command)
 	}
 
 	// Set the working directory if provided.
@@ -74,9 +164,34 @@ func (t *RunShellCommandTool) Execute(args string) (string, error) {
 		cmd.Dir = toolArgs.Directory
 	}
 
-	// Use CombinedOutput to get both stdout and stderr in one slice.
-	output, err := cmd.CombinedOutput()
+	if toolArgs.Stdin != "" {
+		cmd.Stdin = strings.NewReader(toolArgs.Stdin)
+	}
+
+	if len(toolArgs.Env) > 0 {
+		for _, kv := range toolArgs.Env {
+			if !strings.Contains(kv, "=") || strings.HasPrefix(kv, "=") {
+				return "", fmt.Errorf("invalid env entry '%s': expected KEY=VALUE", kv)
+			}
+		}
+		// Later entries win, so the extra variables override inherited ones.
+		cmd.Env = append(os.Environ(), toolArgs.Env...)
+	}
 
+	// Collect stdout and stderr together, as CombinedOutput would, but keep what was
+	// written so far when the command is killed. WaitDelay stops Wait from blocking on
+	// child processes that still hold the pipes open.
+	var combined bytes.Buffer
+	cmd.Stdout = &combined
+	cmd.Stderr = &combined
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	output := combined.Bytes()
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "", fmt.Errorf("command timed out after %s and was killed\nPartial output:\n%s", timeout, string(output))
+	}
 	if err != nil {
 		// If there was an error (e.g., non-zero exit code), we still want to return the output,
 		// as it often contains the error message from the command itself.
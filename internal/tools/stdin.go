@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// --- InjectStdinTool ---
+
+// InjectStdinTool lets the user paste raw multi-line text (e.g. the output of a command they ran
+// elsewhere) into the conversation. Interactive front ends collect the text themselves; Execute
+// reads standard input until EOF for non-interactive use.
+type InjectStdinTool struct{}
+
+func (t *InjectStdinTool) Name() string {
+	return "inject_stdin"
+}
+
+func (t *InjectStdinTool) RequiresConfirmation() bool {
+	return false
+}
+
+func (t *InjectStdinTool) RequiresUserInput() bool {
+	return true
+}
+
+func (t *InjectStdinTool) Description() string {
+	return "Asks the user to paste raw text, such as terminal output from a command they ran themselves, and returns it verbatim. Use this when you need to see output you cannot produce with other tools. Usage: {}"
+}
+
+func (t *InjectStdinTool) Parameters() any {
+	return map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}
+}
+
+func (t *InjectStdinTool) Execute(args string) (string, error) {
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("error reading from stdin: %w", err)
+	}
+	return FormatPastedContent(string(content)), nil
+}
+
+// FormatPastedContent labels user-pasted text so the model can tell it apart from tool output.
+func FormatPastedContent(content string) string {
+	return "[pasted content]\n" + content
+}
@@ -1,5 +1,12 @@
 package tools
 
+import "context"
+
+// ToolAPIVersion is the version of the Tool interface contract. It is increased whenever
+// the interface changes incompatibly, so that outdated tool plugins are rejected.
+// Plugins export it as `var ToolAPIVersion = tools.ToolAPIVersion`.
+const ToolAPIVersion = 1
+
 // Tool represents a function that can be called by the agent.
 type Tool interface {
 	// Name is the name of the tool, as it would be called by the model.
@@ -14,3 +21,81 @@ type Tool interface {
 	// RequiresConfirmation indicates whether the tool requires user confirmation before execution.
 	RequiresConfirmation() bool
 }
+
+// ArgsValidator is implemented by tools that can check their arguments before Execute is called.
+// A validation error is returned to the model as the tool result, prefixed with "Argument error: ",
+// so it can retry with corrected arguments.
+type ArgsValidator interface {
+	ValidateArgs(args string) error
+}
+
+// Completer sends a single prompt to the LLM and returns its text response.
+// It lets tools call the model without depending on the llm package.
+type Completer interface {
+	Complete(prompt string) (string, error)
+}
+
+// ClientAware is implemented by tools that need to call the LLM themselves.
+// The agent injects a Completer when the tool is registered.
+type ClientAware interface {
+	SetCompleter(c Completer)
+}
+
+// PathRestricted is implemented by tools that read or write files. The agent sets a base
+// path to keep them from accessing anything outside of that directory.
+type PathRestricted interface {
+	SetBasePath(dir string)
+}
+
+// ConfirmationSummarizer is implemented by tools that can describe the effect of a call
+// in more detail than its raw arguments. The summary is shown in the confirmation dialog.
+type ConfirmationSummarizer interface {
+	ConfirmationSummary(args string) string
+}
+
+// ArgsConfirmer is implemented by tools for which only some calls need confirmation,
+// such as a query tool that may read or write. RequiresConfirmation should then report
+// true, and RequiresConfirmationFor decides for each call.
+type ArgsConfirmer interface {
+	RequiresConfirmationFor(args string) bool
+}
+
+// NeedsConfirmation reports whether a call of tool with args needs user confirmation.
+func NeedsConfirmation(tool Tool, args string) bool {
+	if confirmer, ok := tool.(ArgsConfirmer); ok {
+		return confirmer.RequiresConfirmationFor(args)
+	}
+	return tool.RequiresConfirmation()
+}
+
+// FileModifier is implemented by tools that modify files even when they do not ask for
+// confirmation, such as create_directory. Read-only mode refuses them like the tools that do.
+type FileModifier interface {
+	ModifiesFiles() bool
+}
+
+// ModifiesFiles reports whether tool declares that it modifies files.
+func ModifiesFiles(tool Tool) bool {
+	modifier, ok := tool.(FileModifier)
+	return ok && modifier.ModifiesFiles()
+}
+
+// ContextExecutor is implemented by long-running tools, such as watch_file, that should
+// stop when the agent's turn is cancelled. The agent calls ExecuteContext instead of Execute.
+type ContextExecutor interface {
+	ExecuteContext(ctx context.Context, args string) (string, error)
+}
+
+// ExecuteContext runs tool with args, passing ctx on to tools that accept one.
+func ExecuteContext(ctx context.Context, tool Tool, args string) (string, error) {
+	if executor, ok := tool.(ContextExecutor); ok {
+		return executor.ExecuteContext(ctx, args)
+	}
+	return tool.Execute(args)
+}
+
+// UserInputTool is implemented by tools whose result is typed or pasted by the user.
+// Interactive front ends collect the input themselves instead of calling Execute.
+type UserInputTool interface {
+	RequiresUserInput() bool
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// JSONSchemaValidator validates tool arguments against the JSON Schema returned by a tool's Parameters.
+type JSONSchemaValidator struct {
+	schema *jsonschema.Schema
+}
+
+// NewJSONSchemaValidator compiles the given parameters schema.
+func NewJSONSchemaValidator(parameters any) (*JSONSchemaValidator, error) {
+	// Parameters are built from Go maps and slices (e.g. []string), so round-trip them
+	// through JSON to get the plain document the compiler expects.
+	raw, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling parameters schema: %w", err)
+	}
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(string(raw)))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing parameters schema: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("parameters.json", doc); err != nil {
+		return nil, fmt.Errorf("error loading parameters schema: %w", err)
+	}
+	schema, err := compiler.Compile("parameters.json")
+	if err != nil {
+		return nil, fmt.Errorf("error compiling parameters schema: %w", err)
+	}
+
+	return &JSONSchemaValidator{schema: schema}, nil
+}
+
+// ValidateArgs checks a JSON argument string against the schema and returns a model-readable error.
+func (v *JSONSchemaValidator) ValidateArgs(args string) error {
+	instance, err := jsonschema.UnmarshalJSON(strings.NewReader(args))
+	if err != nil {
+		return fmt.Errorf("arguments are not valid JSON: %v", err)
+	}
+
+	err = v.schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+
+	printer := message.NewPrinter(language.English)
+	var problems []string
+	collectValidationProblems(validationErr, printer, &problems)
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// collectValidationProblems flattens the leaf causes of a validation error into short messages.
+func collectValidationProblems(err *jsonschema.ValidationError, printer *message.Printer, problems *[]string) {
+	if len(err.Causes) > 0 {
+		for _, cause := range err.Causes {
+			collectValidationProblems(cause, printer, problems)
+		}
+		return
+	}
+
+	if required, ok := err.ErrorKind.(*kind.Required); ok {
+		for _, missing := range required.Missing {
+			*problems = append(*problems, fmt.Sprintf("'%s' is required", missing))
+		}
+		return
+	}
+
+	location := strings.Join(err.InstanceLocation, ".")
+	if location == "" {
+		*problems = append(*problems, err.ErrorKind.LocalizedString(printer))
+		return
+	}
+	*problems = append(*problems, fmt.Sprintf("'%s': %s", location, err.ErrorKind.LocalizedString(printer)))
+}
+
+var (
+	schemaValidatorsMu sync.Mutex
+	schemaValidators   = make(map[string]*JSONSchemaValidator)
+)
+
+// validateAgainstSchema validates args against the tool's Parameters schema, compiling it once per tool.
+func validateAgainstSchema(t Tool, args string) error {
+	schemaValidatorsMu.Lock()
+	validator, ok := schemaValidators[t.Name()]
+	if !ok {
+		var err error
+		validator, err = NewJSONSchemaValidator(t.Parameters())
+		if err != nil {
+			schemaValidatorsMu.Unlock()
+			return err
+		}
+		schemaValidators[t.Name()] = validator
+	}
+	schemaValidatorsMu.Unlock()
+
+	return validator.ValidateArgs(args)
+}
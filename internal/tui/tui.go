@@ -1,32 +1,180 @@
 package tui
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"tachigoma/internal/llm"
-
+	"tachigoma/internal/tools"
+	"time"
+	"unicode"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
 var (
-	helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	diffAddStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	diffDelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
 )
 
 // model is the state of our TUI application.
 type model struct {
 	viewport        viewport.Model
 	textarea        textarea.Model
-	agent           *llm.Agent   // The new core logic handler
-	sub             chan tea.Msg // Channel for receiving streaming messages
+	agent           *llm.Agent         // The new core logic handler
+	sub             chan tea.Msg       // Channel for receiving streaming messages
+	spinner         spinner.Model      // Animated while waiting for the first streamed content
+	cancelFunc      context.CancelFunc // Cancels the current turn's streaming request
 	loading         bool
 	lastContent     string // Stores the live content of the current streaming message
 	err             error
-	availableHeight int  // Available height for the viewport
-	ready           bool // Whether the UI has been sized and is ready for rendering
+	availableHeight int               // Available height for the viewport
+	ready           bool              // Whether the UI has been sized and is ready for rendering
+	boundaries      []MessageBoundary // Line offsets of each message header in the rendered conversation
+	evalScore       string            // Result of the latest automatic evaluation, shown in the help bar
+	notice          string            // Status line shown below the conversation until the next prompt
+	warning         string            // Warning shown in yellow below the conversation until the next prompt
+	showHelp        bool              // Whether the keyboard shortcut overlay is shown
+	width, height   int               // Terminal size
+
+	// Search (Ctrl+F): the search bar replaces the textarea while open
+	searchMode    bool            // Whether the search bar is shown
+	searchInput   textinput.Model // Focused while the query is being typed
+	searchQuery   string          // Query whose matches are highlighted
+	searchMatches []int           // Line of every match in the rendered conversation
+	searchIndex   int             // Index in searchMatches of the current match
+
+	// Input history: Up on the first line and Down on the last line of the textarea recall prompts
+	history      []string // Prompts sent in this run, oldest first
+	historyIndex int      // Entry shown in the textarea; len(history) means the unsent draft
+	historyDraft string   // Text typed before browsing the history, restored after the newest entry
+
+	// Confirmation dialog: the rendered tool details are cached per tool call and scroll when long
+	confirmID       string         // ID of the tool call the details belong to
+	confirmViewport viewport.Model // Tool details, scrolled with j/k
+
+	markdown *markdownRenderer // Renders responses in the selected theme; shared by copies of the model
+
+	price       *llm.ModelPrice // Price of the model, nil if the cost is unknown
+	costWarning float64         // Session cost in USD above which the estimate turns red
+
+	// Demo mode: streamed content is revealed one word at a time
+	demoDelay        time.Duration     // Delay between revealed words; zero disables demo mode
+	revealBuffer     string            // Streamed content not yet shown in the viewport
+	revealing        bool              // Whether a reveal tick is scheduled
+	pendingStreamEnd *llm.StreamEndMsg // Stream end deferred until the buffer is drained
+}
+
+// Option configures optional TUI behaviour.
+type Option func(*model)
+
+// WithDemoMode reveals streamed responses word by word with the given delay between words.
+func WithDemoMode(delay time.Duration) Option {
+	return func(m *model) {
+		m.demoDelay = delay
+	}
+}
+
+// WithCostEstimate shows the estimated cost of the session next to its token count, in red
+// once it exceeds warnAt USD.
+func WithCostEstimate(price llm.ModelPrice, warnAt float64) Option {
+	return func(m *model) {
+		m.price = &price
+		m.costWarning = warnAt
+	}
+}
+
+// Themes are the color schemes accepted by WithTheme. "auto" picks dark or light to suit the
+// terminal's background, and "none" shows responses as raw text.
+var Themes = []string{"auto", "dark", "light", "dracula", "tokyo-night", "none"}
+
+// ValidTheme reports whether name is one of Themes. An empty name means "auto".
+func ValidTheme(name string) bool {
+	return name == "" || slices.Contains(Themes, name)
+}
+
+// WithTheme selects the color scheme responses are rendered in; see Themes.
+func WithTheme(theme string) Option {
+	return func(m *model) {
+		m.markdown.setTheme(theme)
+	}
+}
+
+// ThemeMsg switches the color scheme while the TUI runs, e.g. after the config file changed.
+type ThemeMsg struct {
+	Theme string
+}
+
+// markdownRenderer renders responses with glamour. Creating a renderer is expensive, so it is
+// kept until the theme changes.
+type markdownRenderer struct {
+	theme    string
+	renderer *glamour.TermRenderer
+	dirty    bool // The renderer does not match theme and must be rebuilt
+}
+
+func newMarkdownRenderer() *markdownRenderer {
+	return &markdownRenderer{theme: "auto", dirty: true}
+}
+
+// setTheme switches to theme, rebuilding the renderer on the next render if it changed.
+func (r *markdownRenderer) setTheme(theme string) {
+	if theme == "" {
+		theme = "auto"
+	}
+	if theme != r.theme {
+		r.theme, r.dirty = theme, true
+	}
+}
+
+// render returns content rendered as markdown, or unchanged for the "none" theme or if it
+// cannot be rendered.
+func (r *markdownRenderer) render(content string) string {
+	if r.dirty {
+		r.renderer, r.dirty = nil, false
+		switch r.theme {
+		case "none":
+		case "auto":
+			r.renderer, _ = glamour.NewTermRenderer(glamour.WithAutoStyle())
+		default:
+			r.renderer, _ = glamour.NewTermRenderer(glamour.WithStandardStyle(r.theme))
+		}
+	}
+	if r.renderer == nil {
+		return content + "\n\n"
+	}
+
+	rendered, err := r.renderer.Render(content)
+	if err != nil {
+		return content + "\n\n"
+	}
+	return rendered
+}
+
+// revealTickMsg releases the next buffered word in demo mode.
+type revealTickMsg struct{}
+
+// MessageBoundary marks the line where a message header ("You:" or "Tachigoma:") starts.
+type MessageBoundary struct {
+	lineOffset int
+	role       string
 }
 
 // --- TUI Messages ---
@@ -45,27 +193,546 @@ func (m *model) safeGotoBottom() {
 	}
 }
 
+// setConversation renders the conversation into the viewport.
+// Message boundaries are only recomputed on full renders.
+func (m *model) setConversation(fullRender bool) {
+	content, boundaries := m.renderConversation(fullRender)
+	if fullRender {
+		m.boundaries = boundaries
+	}
+	if m.searchQuery != "" {
+		content = m.highlightMatches(content)
+	}
+	m.viewport.SetContent(content)
+}
+
+var (
+	searchMatchStyle   = lipgloss.NewStyle().Background(lipgloss.Color("220")).Foreground(lipgloss.Color("0"))
+	searchCurrentStyle = lipgloss.NewStyle().Background(lipgloss.Color("208")).Foreground(lipgloss.Color("0")).Bold(true)
+)
+
+// highlightMatches highlights every case-insensitive occurrence of the search query in content
+// and records the line of each one. Lines with a match lose their other styling, because
+// matches may span the escape sequences of the rendered markdown.
+func (m *model) highlightMatches(content string) string {
+	query := strings.ToLower(m.searchQuery)
+	m.searchMatches = m.searchMatches[:0]
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		plain := ansi.Strip(line)
+		lower := strings.ToLower(plain)
+		if len(lower) != len(plain) {
+			lower = plain // Lowercasing changed the byte offsets; match case-sensitively instead
+		}
+		if !strings.Contains(lower, query) {
+			continue
+		}
+
+		var b strings.Builder
+		rest, lowerRest := plain, lower
+		for {
+			at := strings.Index(lowerRest, query)
+			if at < 0 {
+				break
+			}
+			style := searchMatchStyle
+			if len(m.searchMatches) == m.searchIndex {
+				style = searchCurrentStyle
+			}
+			b.WriteString(rest[:at] + style.Render(rest[at:at+len(query)]))
+			rest, lowerRest = rest[at+len(query):], lowerRest[at+len(query):]
+			m.searchMatches = append(m.searchMatches, i)
+		}
+		b.WriteString(rest)
+		lines[i] = b.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// openSearch shows the search bar with the previous query ready to be edited.
+func (m *model) openSearch() tea.Cmd {
+	m.searchMode = true
+	m.searchInput.SetValue(m.searchQuery)
+	m.searchInput.CursorEnd()
+	return m.searchInput.Focus()
+}
+
+// closeSearch hides the search bar and removes the highlights.
+func (m *model) closeSearch() {
+	m.searchMode = false
+	m.searchInput.Blur()
+	m.searchQuery = ""
+	m.searchMatches = nil
+	m.searchIndex = 0
+	m.setConversation(!m.loading)
+}
+
+// runSearch highlights the matches of the typed query and scrolls to the first one at or below the top of the viewport.
+func (m *model) runSearch() {
+	m.searchInput.Blur()
+	m.searchQuery = m.searchInput.Value()
+	m.searchIndex = 0
+	m.setConversation(!m.loading)
+	for i, line := range m.searchMatches {
+		if line >= m.viewport.YOffset {
+			m.searchIndex = i
+			break
+		}
+	}
+	m.gotoMatch(0)
+}
+
+// gotoMatch moves delta matches forward or back, wrapping around, and scrolls the current match into view.
+func (m *model) gotoMatch(delta int) {
+	if len(m.searchMatches) == 0 {
+		return
+	}
+	m.searchIndex = ((m.searchIndex+delta)%len(m.searchMatches) + len(m.searchMatches)) % len(m.searchMatches)
+	m.setConversation(!m.loading) // Move the current match highlight
+	m.viewport.SetYOffset(max(0, m.searchMatches[m.searchIndex]-m.viewport.Height/2))
+}
+
+// updateSearch handles a key press while the search bar is open. It reports false for keys
+// it leaves to the normal handling.
+func (m *model) updateSearch(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return nil, false
+	case tea.KeyEsc:
+		m.closeSearch()
+		return nil, true
+	case tea.KeyEnter:
+		if m.searchInput.Focused() {
+			m.runSearch()
+		}
+		return nil, true
+	case tea.KeyCtrlF:
+		return m.searchInput.Focus(), true
+	}
+
+	if m.searchInput.Focused() {
+		var cmd tea.Cmd
+		m.searchInput, cmd = m.searchInput.Update(msg)
+		return cmd, true
+	}
+
+	switch msg.String() {
+	case "n":
+		m.gotoMatch(1)
+	case "N":
+		m.gotoMatch(-1)
+	default:
+		// Scroll keys still move the conversation
+		var cmd tea.Cmd
+		m.viewport, cmd = m.viewport.Update(msg)
+		return cmd, true
+	}
+	return nil, true
+}
+
+// searchView renders the search bar in place of the textarea.
+func (m model) searchView() string {
+	status := ""
+	if m.searchQuery != "" && !m.searchInput.Focused() {
+		if len(m.searchMatches) == 0 {
+			status = "  无匹配"
+		} else {
+			status = fmt.Sprintf("  %d/%d", m.searchIndex+1, len(m.searchMatches))
+		}
+	}
+	return lipgloss.NewStyle().Height(m.textarea.Height()).Render(m.searchInput.View() + helpStyle.Render(status))
+}
+
+// enterPasteMode prepares the textarea to collect arbitrary multi-line text for an input tool.
+func (m *model) enterPasteMode() {
+	m.textarea.Reset()
+	m.textarea.MaxHeight = 0 // Pasted output can be much longer than a normal prompt
+	m.textarea.Placeholder = "粘贴内容... (Ctrl+D 提交)"
+}
+
+// exitPasteMode restores the textarea for normal prompts.
+func (m *model) exitPasteMode() {
+	m.textarea.Reset()
+	m.textarea.MaxHeight = textarea.New().MaxHeight
+	m.textarea.Placeholder = "输入你的问题... (Enter 发送)"
+}
+
+// finishStream completes a streamed turn and starts any follow-up work.
+func (m *model) finishStream(msg llm.StreamEndMsg) tea.Cmd {
+	m.pendingStreamEnd = nil
+	m.agent.HandleStreamEnd(msg)
+	m.loading = false
+	m.sub = nil
+	m.lastContent = ""
+	m.setConversation(true)
+	m.safeGotoBottom()
+	return tea.Batch(m.agent.EvaluateLastResponse(), m.agent.AutoSummarize())
+}
+
+// revealTick schedules the next word of a demo mode reveal.
+func (m model) revealTick() tea.Cmd {
+	return tea.Tick(m.demoDelay, func(time.Time) tea.Msg {
+		return revealTickMsg{}
+	})
+}
+
+// nextWord splits s after its first word, keeping leading whitespace with the word.
+func nextWord(s string) (string, string) {
+	start := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsSpace(r) })
+	if start < 0 {
+		return s, ""
+	}
+	end := strings.IndexFunc(s[start:], unicode.IsSpace)
+	if end < 0 {
+		return s, ""
+	}
+	return s[:start+end], s[start+end:]
+}
+
+// jumpToBoundary scrolls the viewport to the next (forward) or previous message header.
+func (m *model) jumpToBoundary(forward bool) {
+	offset := m.viewport.YOffset
+	if forward {
+		for _, boundary := range m.boundaries {
+			if boundary.lineOffset > offset {
+				m.viewport.SetYOffset(boundary.lineOffset)
+				return
+			}
+		}
+		return
+	}
+
+	for i := len(m.boundaries) - 1; i >= 0; i-- {
+		if m.boundaries[i].lineOffset < offset {
+			m.viewport.SetYOffset(m.boundaries[i].lineOffset)
+			return
+		}
+	}
+}
+
 // updateViewportHeight adjusts the viewport height based on confirmation state.
+// It also refreshes the cached confirmation details when a new tool call needs confirming.
 func (m *model) updateViewportHeight() {
 	viewState := m.agent.GetViewState()
+	if !viewState.IsConfirming {
+		m.confirmID = ""
+	} else if viewState.ConfirmingToolCall.ID != m.confirmID {
+		m.confirmID = viewState.ConfirmingToolCall.ID
+		details := confirmationDetails(viewState.ConfirmingToolCall)
+		// The dialog's border and padding take 6 columns
+		m.confirmViewport = viewport.New(max(m.width-6, 0), min(len(details), confirmationMaxLines))
+		m.confirmViewport.SetContent(strings.Join(details, "\n"))
+	}
+
+	height := m.availableHeight
+	if planBox := renderPlanBox(viewState); planBox != "" {
+		height -= lipgloss.Height(planBox)
+	}
 	if viewState.IsConfirming {
-		// Create a temporary confirmation box to measure its height
-		confirmStyle := lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("205")).
-			Padding(1, 2)
-
-		question := fmt.Sprintf(
-			"Tachigoma wants to run the tool: %s\n\nArguments:\n%s\n\nDo you want to allow this?",
-			viewState.ConfirmingToolCall.Function.Name,
-			viewState.ConfirmingToolCall.Function.Arguments,
-		)
-		confirmationBox := confirmStyle.Render(question)
-		confirmationBoxHeight := lipgloss.Height(confirmationBox)
-		m.viewport.Height = m.availableHeight - confirmationBoxHeight
-	} else {
-		m.viewport.Height = m.availableHeight
+		// Render the confirmation box to measure its height
+		height -= lipgloss.Height(m.renderConfirmationBox(viewState))
+	}
+	m.viewport.Height = height
+}
+
+// confirmationMaxLines caps the tool details shown in the confirmation dialog; longer details scroll.
+const confirmationMaxLines = 20
+
+// renderConfirmationBox renders the dialog asking the user to allow a tool call.
+func (m model) renderConfirmationBox(viewState llm.ViewState) string {
+	confirmStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("205")).
+		Padding(1, 2)
+
+	details := m.confirmViewport.View()
+	if total := m.confirmViewport.TotalLineCount(); total > m.confirmViewport.Height {
+		first := m.confirmViewport.YOffset + 1
+		last := m.confirmViewport.YOffset + m.confirmViewport.Height
+		details += "\n" + helpStyle.Render(fmt.Sprintf("lines %d-%d of %d (j/k to scroll)", first, last, total))
+	}
+
+	question := fmt.Sprintf(
+		"Tachigoma wants to run the tool: %s\n\n%s",
+		viewState.ConfirmingToolCall.Function.Name,
+		details,
+	)
+	if viewState.ConfirmationSummary != "" {
+		question += "\n\n" + viewState.ConfirmationSummary
+	}
+	question += "\n\nDo you want to allow this?"
+
+	return confirmStyle.Render(question)
+}
+
+// ConfirmationRenderer renders a tool call for the confirmation dialog in a more readable
+// form than its raw JSON arguments. An empty result falls back to the arguments.
+type ConfirmationRenderer interface {
+	RenderConfirmation(toolCall llm.ToolCall) string
+}
+
+// confirmationRenderers holds the custom renderers, keyed by tool name.
+var confirmationRenderers = map[string]ConfirmationRenderer{
+	"write_file":        diffRenderer{},
+	"replace":           diffRenderer{},
+	"insert_lines":      diffRenderer{},
+	"delete_lines":      diffRenderer{},
+	"run_shell_command": shellRenderer{},
+}
+
+// confirmationDetails returns the lines describing toolCall in the confirmation dialog.
+func confirmationDetails(toolCall llm.ToolCall) []string {
+	if renderer, ok := confirmationRenderers[toolCall.Function.Name]; ok {
+		if rendered := renderer.RenderConfirmation(toolCall); rendered != "" {
+			return strings.Split(rendered, "\n")
+		}
+	}
+	return strings.Split("Arguments:\n"+toolCall.Function.Arguments, "\n")
+}
+
+// diffRenderer shows the change a file-editing call such as write_file or replace would make
+// as a colored line diff.
+// New files, and replacements whose effect cannot be predicted, are shown as a highlighted
+// preview of the new text instead.
+type diffRenderer struct{}
+
+// diffContextLines is the number of unchanged lines shown around each change.
+const diffContextLines = 3
+
+func (diffRenderer) RenderConfirmation(toolCall llm.ToolCall) string {
+	path, before, after, ok := proposedChange(toolCall)
+	if !ok {
+		return newTextPreview(toolCall)
+	}
+	if before == "" {
+		return "New file " + path + ":\n" + codePreview(path, after)
+	}
+	if before == after {
+		return fmt.Sprintf("No changes to %s", path)
+	}
+
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(before, after)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	type diffLine struct {
+		op   diffmatchpatch.Operation
+		text string
+	}
+	var all []diffLine
+	for _, d := range diffs {
+		for _, line := range strings.Split(strings.TrimSuffix(d.Text, "\n"), "\n") {
+			all = append(all, diffLine{d.Type, line})
+		}
+	}
+
+	// Keep changed lines and the unchanged lines close to them.
+	visible := make([]bool, len(all))
+	for i, line := range all {
+		if line.op == diffmatchpatch.DiffEqual {
+			continue
+		}
+		for j := max(0, i-diffContextLines); j <= min(len(all)-1, i+diffContextLines); j++ {
+			visible[j] = true
+		}
+	}
+
+	var out []string
+	out = append(out, "Changes to "+path+":")
+	skipped := false
+	for i, line := range all {
+		if !visible[i] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			out = append(out, helpStyle.Render("···"))
+			skipped = false
+		}
+		switch line.op {
+		case diffmatchpatch.DiffInsert:
+			out = append(out, diffAddStyle.Render("+ "+line.text))
+		case diffmatchpatch.DiffDelete:
+			out = append(out, diffDelStyle.Render("- "+line.text))
+		default:
+			out = append(out, "  "+line.text)
+		}
+	}
+	if skipped {
+		out = append(out, helpStyle.Render("···"))
+	}
+	return strings.Join(out, "\n")
+}
+
+// newTextPreview previews the new_string of a replace call whose change cannot be predicted,
+// e.g. because old_string is not in the file. It returns "" for other calls.
+func newTextPreview(toolCall llm.ToolCall) string {
+	if toolCall.Function.Name != "replace" {
+		return ""
+	}
+	var args tools.ReplaceArgs
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil || args.Path == "" {
+		return ""
+	}
+	return "Replacement text for " + args.Path + ":\n" + codePreview(args.Path, args.NewString)
+}
+
+// codePreviewLines is the number of lines of new file content shown for confirmation.
+const codePreviewLines = 20
+
+// codePreview syntax-highlights the start of code, choosing the language from path.
+func codePreview(path, code string) string {
+	lines := strings.Split(strings.TrimSuffix(code, "\n"), "\n")
+	more := len(lines) - codePreviewLines
+	if more > 0 {
+		lines = lines[:codePreviewLines]
+	}
+
+	preview := highlightCode(path, strings.Join(lines, "\n"))
+	if more > 0 {
+		preview += "\n" + helpStyle.Render(fmt.Sprintf("... (%d more lines)", more))
+	}
+	return preview
+}
+
+// highlightCode colors code for the terminal, or returns it unchanged if the language of
+// path is unknown.
+func highlightCode(path, code string) string {
+	lexer := lexers.Match(filepath.Base(path))
+	if lexer == nil {
+		return code
+	}
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return code
+	}
+
+	var b strings.Builder
+	if err := formatters.TTY256.Format(&b, styles.Get("monokai"), iterator); err != nil {
+		return code
+	}
+
+	// Tokens may span lines, so reset the color at the end of each line to keep it
+	// from leaking into the dialog's border.
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	for i := range lines {
+		lines[i] += ansi.ResetStyle
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shellCommandStyle highlights the command of a run_shell_command call.
+var shellCommandStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Bold(true)
+
+// shellRenderer shows the command a run_shell_command call would run, followed by its options.
+type shellRenderer struct{}
+
+func (shellRenderer) RenderConfirmation(toolCall llm.ToolCall) string {
+	var args tools.RunShellCommandArgs
+	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil || args.Command == "" {
+		return ""
 	}
+
+	out := []string{"Command:", shellCommandStyle.Render(args.Command)}
+	if args.Directory != "" {
+		out = append(out, "Directory: "+args.Directory)
+	}
+	if args.TimeoutSeconds > 0 {
+		out = append(out, fmt.Sprintf("Timeout: %ds", args.TimeoutSeconds))
+	}
+	if len(args.Env) > 0 {
+		out = append(out, "Environment: "+strings.Join(args.Env, " "))
+	}
+	if args.Stdin != "" {
+		out = append(out, "Stdin:", args.Stdin)
+	}
+	return strings.Join(out, "\n")
+}
+
+// proposedChange returns the file a file-editing call targets, with its current content
+// and the content after the call. ok is false if the change cannot be predicted.
+func proposedChange(toolCall llm.ToolCall) (path, before, after string, ok bool) {
+	switch toolCall.Function.Name {
+	case "write_file":
+		var args tools.WriteFileArgs
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil || args.Path == "" {
+			return "", "", "", false
+		}
+		current, err := os.ReadFile(args.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", "", "", false
+		}
+		return args.Path, string(current), args.Content, true
+
+	case "replace":
+		var args tools.ReplaceArgs
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil || args.Path == "" || args.OldString == "" {
+			return "", "", "", false
+		}
+		current, err := os.ReadFile(args.Path)
+		if err != nil || !strings.Contains(string(current), args.OldString) {
+			return "", "", "", false
+		}
+		n := 1
+		if args.ReplaceAll {
+			n = -1
+		}
+		return args.Path, string(current), strings.Replace(string(current), args.OldString, args.NewString, n), true
+
+	case "insert_lines":
+		var args tools.InsertLinesArgs
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil || args.Path == "" {
+			return "", "", "", false
+		}
+		current, err := os.ReadFile(args.Path)
+		if err != nil {
+			return "", "", "", false
+		}
+		modified, _ := tools.InsertLines(string(current), args.Line, args.Content, args.After)
+		return args.Path, string(current), modified, true
+
+	case "delete_lines":
+		var args tools.DeleteLinesArgs
+		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil || args.Path == "" {
+			return "", "", "", false
+		}
+		current, err := os.ReadFile(args.Path)
+		if err != nil {
+			return "", "", "", false
+		}
+		modified, _, _, err := tools.DeleteLines(string(current), args.StartLine, args.EndLine)
+		if err != nil {
+			return "", "", "", false
+		}
+		return args.Path, string(current), modified, true
+	}
+	return "", "", "", false
+}
+
+// planDisplayThreshold is the number of planned tool calls above which the plan is shown.
+const planDisplayThreshold = 3
+
+// renderPlanBox renders the model's advisory tool plan, or "" if there is nothing worth showing.
+func renderPlanBox(viewState llm.ViewState) string {
+	if len(viewState.Plan) <= planDisplayThreshold {
+		return ""
+	}
+
+	planStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("66")).
+		Padding(0, 1)
+
+	var b strings.Builder
+	b.WriteString("Planned tool calls (advisory):")
+	for i, step := range viewState.Plan {
+		b.WriteString(fmt.Sprintf("\n%d. %s", i+1, step.Tool))
+		if step.Purpose != "" {
+			b.WriteString(" - " + step.Purpose)
+		}
+	}
+	return planStyle.Render(b.String())
 }
 
 // toolResultMsg is sent when a tool has finished executing.
@@ -74,18 +741,32 @@ func (m *model) updateViewportHeight() {
 // --- TUI Commands ---
 
 // NewModel creates the initial model for the TUI.
-func NewModel(client *llm.Client, modelName string) tea.Model {
+func NewModel(client *llm.Client, modelName string, agentOpts []llm.AgentOption, opts ...Option) tea.Model {
 	ti := textarea.New()
 	ti.Placeholder = "输入你的问题... (Enter 发送)"
 	ti.Focus()
 
 	vp := viewport.New(0, 0)
 
-	return model{
-		agent:    llm.NewAgent(client, modelName),
-		textarea: ti,
-		viewport: vp,
+	si := textinput.New()
+	si.Prompt = "搜索: "
+	si.Placeholder = "输入要查找的文本 (Enter 搜索)"
+
+	sp := spinner.New(spinner.WithSpinner(spinner.Dot))
+	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+
+	m := model{
+		agent:       llm.NewAgent(client, modelName, agentOpts...),
+		textarea:    ti,
+		viewport:    vp,
+		spinner:     sp,
+		searchInput: si,
+		markdown:    newMarkdownRenderer(),
+	}
+	for _, opt := range opts {
+		opt(&m)
 	}
+	return m
 }
 
 // Init is the first command that is run when the program starts.
@@ -100,11 +781,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
 		m.availableHeight = msg.Height - m.textarea.Height() - lipgloss.Height(m.helpView())
 		m.viewport.Height = m.availableHeight
 		m.viewport.Width = msg.Width
+		m.confirmViewport.Width = max(msg.Width-6, 0)
 		m.textarea.SetWidth(msg.Width)
-		m.viewport.SetContent(m.renderConversation(true))
+		m.setConversation(true)
 		m.ready = true // Mark UI as ready after first resize
 		return m, nil
 
@@ -118,27 +801,78 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.err = nil
 		m.lastContent = ""
 		m.agent.HandleStreamStart()
-		return m, waitForActivity(m.sub)
+		m.setConversation(false)
+		m.safeGotoBottom()
+		return m, tea.Batch(waitForActivity(m.sub), m.spinner.Tick)
+
+	case spinner.TickMsg:
+		// The spinner stops once loading ends, as no further tick is scheduled.
+		if !m.loading {
+			return m, nil
+		}
+		m.spinner, cmd = m.spinner.Update(msg)
+		if m.lastContent == "" {
+			m.setConversation(false)
+		}
+		return m, cmd
 
 	case llm.StreamContentMsg:
 		m.agent.HandleStreamContent(msg.Content)
+		if m.demoDelay > 0 {
+			// The agent keeps the full content; only the viewport lags behind.
+			m.revealBuffer += msg.Content
+			if !m.revealing {
+				m.revealing = true
+				return m, tea.Batch(waitForActivity(m.sub), m.revealTick())
+			}
+			return m, waitForActivity(m.sub)
+		}
 		m.lastContent = m.agent.GetViewState().LastStreamedContent
-		m.viewport.SetContent(m.renderConversation(false))
+		m.setConversation(false)
 		m.safeGotoBottom()
 		return m, waitForActivity(m.sub)
 
-	case llm.StreamEndMsg:
-		m.loading = false
-		m.sub = nil
-		m.lastContent = ""
-		m.viewport.SetContent(m.renderConversation(true))
+	case revealTickMsg:
+		m.revealing = false
+		if m.revealBuffer == "" {
+			return m, nil
+		}
+		var word string
+		word, m.revealBuffer = nextWord(m.revealBuffer)
+		m.lastContent += word
+		m.setConversation(false)
 		m.safeGotoBottom()
+		if m.revealBuffer != "" {
+			m.revealing = true
+			return m, m.revealTick()
+		}
+		if m.pendingStreamEnd != nil {
+			return m, m.finishStream(*m.pendingStreamEnd)
+		}
+		return m, nil
+
+	case llm.StreamEndMsg:
+		if m.revealBuffer != "" {
+			// Let demo mode finish revealing before the final render.
+			m.pendingStreamEnd = &msg
+			m.sub = nil
+			return m, nil
+		}
+		return m, m.finishStream(msg)
+
+	case llm.EvaluationMsg:
+		if msg.Err != nil {
+			m.evalScore = "eval: failed"
+		} else {
+			m.evalScore = fmt.Sprintf("eval: %.1f/5", msg.Overall)
+		}
 		return m, nil
 
 	case llm.AssistantToolCallMsg:
+		m.revealBuffer = ""
 		cmd = m.agent.HandleToolCallRequest(msg)
 		m.updateViewportHeight() // Adjust height if confirmation dialog appears
-		m.viewport.SetContent(m.renderConversation(true))
+		m.setConversation(true)
 		m.safeGotoBottom()
 		// 如果流订阅通道还存在，需要继续监听以接收 StreamEndMsg
 		if m.sub != nil {
@@ -149,14 +883,64 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case llm.ToolResultMsg:
 		cmd = m.agent.HandleToolResult(msg.ToolCallID, msg.Result)
 		m.updateViewportHeight() // Adjust height as confirmation state may change
-		m.viewport.SetContent(m.renderConversation(true))
+		m.setConversation(true)
+		m.safeGotoBottom()
+		return m, cmd
+
+	case llm.ToolResultsMsg:
+		cmd = m.agent.HandleToolResults(msg.Results)
+		m.updateViewportHeight()
+		m.setConversation(true)
+		m.safeGotoBottom()
+		return m, cmd
+
+	case ThemeMsg:
+		if !ValidTheme(msg.Theme) {
+			m.warning = fmt.Sprintf("Unknown theme '%s': use %s.", msg.Theme, strings.Join(Themes, ", "))
+			return m, nil
+		}
+		m.markdown.setTheme(msg.Theme)
+		m.setConversation(!m.loading)
+		return m, nil
+
+	case llm.WarningMsg:
+		m.warning = msg.Text
+		m.setConversation(!m.loading)
+		m.safeGotoBottom()
+		return m, nil
+
+	case llm.SummaryMsg:
+		m.agent.HandleSummary(msg)
+		if msg.Err != nil {
+			m.notice = "Summarizing earlier messages failed: " + msg.Err.Error()
+		} else {
+			m.notice = "Earlier messages were summarized to save context."
+			m.warning = ""
+		}
+		m.setConversation(true)
+		m.safeGotoBottom()
+		return m, nil
+
+	case llm.PlanMsg:
+		cmd = m.agent.HandlePlan(msg)
+		m.updateViewportHeight()
+		m.setConversation(true)
 		m.safeGotoBottom()
 		return m, cmd
 
+	case llm.UserInputRequiredMsg:
+		m.enterPasteMode()
+		m.setConversation(true)
+		m.safeGotoBottom()
+		if m.sub != nil {
+			return m, waitForActivity(m.sub)
+		}
+		return m, nil
+
 	case llm.ConfirmationRequiredMsg:
 		// 工具需要确认，更新视图以显示确认对话框
 		m.updateViewportHeight()
-		m.viewport.SetContent(m.renderConversation(true))
+		m.setConversation(true)
 		m.safeGotoBottom()
 		// 如果流订阅通道还存在，需要继续监听
 		if m.sub != nil {
@@ -168,11 +952,34 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.loading = false
 		m.err = msg.Err
 		m.sub = nil
-		m.viewport.SetContent(m.renderConversation(true))
+		m.setConversation(true)
 		m.safeGotoBottom()
 		return m, nil
 
 	case tea.KeyMsg:
+		// Any key closes the help overlay.
+		if m.showHelp {
+			m.showHelp = false
+			return m, nil
+		}
+
+		// Any key skips the rest of a demo mode reveal.
+		if m.revealBuffer != "" {
+			m.lastContent += m.revealBuffer
+			m.revealBuffer = ""
+			m.setConversation(false)
+			m.safeGotoBottom()
+			if m.pendingStreamEnd != nil {
+				return m, m.finishStream(*m.pendingStreamEnd)
+			}
+		}
+
+		if m.searchMode {
+			if cmd, handled := m.updateSearch(msg); handled {
+				return m, cmd
+			}
+		}
+
 		viewState := m.agent.GetViewState()
 		if viewState.IsConfirming {
 			switch msg.String() {
@@ -184,31 +991,113 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmd = m.agent.HandleConfirmation(false)
 				m.updateViewportHeight() // Restore height after denial
 				return m, cmd
+			case "up", "k", "down", "j", "pgup", "pgdown":
+				m.confirmViewport, cmd = m.confirmViewport.Update(msg)
+				return m, cmd
+			}
+		}
+
+		// In paste mode Enter inserts a newline and Ctrl+D submits the collected text.
+		if viewState.IsAwaitingInput && msg.Type == tea.KeyCtrlD {
+			content := m.textarea.Value()
+			m.exitPasteMode()
+			cmd = m.agent.HandlePastedInput(content)
+			m.setConversation(true)
+			m.safeGotoBottom()
+			return m, cmd
+		}
+
+		// Bracketed paste delivers the whole clipboard as one message, newlines included,
+		// so it goes straight into the textarea instead of triggering any shortcut.
+		if msg.Paste {
+			m.textarea, cmd = m.textarea.Update(msg)
+			return m, cmd
+		}
+
+		// Alt+Enter and Ctrl+J insert a newline without sending; most terminals cannot report Shift+Enter.
+		if !viewState.IsConfirming && (msg.Type == tea.KeyCtrlJ || (msg.Type == tea.KeyEnter && msg.Alt)) {
+			m.textarea.InsertString("\n")
+			return m, nil
+		}
+
+		// Jump between message headers or open the help, but only while the input is empty so the keys can still be typed.
+		if m.textarea.Value() == "" && !viewState.IsConfirming && !viewState.IsAwaitingInput {
+			switch msg.String() {
+			case "?":
+				m.showHelp = true
+				return m, nil
+			case "]":
+				m.jumpToBoundary(true)
+				return m, nil
+			case "[":
+				m.jumpToBoundary(false)
+				return m, nil
 			}
 		}
 
+		if !viewState.IsConfirming && !viewState.IsAwaitingInput && m.browseHistory(msg) {
+			return m, nil
+		}
+
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			// If loading, interrupt the stream; otherwise quit
 			if m.loading {
+				if m.cancelFunc != nil {
+					m.cancelFunc()
+					m.cancelFunc = nil
+				}
 				m.loading = false
 				m.sub = nil
 				m.lastContent = ""
 				m.err = fmt.Errorf("用户中断生成")
-				m.viewport.SetContent(m.renderConversation(true))
+				m.setConversation(true)
 				m.safeGotoBottom()
 				return m, nil
 			}
 			return m, tea.Quit
 		case tea.KeyCtrlD, tea.KeyEsc:
-			// Always quit on Ctrl+D or Esc
+			// Always quit on Ctrl+D or Esc, saving the session if there is one
+			if err := m.agent.SaveSession(); err != nil {
+				return m, tea.Sequence(tea.Println("保存会话失败: "+err.Error()), tea.Quit)
+			}
+			if id := m.agent.SessionID(); id != "" {
+				return m, tea.Sequence(tea.Println("会话已保存: "+id), tea.Quit)
+			}
 			return m, tea.Quit
+		case tea.KeyCtrlF:
+			// Search the conversation
+			if !viewState.IsConfirming && !viewState.IsAwaitingInput {
+				return m, m.openSearch()
+			}
+		case tea.KeyCtrlL:
+			// Start a fresh conversation
+			if !m.loading && !viewState.IsConfirming && !viewState.IsAwaitingInput {
+				m.agent.Reset()
+				m.err = nil
+				m.evalScore = ""
+				m.notice = "Conversation cleared."
+				m.warning = ""
+				m.updateViewportHeight() // The plan is cleared
+				m.setConversation(true)
+				m.viewport.GotoTop()
+				return m, nil
+			}
 		case tea.KeyEnter:
 			prompt := strings.TrimSpace(m.textarea.Value())
-			if prompt != "" && !m.loading && !viewState.IsConfirming {
-				cmd = m.agent.HandleUserInput(prompt)
+			if prompt != "" && !m.loading && !viewState.IsConfirming && !viewState.IsAwaitingInput {
+				m.notice = ""
+				m.warning = ""
+				if m.cancelFunc != nil {
+					m.cancelFunc() // Release the finished turn's context
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				m.cancelFunc = cancel
+				cmd = m.agent.HandleUserInput(ctx, prompt)
+				m.addToHistory(prompt)
 				m.textarea.Reset()
-				m.viewport.SetContent(m.renderConversation(true))
+				m.updateViewportHeight() // The previous turn's plan is cleared
+				m.setConversation(true)
 				m.safeGotoBottom()
 				return m, cmd
 			}
@@ -225,52 +1114,216 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// addToHistory records a sent prompt and stops browsing the history.
+func (m *model) addToHistory(prompt string) {
+	if n := len(m.history); n == 0 || m.history[n-1] != prompt {
+		m.history = append(m.history, prompt)
+	}
+	m.historyIndex = len(m.history)
+	m.historyDraft = ""
+}
+
+// browseHistory handles Up on the first line and Down on the last line of the textarea
+// like a shell does, replacing the input with an older or newer prompt. It reports whether
+// the key was used.
+func (m *model) browseHistory(msg tea.KeyMsg) bool {
+	switch {
+	case msg.Type == tea.KeyUp && m.textarea.Line() == 0 && m.historyIndex > 0:
+		if m.historyIndex == len(m.history) {
+			m.historyDraft = m.textarea.Value()
+		}
+		m.historyIndex--
+		m.textarea.SetValue(m.history[m.historyIndex])
+		return true
+	case msg.Type == tea.KeyDown && m.textarea.Line() == m.textarea.LineCount()-1 && m.historyIndex < len(m.history):
+		m.historyIndex++
+		if m.historyIndex == len(m.history) {
+			m.textarea.SetValue(m.historyDraft)
+		} else {
+			m.textarea.SetValue(m.history[m.historyIndex])
+		}
+		return true
+	}
+	return false
+}
+
 // View renders the UI based on the model's state.
 func (m model) View() string {
+	if m.showHelp {
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, renderHelpOverlay())
+	}
+
 	viewState := m.agent.GetViewState()
 	var confirmationBox string
 
 	if viewState.IsConfirming {
-		confirmStyle := lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("205")).
-			Padding(1, 2)
+		confirmationBox = m.renderConfirmationBox(viewState)
+	}
 
-		question := fmt.Sprintf(
-			"Tachigoma wants to run the tool: %s\n\nArguments:\n%s\n\nDo you want to allow this?",
-			viewState.ConfirmingToolCall.Function.Name,
-			viewState.ConfirmingToolCall.Function.Arguments,
-		)
-		confirmationBox = confirmStyle.Render(question)
+	input := m.textarea.View()
+	if m.searchMode {
+		input = m.searchView()
 	}
 
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
-		confirmationBox, // Will be an empty string if not confirming
+		renderPlanBox(viewState), // Empty unless a long tool plan is active
+		confirmationBox,          // Will be an empty string if not confirming
 		m.viewport.View(),
-		m.textarea.View(),
+		input,
 		m.helpView(),
 	)
 }
 
+// keyBindings lists every shortcut shown in the help overlay.
+var keyBindings = [][2]string{
+	{"enter", "发送消息"},
+	{"alt+enter / ctrl+j", "换行"},
+	{"[ / ]", "跳到上一条 / 下一条消息（输入框为空时）"},
+	{"↑ / ↓", "上一条 / 下一条历史输入（光标在首行 / 末行时）"},
+	{"pgup / pgdown", "滚动对话"},
+	{"ctrl+f", "搜索对话；n / N 跳到下一个 / 上一个匹配，esc 关闭"},
+	{"ctrl+l", "清空对话"},
+	{"ctrl+c", "中断生成；空闲时退出"},
+	{"esc / ctrl+d", "退出（使用 --session 时保存会话）"},
+	{"y / n", "确认 / 拒绝工具调用"},
+	{"↑ / ↓, k / j, pgup / pgdown", "确认工具调用时滚动详情"},
+	{"ctrl+d", "粘贴模式下提交内容"},
+	{"?", "显示此帮助（输入框为空时）"},
+}
+
+// renderHelpOverlay renders the keyboard shortcut reference shown by the ? key.
+func renderHelpOverlay() string {
+	keyStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("63"))
+	keyWidth := 0
+	for _, binding := range keyBindings {
+		keyWidth = max(keyWidth, lipgloss.Width(binding[0]))
+	}
+
+	var b strings.Builder
+	b.WriteString(lipgloss.NewStyle().Bold(true).Render("键盘快捷键") + "\n\n")
+	for _, binding := range keyBindings {
+		b.WriteString(keyStyle.Width(keyWidth+2).Render(binding[0]) + binding[1] + "\n")
+	}
+	b.WriteString("\n" + helpStyle.Render("按任意键关闭"))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("63")).
+		Padding(1, 2).
+		Render(b.String())
+}
+
 // helpView renders the help text at the bottom.
 func (m model) helpView() string {
 	if m.agent.GetViewState().IsConfirming {
-		return helpStyle.Render("y: confirm | n: deny | esc/ctrl+d: quit")
+		help := "y: confirm | n: deny | esc/ctrl+d: quit"
+		if m.confirmViewport.TotalLineCount() > m.confirmViewport.Height {
+			help = "y: confirm | n: deny | j/k: scroll | esc/ctrl+d: quit"
+		}
+		return helpStyle.Render(help)
+	}
+	if m.agent.GetViewState().IsAwaitingInput {
+		return helpStyle.Render("paste mode | enter: newline | ctrl+d: submit | esc: quit")
+	}
+	if m.searchMode {
+		if m.searchInput.Focused() {
+			return helpStyle.Render("search | enter: find | esc: close")
+		}
+		return helpStyle.Render("search | n/N: next/prev match | ctrl+f: edit query | esc: close")
+	}
+	viewState := m.agent.GetViewState()
+	mode := ""
+	if viewState.NoTools {
+		mode = "[no tools] "
+	} else if viewState.ReadOnly {
+		mode = "[read-only] "
+	}
+	if viewState.JSONMode {
+		mode += "[json] "
 	}
 	if m.loading {
-		return helpStyle.Render("ctrl+c: 中断生成 | esc/ctrl+d: quit")
+		return helpStyle.Render(mode + "ctrl+c: 中断生成 | esc/ctrl+d: quit")
+	}
+	help := mode + "enter: send | alt+enter: newline | [/]: prev/next message | ctrl+f: search | ctrl+l: clear | ?: help | esc/ctrl+d: quit"
+	// The cost may be colored, so the bar up to it is rendered into bar and help continues after it
+	bar := ""
+	if viewState.Usage.TotalTokens > 0 {
+		help += fmt.Sprintf(" | tokens: %d", viewState.Usage.TotalTokens)
+		if m.price != nil {
+			bar = helpStyle.Render(help+" | ") + m.costView(m.price.Cost(viewState.Usage))
+			help = ""
+		}
+		help += " | model: " + viewState.Model
+	}
+	if viewState.Temperature != nil {
+		help += fmt.Sprintf(" | temp: %g", *viewState.Temperature)
+	}
+	if m.evalScore != "" {
+		help += " | " + m.evalScore
 	}
-	return helpStyle.Render("enter: send | esc/ctrl+d: quit")
+	if lastResponseTruncated(viewState.Messages) {
+		warning := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("⚠ 回复因 max_tokens 限制被截断")
+		return bar + helpStyle.Render(help+" | ") + warning
+	}
+	return bar + helpStyle.Render(help)
+}
+
+// costView renders the estimated session cost, in red once it exceeds the warning threshold.
+func (m model) costView(cost float64) string {
+	var text string
+	switch {
+	case cost < 0.001:
+		text = "<$0.001"
+	case cost < 1:
+		text = fmt.Sprintf("~$%.3f", cost)
+	default:
+		text = fmt.Sprintf("~$%.2f", cost)
+	}
+	if m.costWarning > 0 && cost > m.costWarning {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(text)
+	}
+	return helpStyle.Render(text)
+}
+
+// lastResponseTruncated reports whether the latest assistant message was cut off by the max tokens limit.
+func lastResponseTruncated(messages []llm.Message) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" {
+			return messages[i].Truncated
+		}
+	}
+	return false
+}
+
+// prettyJSON indents content as a fenced JSON code block if it is valid JSON, so that glamour
+// highlights it. Anything else is returned unchanged.
+func prettyJSON(content string) string {
+	var value any
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return content
+	}
+	indented, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return content
+	}
+	return "```json\n" + string(indented) + "\n```"
+}
+
+// timestampLabel returns t as a dim " [HH:MM:SS]" suffix for a role header, or "" if t is not set.
+func timestampLabel(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return " " + lipgloss.NewStyle().Faint(true).Render(t.Format("[15:04:05]"))
 }
 
-// renderConversation renders the message history.
-func (m model) renderConversation(fullRender bool) string {
+// renderConversation renders the message history and returns the line offset of every message header.
+func (m model) renderConversation(fullRender bool) (string, []MessageBoundary) {
 	var b strings.Builder
+	var boundaries []MessageBoundary
 	viewState := m.agent.GetViewState()
 
-	renderer, _ := glamour.NewTermRenderer(glamour.WithAutoStyle())
-
 	// Track which messages we've already rendered (to avoid duplicates when merging tool results)
 	rendered := make(map[int]bool)
 
@@ -285,7 +1338,8 @@ func (m model) renderConversation(fullRender bool) string {
 		if msg.Role == "user" {
 			roleText = "You"
 			roleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("70"))
-			b.WriteString(roleStyle.Render(roleText) + ":\n")
+			boundaries = append(boundaries, MessageBoundary{lineOffset: strings.Count(b.String(), "\n"), role: msg.Role})
+			b.WriteString(roleStyle.Render(roleText) + ":" + timestampLabel(msg.Timestamp) + "\n")
 			b.WriteString(msg.Content + "\n\n")
 			rendered[i] = true
 		} else if msg.Role == "assistant" {
@@ -324,7 +1378,8 @@ func (m model) renderConversation(fullRender bool) string {
 			// 显示 Tachigoma 标题（只显示一次）
 			roleText = "Tachigoma"
 			roleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("66"))
-			b.WriteString(roleStyle.Render(roleText) + ":\n")
+			boundaries = append(boundaries, MessageBoundary{lineOffset: strings.Count(b.String(), "\n"), role: msg.Role})
+			b.WriteString(roleStyle.Render(roleText) + ":" + timestampLabel(msg.Timestamp) + "\n")
 
 			toolCallStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))      // 橙色
 			toolArgStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("243"))       // 灰色
@@ -344,11 +1399,15 @@ func (m model) renderConversation(fullRender bool) string {
 							b.WriteString("\n\n")
 						}
 					} else {
-						renderedContent, err := renderer.Render(assistantMsg.Content)
-						if err != nil {
-							renderedContent = assistantMsg.Content
+						content := assistantMsg.Content
+						if viewState.JSONMode {
+							content = prettyJSON(content)
+						}
+						b.WriteString(m.markdown.render(content))
+						if assistantMsg.Truncated {
+							truncateStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("243")).Italic(true)
+							b.WriteString(truncateStyle.Render("  "+llm.TruncatedNote) + "\n")
 						}
-						b.WriteString(renderedContent)
 						if len(assistantMsg.ToolCalls) > 0 {
 							b.WriteString("\n")
 						}
@@ -467,12 +1526,20 @@ func (m model) renderConversation(fullRender bool) string {
 		}
 	}
 
+	if m.warning != "" {
+		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		b.WriteString(warningStyle.Render("⚠ "+m.warning) + "\n")
+	}
+
 	if m.loading && len(m.lastContent) == 0 {
-		b.WriteString("Tachigoma: ...\n")
+		b.WriteString(m.spinner.View() + " Tachigoma is thinking...\n")
 	} else if m.err != nil {
 		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
 		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v\n", m.err)))
+	} else if m.notice != "" {
+		noticeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("243")).Italic(true)
+		b.WriteString(noticeStyle.Render(m.notice) + "\n")
 	}
 
-	return b.String()
+	return b.String(), boundaries
 }